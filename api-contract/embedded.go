@@ -1,11 +1,55 @@
 package apicontract
 
-import _ "embed"
+import (
+	"embed"
+	"path"
+	"strings"
+)
 
 //go:embed openapi.gen.yml
 var specBytes []byte
 
+//go:embed asyncapi.yml
+var asyncAPISpecBytes []byte
+
+//go:embed events/*.json
+var eventSchemas embed.FS
+
 // GetSpecBytes returns the embedded OpenAPI specification as a byte slice.
 func GetSpecBytes() []byte {
 	return specBytes
 }
+
+// GetAsyncAPISpecBytes returns the embedded AsyncAPI specification of the events as a byte slice.
+func GetAsyncAPISpecBytes() []byte {
+	return asyncAPISpecBytes
+}
+
+// GetEventSchemaBytes returns the embedded JSON Schema of the payloads published to the topic,
+// false if the topic has none.
+func GetEventSchemaBytes(topic string) ([]byte, bool) {
+	b, err := eventSchemas.ReadFile("events/" + topic + ".json")
+	if err != nil {
+		return nil, false
+	}
+
+	return b, true
+}
+
+// GetEventSchemas returns the embedded JSON Schemas of the event payloads by topic.
+func GetEventSchemas() map[string][]byte {
+	entries, err := eventSchemas.ReadDir("events")
+	if err != nil {
+		return nil
+	}
+
+	schemas := make(map[string][]byte, len(entries))
+	for _, entry := range entries {
+		topic := strings.TrimSuffix(entry.Name(), path.Ext(entry.Name()))
+		if b, ok := GetEventSchemaBytes(topic); ok {
+			schemas[topic] = b
+		}
+	}
+
+	return schemas
+}
@@ -0,0 +1,94 @@
+package apicontract_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	apicontract "github.com/tuanvumaihuynh/outbox-pattern/api-contract"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/event"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/correlationid"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/outbox"
+)
+
+type asyncAPIDoc struct {
+	AsyncAPI string `yaml:"asyncapi"`
+	Channels map[string]struct {
+		Address  string `yaml:"address"`
+		Bindings struct {
+			Kafka struct {
+				Partitions int32 `yaml:"partitions"`
+			} `yaml:"kafka"`
+		} `yaml:"bindings"`
+		Messages map[string]struct {
+			Ref string `yaml:"$ref"`
+		} `yaml:"messages"`
+	} `yaml:"channels"`
+	Components struct {
+		Messages map[string]struct {
+			Headers struct {
+				Ref string `yaml:"$ref"`
+			} `yaml:"headers"`
+			Payload struct {
+				Ref string `yaml:"$ref"`
+			} `yaml:"payload"`
+		} `yaml:"messages"`
+		Schemas map[string]map[string]any `yaml:"schemas"`
+	} `yaml:"components"`
+}
+
+// component returns the name of the component a local reference points to.
+func component(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+func TestAsyncAPISpec(t *testing.T) {
+	var doc asyncAPIDoc
+	require.NoError(t, yaml.Unmarshal(apicontract.GetAsyncAPISpecBytes(), &doc))
+	assert.Equal(t, "3.0.0", doc.AsyncAPI)
+
+	t.Run("Should describe every topic with its event schema and headers", func(t *testing.T) {
+		for _, topic := range event.Topics() {
+			var found bool
+			for _, channel := range doc.Channels {
+				if channel.Address != topic.Name {
+					continue
+				}
+				found = true
+				assert.Equal(t, topic.Partitions, channel.Bindings.Kafka.Partitions, topic.Name)
+
+				require.Len(t, channel.Messages, 1, topic.Name)
+				for _, ref := range channel.Messages {
+					msg, ok := doc.Components.Messages[component(ref.Ref)]
+					require.True(t, ok, topic.Name)
+
+					headers := doc.Components.Schemas[component(msg.Headers.Ref)]
+					properties, ok := headers["properties"].(map[string]any)
+					require.True(t, ok, topic.Name)
+					for _, header := range []string{"traceparent", correlationid.Header, outbox.EventTypeHeader, outbox.EventVersionHeader, outbox.MessageIDHeader} {
+						assert.Contains(t, properties, header, topic.Name)
+					}
+
+					schemaBytes, ok := apicontract.GetEventSchemaBytes(topic.Name)
+					require.True(t, ok, topic.Name)
+					var want map[string]any
+					require.NoError(t, json.Unmarshal(schemaBytes, &want))
+					for _, key := range []string{"$schema", "$id", "title"} {
+						delete(want, key)
+					}
+
+					got, err := json.Marshal(doc.Components.Schemas[component(msg.Payload.Ref)])
+					require.NoError(t, err)
+					wantBytes, err := json.Marshal(want)
+					require.NoError(t, err)
+					assert.JSONEq(t, string(wantBytes), string(got), "payload of %s differs from its event schema", topic.Name)
+				}
+			}
+			assert.True(t, found, "no channel for topic %s", topic.Name)
+		}
+	})
+}
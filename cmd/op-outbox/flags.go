@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// optionalString is a string flag left nil when not set.
+type optionalString struct {
+	value **string
+}
+
+func (f optionalString) String() string {
+	if f.value == nil || *f.value == nil {
+		return ""
+	}
+	return **f.value
+}
+
+func (f optionalString) Set(s string) error {
+	*f.value = &s
+	return nil
+}
+
+// optionalTime is an RFC 3339 time flag left nil when not set.
+type optionalTime struct {
+	value **time.Time
+}
+
+func (f optionalTime) String() string {
+	if f.value == nil || *f.value == nil {
+		return ""
+	}
+	return (*f.value).Format(time.RFC3339)
+}
+
+func (f optionalTime) Set(s string) error {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return fmt.Errorf("parse RFC 3339 time: %w", err)
+	}
+	*f.value = &t
+	return nil
+}
+
+// uuidList is a flag of comma separated ids that can be repeated.
+type uuidList struct {
+	value *[]uuid.UUID
+}
+
+func (f uuidList) String() string {
+	if f.value == nil {
+		return ""
+	}
+
+	ids := make([]string, len(*f.value))
+	for i, id := range *f.value {
+		ids[i] = id.String()
+	}
+	return strings.Join(ids, ",")
+}
+
+func (f uuidList) Set(s string) error {
+	for idStr := range strings.SplitSeq(s, ",") {
+		id, err := uuid.Parse(strings.TrimSpace(idStr))
+		if err != nil {
+			return fmt.Errorf("parse id: %w", err)
+		}
+		*f.value = append(*f.value, id)
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/eventschema"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/model"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/repository"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/service"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db/sqlc"
+)
+
+const usage = `Usage: op-outbox [-output table|json] <command> [flags]
+
+Commands:
+  stats     show the messages per topic and the age of the oldest pending message
+  list      list messages, newest first
+  show      show a message with its headers and payload
+  requeue   make failed messages pending again
+  replay    publish processed messages again
+  purge     delete relayed messages created before a time
+  tail      stream newly relayed messages
+
+Run 'op-outbox <command> -h' for the flags of a command.
+`
+
+// action runs a command once its flags are parsed.
+type action func(ctx context.Context, svc service.OutboxService, p printer) error
+
+type command struct {
+	name string
+	// setup defines the flags of the command and returns its action.
+	setup func(fs *flag.FlagSet) action
+}
+
+var commands = []command{
+	{name: "stats", setup: statsCmd},
+	{name: "list", setup: listCmd},
+	{name: "show", setup: showCmd},
+	{name: "requeue", setup: requeueCmd},
+	{name: "replay", setup: replayCmd},
+	{name: "purge", setup: purgeCmd},
+	{name: "tail", setup: tailCmd},
+}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Printf("error running outbox application: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+	}
+	output := flag.String("output", outputTable, "output format, table or json")
+	flag.Parse()
+
+	p, err := newPrinter(os.Stdout, *output)
+	if err != nil {
+		return err
+	}
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		return errors.New("missing command")
+	}
+	i := slices.IndexFunc(commands, func(c command) bool { return c.name == flag.Arg(0) })
+	if i < 0 {
+		flag.Usage()
+		return fmt.Errorf("unknown command %q", flag.Arg(0))
+	}
+
+	fs := flag.NewFlagSet(commands[i].name, flag.ContinueOnError)
+	act := commands[i].setup(fs)
+	if err := fs.Parse(flag.Args()[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return fmt.Errorf("error parsing flags: %w", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	time.Local = time.UTC
+
+	type Config struct {
+		Postgres config.Postgres
+	}
+	cfg, err := config.New[Config]()
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+
+	pgxPool, err := db.NewPgxPool(ctx, cfg.Postgres)
+	if err != nil {
+		return fmt.Errorf("error creating pgx pool: %w", err)
+	}
+	defer pgxPool.Close()
+
+	eventValidator, err := eventschema.Default()
+	if err != nil {
+		return fmt.Errorf("error creating event schema validator: %w", err)
+	}
+
+	outboxMsgRepository := repository.NewOutboxMsgRepository(db.NewClient(pgxPool), *sqlc.New(), eventValidator)
+	outboxService := service.NewOutboxService(outboxMsgRepository)
+
+	return act(ctx, outboxService, p)
+}
+
+func statsCmd(*flag.FlagSet) action {
+	return func(ctx context.Context, svc service.OutboxService, p printer) error {
+		counts, err := svc.CountOutboxMsgs(ctx)
+		if err != nil {
+			return fmt.Errorf("error counting messages: %w", err)
+		}
+
+		backlog, err := svc.ListOutboxBacklog(ctx)
+		if err != nil {
+			return fmt.Errorf("error listing backlog: %w", err)
+		}
+
+		return p.stats(newOutboxStats(counts, backlog, time.Now()))
+	}
+}
+
+func listCmd(fs *flag.FlagSet) action {
+	var (
+		params service.ListOutboxMsgsParams
+		status *string
+	)
+	fs.Var(optionalString{&params.Topic}, "topic", "only list messages of the topic")
+	fs.Var(optionalString{&status}, "status", "only list messages with the status, pending, processed or failed")
+	fs.Var(optionalTime{&params.CreatedAfter}, "created-after", "only list messages created at or after the RFC 3339 time")
+	fs.Var(optionalTime{&params.CreatedBefore}, "created-before", "only list messages created before the RFC 3339 time")
+	fs.Var(optionalString{&params.ErrorContains}, "error", "only list failed messages whose error contains the text")
+	fs.Var(optionalString{&params.Cursor}, "cursor", "list the page following the cursor printed by the previous page")
+	fs.IntVar(&params.Limit, "limit", service.DefaultOutboxMsgPageSize, "number of messages to list")
+
+	return func(ctx context.Context, svc service.OutboxService, p printer) error {
+		params.Status = (*model.OutboxMsgStatus)(status)
+
+		result, err := svc.ListOutboxMsgs(ctx, params)
+		if err != nil {
+			return fmt.Errorf("error listing messages: %w", err)
+		}
+
+		return p.msgs(result.Msgs, result.NextCursor)
+	}
+}
+
+func showCmd(fs *flag.FlagSet) action {
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: op-outbox show <id>")
+	}
+
+	return func(ctx context.Context, svc service.OutboxService, p printer) error {
+		if fs.NArg() != 1 {
+			return errors.New("show requires exactly one message id")
+		}
+		id, err := uuid.Parse(fs.Arg(0))
+		if err != nil {
+			return fmt.Errorf("error parsing message id: %w", err)
+		}
+
+		msg, err := svc.GetOutboxMsg(ctx, id)
+		if err != nil {
+			return fmt.Errorf("error getting message: %w", err)
+		}
+
+		return p.msg(msg)
+	}
+}
+
+func requeueCmd(fs *flag.FlagSet) action {
+	var params service.RequeueOutboxMsgsParams
+	fs.Var(uuidList{&params.IDs}, "id", "only requeue the messages with the ids, comma separated or repeated")
+	fs.Var(optionalString{&params.Topic}, "topic", "only requeue messages of the topic")
+	fs.Var(optionalTime{&params.CreatedAfter}, "created-after", "only requeue messages created at or after the RFC 3339 time")
+	fs.Var(optionalTime{&params.CreatedBefore}, "created-before", "only requeue messages created before the RFC 3339 time")
+	fs.Var(optionalString{&params.ErrorContains}, "error", "only requeue messages whose error contains the text")
+	fs.BoolVar(&params.DryRun, "dry-run", false, "only count the messages that would be requeued")
+
+	return func(ctx context.Context, svc service.OutboxService, p printer) error {
+		affected, err := svc.RequeueOutboxMsgs(ctx, params)
+		if err != nil {
+			return fmt.Errorf("error requeuing messages: %w", err)
+		}
+
+		return p.affected("requeued", affected, params.DryRun)
+	}
+}
+
+func replayCmd(fs *flag.FlagSet) action {
+	var (
+		params service.ReplayOutboxMsgsParams
+		mode   string
+	)
+	fs.StringVar(&mode, "mode", string(service.OutboxReplayModeReset),
+		"reset makes the messages pending again with their ids, copy inserts pending copies with new ids")
+	fs.Var(uuidList{&params.IDs}, "id", "only replay the messages with the ids, comma separated or repeated")
+	fs.Var(optionalString{&params.Topic}, "topic", "only replay messages of the topic")
+	fs.Var(optionalString{&params.PartitionKey}, "partition-key", "only replay messages with the partition key")
+	fs.Var(optionalTime{&params.CreatedAfter}, "created-after", "only replay messages created at or after the RFC 3339 time")
+	fs.Var(optionalTime{&params.CreatedBefore}, "created-before", "only replay messages created before the RFC 3339 time")
+	fs.BoolVar(&params.DryRun, "dry-run", false, "only count the messages that would be replayed")
+
+	return func(ctx context.Context, svc service.OutboxService, p printer) error {
+		params.Mode = service.OutboxReplayMode(mode)
+
+		affected, err := svc.ReplayOutboxMsgs(ctx, params)
+		if err != nil {
+			return fmt.Errorf("error replaying messages: %w", err)
+		}
+
+		return p.affected("replayed", affected, params.DryRun)
+	}
+}
+
+func purgeCmd(fs *flag.FlagSet) action {
+	var (
+		params    service.PurgeOutboxMsgsParams
+		olderThan time.Duration
+	)
+	fs.DurationVar(&olderThan, "older-than", 0, "purge messages created longer ago than the duration, required")
+	fs.BoolVar(&params.Failed, "failed", false, "purge failed messages instead of processed ones")
+	fs.Var(optionalString{&params.Topic}, "topic", "only purge messages of the topic")
+	fs.BoolVar(&params.DryRun, "dry-run", false, "only count the messages that would be purged")
+
+	return func(ctx context.Context, svc service.OutboxService, p printer) error {
+		if olderThan <= 0 {
+			return errors.New("purge requires a positive -older-than duration")
+		}
+		params.CreatedBefore = time.Now().Add(-olderThan)
+
+		affected, err := svc.PurgeOutboxMsgs(ctx, params)
+		if err != nil {
+			return fmt.Errorf("error purging messages: %w", err)
+		}
+
+		return p.affected("purged", affected, params.DryRun)
+	}
+}
+
+func tailCmd(fs *flag.FlagSet) action {
+	var (
+		params service.TailOutboxMsgsParams
+		since  time.Duration
+	)
+	fs.Var(optionalString{&params.Topic}, "topic", "only stream messages of the topic")
+	fs.DurationVar(&since, "since", 0, "also stream the messages relayed within the duration")
+	fs.DurationVar(&params.Interval, "interval", service.DefaultOutboxTailInterval, "how often relayed messages are polled")
+
+	return func(ctx context.Context, svc service.OutboxService, p printer) error {
+		params.Since = time.Now().Add(-since)
+
+		if err := svc.TailOutboxMsgs(ctx, params, p.tailed); err != nil {
+			return fmt.Errorf("error tailing messages: %w", err)
+		}
+
+		return nil
+	}
+}
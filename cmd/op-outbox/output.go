@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/model"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/service"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/ptr"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// topicStats is the stats of a topic, or of every topic when Topic is empty.
+type topicStats struct {
+	Topic string `json:"topic,omitempty"`
+	model.OutboxMsgCounts
+	OldestPendingCreatedAt  *time.Time `json:"oldest_pending_created_at"`
+	OldestPendingAgeSeconds *float64   `json:"oldest_pending_age_seconds"`
+}
+
+type outboxStats struct {
+	Total  topicStats   `json:"total"`
+	Topics []topicStats `json:"topics"`
+}
+
+// newOutboxStats merges the counts and the backlog of each topic, the total has the oldest pending message
+// of every topic.
+func newOutboxStats(counts service.CountOutboxMsgsResult, backlog []service.TopicOutboxBacklog, now time.Time) outboxStats {
+	oldest := make(map[string]time.Time, len(backlog))
+	for _, topic := range backlog {
+		oldest[topic.Topic] = topic.OldestCreatedAt
+	}
+
+	stats := outboxStats{Topics: make([]topicStats, 0, len(counts.Topics))}
+	stats.Total = topicStats{OutboxMsgCounts: counts.Total}
+	for _, topic := range counts.Topics {
+		s := topicStats{Topic: topic.Topic, OutboxMsgCounts: topic.Counts}
+		if createdAt, ok := oldest[topic.Topic]; ok {
+			s.OldestPendingCreatedAt = &createdAt
+			s.OldestPendingAgeSeconds = ptr.New(now.Sub(createdAt).Seconds())
+
+			if stats.Total.OldestPendingCreatedAt == nil || createdAt.Before(*stats.Total.OldestPendingCreatedAt) {
+				stats.Total.OldestPendingCreatedAt = s.OldestPendingCreatedAt
+				stats.Total.OldestPendingAgeSeconds = s.OldestPendingAgeSeconds
+			}
+		}
+		stats.Topics = append(stats.Topics, s)
+	}
+
+	return stats
+}
+
+type outboxMsg struct {
+	model.OutboxMsg
+	Status model.OutboxMsgStatus `json:"status"`
+}
+
+type outboxMsgs struct {
+	Items      []outboxMsg `json:"items"`
+	NextCursor *string     `json:"next_cursor"`
+}
+
+type affected struct {
+	Affected int64 `json:"affected"`
+	DryRun   bool  `json:"dry_run"`
+}
+
+// printer writes the command results as aligned tables or as JSON.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func newPrinter(w io.Writer, format string) (printer, error) {
+	if format != outputTable && format != outputJSON {
+		return printer{}, fmt.Errorf("unknown output format %q, expected %s or %s", format, outputTable, outputJSON)
+	}
+
+	return printer{w: w, format: format}, nil
+}
+
+func (p printer) stats(stats outboxStats) error {
+	if p.format == outputJSON {
+		return p.json(stats)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TOPIC\tPENDING\tPROCESSED\tFAILED\tOLDEST PENDING AGE")
+	for _, s := range append(stats.Topics, stats.Total) {
+		topic := s.Topic
+		if topic == "" {
+			topic = "TOTAL"
+		}
+		age := "-"
+		if s.OldestPendingAgeSeconds != nil {
+			age = time.Duration(*s.OldestPendingAgeSeconds * float64(time.Second)).Round(time.Second).String()
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\n", topic, s.Pending, s.Processed, s.Failed, age)
+	}
+
+	return tw.Flush()
+}
+
+func (p printer) msgs(msgs []model.OutboxMsg, nextCursor *string) error {
+	if p.format == outputJSON {
+		items := make([]outboxMsg, 0, len(msgs))
+		for _, msg := range msgs {
+			items = append(items, outboxMsg{OutboxMsg: msg, Status: msg.Status()})
+		}
+		return p.json(outboxMsgs{Items: items, NextCursor: nextCursor})
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTOPIC\tSTATUS\tCREATED AT\tPROCESSED AT\tERROR")
+	for _, msg := range msgs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			msg.ID, msg.Topic, msg.Status(), formatTime(&msg.CreatedAt), formatTime(msg.ProcessedAt), orDash(msg.Error))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if nextCursor != nil {
+		_, err := fmt.Fprintf(p.w, "\nnext page: -cursor %s\n", *nextCursor)
+		return err
+	}
+
+	return nil
+}
+
+func (p printer) msg(msg model.OutboxMsg) error {
+	if p.format == outputJSON {
+		return p.json(outboxMsg{OutboxMsg: msg, Status: msg.Status()})
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "ID:\t%s\n", msg.ID)
+	fmt.Fprintf(tw, "Topic:\t%s\n", msg.Topic)
+	fmt.Fprintf(tw, "Status:\t%s\n", msg.Status())
+	fmt.Fprintf(tw, "Partition key:\t%s\n", orDash(msg.PartitionKey))
+	fmt.Fprintf(tw, "Created at:\t%s\n", formatTime(&msg.CreatedAt))
+	fmt.Fprintf(tw, "Processed at:\t%s\n", formatTime(msg.ProcessedAt))
+	fmt.Fprintf(tw, "Error:\t%s\n", orDash(msg.Error))
+	fmt.Fprintf(tw, "Attempts:\t%d\n", msg.Attempts)
+	fmt.Fprintf(tw, "Next attempt at:\t%s\n", formatTime(msg.NextAttemptAt))
+	fmt.Fprintln(tw, "Headers:")
+	for _, key := range slices.Sorted(maps.Keys(msg.Headers)) {
+		fmt.Fprintf(tw, "  %s:\t%s\n", key, msg.Headers[key])
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	var payload bytes.Buffer
+	if err := json.Indent(&payload, msg.Payload, "  ", "  "); err != nil {
+		// the payload is printed as is when it is not JSON
+		payload.Reset()
+		payload.Write(msg.Payload)
+	}
+	_, err := fmt.Fprintf(p.w, "Payload:\n  %s\n", payload.String())
+	return err
+}
+
+// affected prints how many messages the command changed, or would change on a dry run.
+func (p printer) affected(verb string, count int64, dryRun bool) error {
+	if p.format == outputJSON {
+		return p.json(affected{Affected: count, DryRun: dryRun})
+	}
+
+	if dryRun {
+		_, err := fmt.Fprintf(p.w, "%d messages would be %s (dry run)\n", count, verb)
+		return err
+	}
+	_, err := fmt.Fprintf(p.w, "%d messages %s\n", count, verb)
+	return err
+}
+
+// tailed prints a streamed message on a single line, JSON lines when the output is JSON.
+func (p printer) tailed(msg model.OutboxMsg) error {
+	if p.format == outputJSON {
+		return json.NewEncoder(p.w).Encode(outboxMsg{OutboxMsg: msg, Status: msg.Status()})
+	}
+
+	line := fmt.Sprintf("%s  %s  %s  %s", formatTime(msg.ProcessedAt), msg.ID, msg.Topic, msg.Status())
+	if msg.Error != nil {
+		line += "  " + *msg.Error
+	}
+	_, err := fmt.Fprintln(p.w, line)
+	return err
+}
+
+func (p printer) json(v any) error {
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func orDash(s *string) string {
+	if s == nil {
+		return "-"
+	}
+	return *s
+}
@@ -7,13 +7,17 @@ import (
 	"os"
 	"time"
 
+	apicontract "github.com/tuanvumaihuynh/outbox-pattern/api-contract"
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/event"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/eventschema"
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/log"
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/relay"
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/repository"
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db"
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db/sqlc"
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq/schemaregistry"
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/telemetry"
 	"github.com/tuanvumaihuynh/outbox-pattern/pkg/cmdutil"
 )
@@ -35,8 +39,9 @@ func run() error {
 		Log      config.Log
 		Postgres config.Postgres
 		Relay    config.Relay
-		Kafka    config.Kafka
 		Otel     config.Otel
+
+		SchemaRegistry config.SchemaRegistry
 	}
 	cfg, err := config.New[Config]()
 	if err != nil {
@@ -55,6 +60,16 @@ func run() error {
 		}
 	}()
 
+	cleanupMeter, err := telemetry.InitMeter(ctx, cfg.Otel)
+	if err != nil {
+		return fmt.Errorf("error initializing meter: %w", err)
+	}
+	defer func() {
+		if err := cleanupMeter(ctx); err != nil {
+			logger.ErrorContext(ctx, "error cleaning up meter", slog.Any("error", err))
+		}
+	}()
+
 	pgxPool, err := db.NewPgxPool(ctx, cfg.Postgres)
 	if err != nil {
 		return fmt.Errorf("error creating pgx pool: %w", err)
@@ -64,19 +79,58 @@ func run() error {
 	dbClient := db.NewClient(pgxPool)
 	queries := *sqlc.New()
 
-	kafkaProducer, err := mq.NewKafkaProducer(ctx, cfg.Kafka)
+	var mqProducer mq.Producer
+	switch cfg.Relay.Sink {
+	case config.RelaySinkKafka:
+		kafkaCfg, err := config.New[config.Kafka]()
+		if err != nil {
+			return fmt.Errorf("error loading kafka config: %w", err)
+		}
+
+		kafkaProducer, err := mq.NewKafkaProducer(ctx, kafkaCfg)
+		if err != nil {
+			return fmt.Errorf("error creating kafka producer: %w", err)
+		}
+		defer kafkaProducer.Close()
+
+		if err := mq.ReconcileTopics(ctx, kafkaCfg, event.Topics(), logger); err != nil {
+			return fmt.Errorf("error reconciling kafka topics: %w", err)
+		}
+
+		mqProducer = kafkaProducer
+
+		if cfg.SchemaRegistry.Enabled {
+			registry := schemaregistry.NewClient(cfg.SchemaRegistry)
+			mqProducer = mq.NewSchemaProducer(mqProducer, schemaregistry.NewSerializer(registry, schemaregistry.JSONSchemas(apicontract.GetEventSchemaBytes)))
+		}
+	case config.RelaySinkWebhook:
+		webhookCfg, err := config.New[config.Webhook]()
+		if err != nil {
+			return fmt.Errorf("error loading webhook config: %w", err)
+		}
+
+		mqProducer = mq.NewWebhookProducer(webhookCfg)
+	case config.RelaySinkPostgres:
+		mqProducer = mq.NewPostgresProducer(dbClient, queries)
+	default:
+		return fmt.Errorf("unsupported relay sink: %s", cfg.Relay.Sink)
+	}
+
+	eventValidator, err := eventschema.Default()
 	if err != nil {
-		return fmt.Errorf("error creating kafka producer: %w", err)
+		return fmt.Errorf("error creating event schema validator: %w", err)
 	}
-	defer kafkaProducer.Close()
 
-	outboxMsgRepository := repository.NewOutboxMsgRepository(dbClient, queries)
+	outboxMsgRepository := repository.NewOutboxMsgRepository(dbClient, queries, eventValidator)
 
 	interruptChan := cmdutil.InterruptChan()
 
-	svc := relay.NewService(cfg.Relay, logger, dbClient, outboxMsgRepository, kafkaProducer)
+	svc, err := relay.NewService(cfg.Relay, logger, dbClient, outboxMsgRepository, mqProducer)
+	if err != nil {
+		return fmt.Errorf("error creating relay service: %w", err)
+	}
 	cleanup := svc.Run(ctx)
-	logger.InfoContext(ctx, "relay service started")
+	logger.InfoContext(ctx, "relay service started", slog.String("sink", cfg.Relay.Sink.String()))
 
 	<-interruptChan
 
@@ -8,9 +8,12 @@ import (
 	"sync"
 	"time"
 
+	apicontract "github.com/tuanvumaihuynh/outbox-pattern/api-contract"
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/event"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/eventschema"
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/http"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/inbox"
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/log"
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/relay"
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/repository"
@@ -18,6 +21,8 @@ import (
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db"
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db/sqlc"
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq/middleware"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq/schemaregistry"
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/telemetry"
 	"github.com/tuanvumaihuynh/outbox-pattern/pkg/cmdutil"
 )
@@ -40,8 +45,9 @@ func run() error {
 		Postgres config.Postgres
 		HTTP     config.HTTP
 		Relay    config.Relay
-		Kafka    config.Kafka
 		Otel     config.Otel
+
+		SchemaRegistry config.SchemaRegistry
 	}
 	cfg, err := config.New[Config]()
 	if err != nil {
@@ -60,6 +66,16 @@ func run() error {
 		}
 	}()
 
+	cleanupMeter, err := telemetry.InitMeter(ctx, cfg.Otel)
+	if err != nil {
+		return fmt.Errorf("error initializing meter: %w", err)
+	}
+	defer func() {
+		if err := cleanupMeter(ctx); err != nil {
+			logger.ErrorContext(ctx, "error cleaning up meter", slog.Any("error", err))
+		}
+	}()
+
 	pgxPool, err := db.NewPgxPool(ctx, cfg.Postgres)
 	if err != nil {
 		return fmt.Errorf("error creating pgx pool: %w", err)
@@ -69,28 +85,72 @@ func run() error {
 	dbClient := db.NewClient(pgxPool)
 	queries := *sqlc.New()
 
-	kafkaProducer, err := mq.NewKafkaProducer(ctx, cfg.Kafka)
-	if err != nil {
-		return fmt.Errorf("error creating kafka producer: %w", err)
+	var (
+		mqProducer    mq.Producer
+		mqConsumer    mq.Consumer
+		mqMiddlewares []mq.Middleware
+	)
+	switch cfg.Relay.Sink {
+	case config.RelaySinkKafka:
+		kafkaCfg, err := config.New[config.Kafka]()
+		if err != nil {
+			return fmt.Errorf("error loading kafka config: %w", err)
+		}
+
+		kafkaProducer, err := mq.NewKafkaProducer(ctx, kafkaCfg)
+		if err != nil {
+			return fmt.Errorf("error creating kafka producer: %w", err)
+		}
+		defer kafkaProducer.Close()
+
+		if err := mq.ReconcileTopics(ctx, kafkaCfg, event.Topics(), logger); err != nil {
+			return fmt.Errorf("error reconciling kafka topics: %w", err)
+		}
+
+		kafkaConsumer, err := mq.NewKafkaConsumer(ctx, kafkaCfg, logger)
+		if err != nil {
+			return fmt.Errorf("error creating kafka consumer: %w", err)
+		}
+		defer kafkaConsumer.Close()
+
+		mqProducer = kafkaProducer
+		mqConsumer = kafkaConsumer
+
+		if cfg.SchemaRegistry.Enabled {
+			registry := schemaregistry.NewClient(cfg.SchemaRegistry)
+			mqProducer = mq.NewSchemaProducer(mqProducer, schemaregistry.NewSerializer(registry, schemaregistry.JSONSchemas(apicontract.GetEventSchemaBytes)))
+			mqMiddlewares = append(mqMiddlewares, middleware.SchemaRegistry(schemaregistry.NewDeserializer(registry)))
+		}
+	case config.RelaySinkPostgres:
+		postgresMQCfg, err := config.New[config.PostgresMQ]()
+		if err != nil {
+			return fmt.Errorf("error loading postgres mq config: %w", err)
+		}
+
+		mqProducer = mq.NewPostgresProducer(dbClient, queries)
+		mqConsumer = mq.NewPostgresConsumer(postgresMQCfg, pgxPool, dbClient, queries, logger)
+	default:
+		return fmt.Errorf("unsupported relay sink for standalone: %s", cfg.Relay.Sink)
 	}
-	defer kafkaProducer.Close()
 
-	kafkaConsumer, err := mq.NewKafkaConsumer(ctx, cfg.Kafka, logger)
+	eventValidator, err := eventschema.Default()
 	if err != nil {
-		return fmt.Errorf("error creating kafka consumer: %w", err)
+		return fmt.Errorf("error creating event schema validator: %w", err)
 	}
-	defer kafkaConsumer.Close()
 
 	productRepository := repository.NewProductRepository(dbClient, queries)
-	outboxMsgRepository := repository.NewOutboxMsgRepository(dbClient, queries)
+	outboxMsgRepository := repository.NewOutboxMsgRepository(dbClient, queries, eventValidator)
+	inboxMsgRepository := repository.NewInboxMsgRepository(dbClient, queries)
 
 	productService := service.NewProductService(dbClient, productRepository, outboxMsgRepository)
+	outboxService := service.NewOutboxService(outboxMsgRepository)
 
 	interruptChan := cmdutil.InterruptChan()
 	var wg sync.WaitGroup
 
 	wg.Go(func() {
-		svc := event.New(logger, kafkaConsumer)
+		svc := event.New(logger, mqConsumer, inbox.New(dbClient, inboxMsgRepository, logger))
+		svc.Use(mqMiddlewares...)
 		cleanup, err := svc.Run(ctx)
 		if err != nil {
 			panic(fmt.Errorf("error running event service: %w", err))
@@ -106,7 +166,7 @@ func run() error {
 	})
 
 	wg.Go(func() {
-		svc := http.New(cfg.HTTP, logger, productService)
+		svc := http.New(cfg.HTTP, logger, productService, outboxService)
 		cleanup, err := svc.Run(ctx)
 		if err != nil {
 			panic(fmt.Errorf("error running http service: %w", err))
@@ -125,9 +185,12 @@ func run() error {
 	})
 
 	wg.Go(func() {
-		svc := relay.NewService(cfg.Relay, logger, dbClient, outboxMsgRepository, kafkaProducer)
+		svc, err := relay.NewService(cfg.Relay, logger, dbClient, outboxMsgRepository, mqProducer)
+		if err != nil {
+			panic(fmt.Errorf("error creating relay service: %w", err))
+		}
 		cleanup := svc.Run(ctx)
-		logger.InfoContext(ctx, "relay service started")
+		logger.InfoContext(ctx, "relay service started", slog.String("sink", cfg.Relay.Sink.String()))
 
 		<-interruptChan
 
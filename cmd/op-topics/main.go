@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/event"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/log"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Printf("error running topics application: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	check := flag.Bool("check", false, "only report missing topics and drifted settings, failing if there are any")
+	flag.Parse()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	time.Local = time.UTC
+
+	type Config struct {
+		Log   config.Log
+		Kafka config.Kafka
+	}
+	cfg, err := config.New[Config]()
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+
+	logger := log.NewSlogLogger(cfg.Log)
+
+	admin, err := mq.NewTopicAdmin(ctx, cfg.Kafka)
+	if err != nil {
+		return fmt.Errorf("error creating topic admin: %w", err)
+	}
+	defer admin.Close()
+
+	specs := mq.WithRetryTopics(event.Topics(), cfg.Kafka)
+
+	if *check {
+		logger.InfoContext(ctx, "checking kafka topics")
+
+		report, err := admin.Check(ctx, specs)
+		if err != nil {
+			return fmt.Errorf("error checking topics: %w", err)
+		}
+		mq.LogTopicReport(ctx, logger, report)
+
+		if len(report.Missing) > 0 || len(report.Drifts) > 0 {
+			return fmt.Errorf("%d topics missing, %d settings drifted", len(report.Missing), len(report.Drifts))
+		}
+
+		logger.InfoContext(ctx, "kafka topics are up to date")
+		return nil
+	}
+
+	logger.InfoContext(ctx, "provisioning kafka topics")
+
+	report, err := admin.Provision(ctx, specs)
+	if err != nil {
+		return fmt.Errorf("error provisioning topics: %w", err)
+	}
+	mq.LogTopicReport(ctx, logger, report)
+
+	logger.InfoContext(ctx, "kafka topics provisioned")
+
+	return nil
+}
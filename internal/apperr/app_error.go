@@ -3,9 +3,17 @@ package apperr
 import "github.com/tuanvumaihuynh/outbox-pattern/pkg/zerror"
 
 const (
-	ValidationErrorCode = "VALIDATION_FAILED"
+	ValidationErrorCode          = "VALIDATION_FAILED"
+	UnauthorizedErrorCode        = "UNAUTHORIZED"
+	EventPayloadInvalidErrorCode = "EVENT_PAYLOAD_INVALID"
+	OutboxMsgNotFoundErrorCode   = "OUTBOX_MESSAGE_NOT_FOUND"
+	OutboxCursorInvalidErrorCode = "OUTBOX_CURSOR_INVALID"
 )
 
 var (
-	ValidationErr = zerror.NewValidationFailed(ValidationErrorCode, "validation error")
+	ValidationErr          = zerror.NewValidationFailed(ValidationErrorCode, "validation error")
+	UnauthorizedErr        = zerror.NewUnauthorized(UnauthorizedErrorCode, "missing or invalid credentials")
+	EventPayloadInvalidErr = zerror.NewValidationFailed(EventPayloadInvalidErrorCode, "event payload does not match the schema of its topic")
+	OutboxMsgNotFoundErr   = zerror.NewNotFound(OutboxMsgNotFoundErrorCode, "outbox message not found")
+	OutboxCursorInvalidErr = zerror.NewValidationFailed(OutboxCursorInvalidErrorCode, "invalid outbox message cursor")
 )
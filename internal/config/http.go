@@ -3,4 +3,6 @@ package config
 type HTTP struct {
 	Port    uint32 `env:"HTTP_PORT" envDefault:"8000"`
 	Swagger bool   `env:"HTTP_SWAGGER" envDefault:"true"`
+	// AdminToken is the bearer token of the admin API, which rejects every request when it is empty.
+	AdminToken string `env:"HTTP_ADMIN_TOKEN"`
 }
@@ -1,6 +1,277 @@
 package config
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
 type Kafka struct {
 	Addresses []string `env:"KAFKA_ADDRESSES,required" envSeparator:","`
-	Group     string   `env:"KAFKA_GROUP,required"`
+	// Group is the consumer group, required by consumers only.
+	Group string `env:"KAFKA_GROUP"`
+
+	// TLSEnabled connects to the brokers over TLS.
+	TLSEnabled bool `env:"KAFKA_TLS_ENABLED" envDefault:"false"`
+	// TLSCAFile is the PEM file of the CAs verifying the brokers, the system pool if empty.
+	TLSCAFile string `env:"KAFKA_TLS_CA_FILE"`
+	// TLSCertFile and TLSKeyFile are the PEM files of the client certificate, for mutual TLS.
+	TLSCertFile string `env:"KAFKA_TLS_CERT_FILE"`
+	TLSKeyFile  string `env:"KAFKA_TLS_KEY_FILE"`
+	// TLSInsecureSkipVerify skips verifying the brokers' certificates, for development only.
+	TLSInsecureSkipVerify bool `env:"KAFKA_TLS_INSECURE_SKIP_VERIFY" envDefault:"false"`
+	// SASLMechanism authenticates to the brokers with SASL.
+	SASLMechanism SASLMechanism `env:"KAFKA_SASL_MECHANISM" envDefault:"NONE"`
+	SASLUsername  string        `env:"KAFKA_SASL_USERNAME"`
+	SASLPassword  string        `env:"KAFKA_SASL_PASSWORD"`
+
+	// TopicsCheck checks on startup that the declared topics exist with their settings, logging the
+	// drifted settings and failing on missing topics unless TopicsAutoCreate is set.
+	TopicsCheck bool `env:"KAFKA_TOPICS_CHECK" envDefault:"true"`
+	// TopicsCreate creates the missing declared topics on startup, instead of only reporting them.
+	TopicsCreate bool `env:"KAFKA_TOPICS_CREATE" envDefault:"false"`
+	// TopicsAutoCreate lets the clients create the topics they produce to or consume from with the
+	// broker defaults when they are missing, instead of requiring them to be provisioned from their specs.
+	TopicsAutoCreate bool `env:"KAFKA_TOPICS_AUTO_CREATE" envDefault:"false"`
+
+	// ProducerAcks is how many replicas must acknowledge a produced record.
+	ProducerAcks ProducerAcks `env:"KAFKA_PRODUCER_ACKS" envDefault:"ALL"`
+	// ProducerDisableIdempotence disables idempotent writes, which are required unless acks are ALL.
+	ProducerDisableIdempotence bool `env:"KAFKA_PRODUCER_DISABLE_IDEMPOTENCE" envDefault:"false"`
+	// ProducerLinger is how long a partition batch waits for more records before it is produced.
+	ProducerLinger time.Duration `env:"KAFKA_PRODUCER_LINGER" envDefault:"10ms"`
+	// ProducerMaxBufferedRecords is how many records can wait to be produced before producing blocks.
+	ProducerMaxBufferedRecords int `env:"KAFKA_PRODUCER_MAX_BUFFERED_RECORDS" envDefault:"10000"`
+	// ProducerBatchMaxBytes is the maximum size of a partition batch, it must not exceed the
+	// max.message.bytes of the topics.
+	ProducerBatchMaxBytes int32 `env:"KAFKA_PRODUCER_BATCH_MAX_BYTES" envDefault:"1000012"`
+	// ProducerCompression is the codec compressing the produced batches.
+	ProducerCompression ProducerCompression `env:"KAFKA_PRODUCER_COMPRESSION" envDefault:"SNAPPY"`
+	// ProducerPartitioner picks the partition of a produced record.
+	ProducerPartitioner ProducerPartitioner `env:"KAFKA_PRODUCER_PARTITIONER" envDefault:"MURMUR2"`
+	// ProducerTimeout is how long a record can take to be produced, retries included, zero for no limit.
+	ProducerTimeout time.Duration `env:"KAFKA_PRODUCER_TIMEOUT" envDefault:"30s"`
+
+	// ConsumerConcurrency is how the records of a poll are spread across workers.
+	ConsumerConcurrency ConsumerConcurrency `env:"KAFKA_CONSUMER_CONCURRENCY" envDefault:"SEQUENTIAL"`
+	// ConsumerKeyWorkers is how many workers handle the records of a partition with KEY concurrency,
+	// the keys are spread across them by hash.
+	ConsumerKeyWorkers uint32 `env:"KAFKA_CONSUMER_KEY_WORKERS" envDefault:"16"`
+	// ConsumerMaxAttempts is how many times a record is handled before the consumer gives up on it for now.
+	ConsumerMaxAttempts uint32 `env:"KAFKA_CONSUMER_MAX_ATTEMPTS" envDefault:"5"`
+	// ConsumerRetryBackoff is the delay before the first retry, doubled on each subsequent retry.
+	ConsumerRetryBackoff    time.Duration `env:"KAFKA_CONSUMER_RETRY_BACKOFF" envDefault:"100ms"`
+	ConsumerMaxRetryBackoff time.Duration `env:"KAFKA_CONSUMER_MAX_RETRY_BACKOFF" envDefault:"5s"`
+
+	// ConsumerRetryTopicDelays enables non-blocking retries: a record that keeps failing is republished to
+	// <topic>.retry.<n> and handled again once the n-th delay has passed since it was republished.
+	ConsumerRetryTopicDelays []time.Duration `env:"KAFKA_CONSUMER_RETRY_TOPIC_DELAYS" envSeparator:","`
+	// ConsumerDeadLetter republishes records that exhausted their retries to <topic>.dlq instead of
+	// blocking their partition until they succeed.
+	ConsumerDeadLetter bool `env:"KAFKA_CONSUMER_DEAD_LETTER" envDefault:"false"`
+	// ConsumerSkipUnhandledEvents skips the records of a topic with event type handlers whose event type
+	// has no handler, instead of failing them.
+	ConsumerSkipUnhandledEvents bool `env:"KAFKA_CONSUMER_SKIP_UNHANDLED_EVENTS" envDefault:"false"`
+	// ConsumerRegex subscribes the consumer to topics with regular expressions, which pattern handlers
+	// require. The consumer then no longer creates missing topics, new topics are picked up on the next
+	// metadata refresh.
+	ConsumerRegex bool `env:"KAFKA_CONSUMER_REGEX" envDefault:"false"`
+	// ConsumerDrainTimeout is how long a stopping consumer waits for the records being handled and the
+	// buffered batches before cancelling their handlers.
+	ConsumerDrainTimeout time.Duration `env:"KAFKA_CONSUMER_DRAIN_TIMEOUT" envDefault:"30s"`
+}
+
+// SASLMechanism represents the SASL mechanism used to authenticate to Kafka.
+type SASLMechanism uint8
+
+// String returns the string representation of the SASL mechanism.
+func (m SASLMechanism) String() string {
+	return []string{"NONE", "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512"}[m]
+}
+
+const (
+	// SASLMechanismNone disables SASL.
+	SASLMechanismNone SASLMechanism = iota
+	// SASLMechanismPlain sends the username and password as is, it should only be used over TLS.
+	SASLMechanismPlain
+	// SASLMechanismScramSHA256 authenticates with SCRAM using SHA-256.
+	SASLMechanismScramSHA256
+	// SASLMechanismScramSHA512 authenticates with SCRAM using SHA-512.
+	SASLMechanismScramSHA512
+)
+
+// UnmarshalText implements [encoding.TextUnmarshaler].
+// It unmarshals the text to a SASL mechanism.
+func (m *SASLMechanism) UnmarshalText(text []byte) error {
+	switch strings.ToUpper(string(text)) {
+	case "NONE", "":
+		*m = SASLMechanismNone
+	case "PLAIN":
+		*m = SASLMechanismPlain
+	case "SCRAM-SHA-256":
+		*m = SASLMechanismScramSHA256
+	case "SCRAM-SHA-512":
+		*m = SASLMechanismScramSHA512
+	default:
+		return fmt.Errorf("unknown sasl mechanism: %s", text)
+	}
+	return nil
+}
+
+func (m SASLMechanism) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// ProducerAcks represents how many replicas must acknowledge a produced record.
+type ProducerAcks uint8
+
+// String returns the string representation of the producer acks.
+func (a ProducerAcks) String() string {
+	return []string{"ALL", "LEADER", "NONE"}[a]
+}
+
+const (
+	// ProducerAcksAll waits for all in-sync replicas.
+	ProducerAcksAll ProducerAcks = iota
+	// ProducerAcksLeader waits for the partition leader only.
+	ProducerAcksLeader
+	// ProducerAcksNone does not wait for any acknowledgement.
+	ProducerAcksNone
+)
+
+// UnmarshalText implements [encoding.TextUnmarshaler].
+// It unmarshals the text to producer acks.
+func (a *ProducerAcks) UnmarshalText(text []byte) error {
+	switch strings.ToUpper(string(text)) {
+	case "ALL":
+		*a = ProducerAcksAll
+	case "LEADER":
+		*a = ProducerAcksLeader
+	case "NONE":
+		*a = ProducerAcksNone
+	default:
+		return fmt.Errorf("unknown producer acks: %s", text)
+	}
+	return nil
+}
+
+func (a ProducerAcks) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// ProducerCompression represents the codec compressing produced batches.
+type ProducerCompression uint8
+
+// String returns the string representation of the producer compression.
+func (c ProducerCompression) String() string {
+	return []string{"NONE", "GZIP", "SNAPPY", "LZ4", "ZSTD"}[c]
+}
+
+const (
+	ProducerCompressionNone ProducerCompression = iota
+	ProducerCompressionGzip
+	ProducerCompressionSnappy
+	ProducerCompressionLz4
+	ProducerCompressionZstd
+)
+
+// UnmarshalText implements [encoding.TextUnmarshaler].
+// It unmarshals the text to a producer compression.
+func (c *ProducerCompression) UnmarshalText(text []byte) error {
+	switch strings.ToUpper(string(text)) {
+	case "NONE":
+		*c = ProducerCompressionNone
+	case "GZIP":
+		*c = ProducerCompressionGzip
+	case "SNAPPY":
+		*c = ProducerCompressionSnappy
+	case "LZ4":
+		*c = ProducerCompressionLz4
+	case "ZSTD":
+		*c = ProducerCompressionZstd
+	default:
+		return fmt.Errorf("unknown producer compression: %s", text)
+	}
+	return nil
+}
+
+func (c ProducerCompression) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// ProducerPartitioner represents how the partition of a produced record is picked.
+type ProducerPartitioner uint8
+
+// String returns the string representation of the producer partitioner.
+func (p ProducerPartitioner) String() string {
+	return []string{"MURMUR2", "STICKY", "ROUND_ROBIN"}[p]
+}
+
+const (
+	// ProducerPartitionerMurmur2 hashes keys with murmur2 like the Java client, so records with the same
+	// key land in the same partition whichever client produced them. Records without a key stick to a
+	// partition per batch.
+	ProducerPartitionerMurmur2 ProducerPartitioner = iota
+	// ProducerPartitionerSticky sticks to a partition per batch, ignoring keys.
+	ProducerPartitionerSticky
+	// ProducerPartitionerRoundRobin spreads records across partitions one by one, ignoring keys.
+	ProducerPartitionerRoundRobin
+)
+
+// UnmarshalText implements [encoding.TextUnmarshaler].
+// It unmarshals the text to a producer partitioner.
+func (p *ProducerPartitioner) UnmarshalText(text []byte) error {
+	switch strings.ToUpper(string(text)) {
+	case "MURMUR2":
+		*p = ProducerPartitionerMurmur2
+	case "STICKY":
+		*p = ProducerPartitionerSticky
+	case "ROUND_ROBIN":
+		*p = ProducerPartitionerRoundRobin
+	default:
+		return fmt.Errorf("unknown producer partitioner: %s", text)
+	}
+	return nil
+}
+
+func (p ProducerPartitioner) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// ConsumerConcurrency represents how a consumer spreads records across workers.
+type ConsumerConcurrency uint8
+
+// String returns the string representation of the consumer concurrency.
+func (c ConsumerConcurrency) String() string {
+	return []string{"SEQUENTIAL", "PARTITION", "KEY"}[c]
+}
+
+const (
+	// ConsumerConcurrencySequential handles all records in a single worker.
+	ConsumerConcurrencySequential ConsumerConcurrency = iota
+	// ConsumerConcurrencyPartition handles the records of each assigned partition in their own worker.
+	ConsumerConcurrencyPartition
+	// ConsumerConcurrencyKey handles the records of each assigned partition in their own worker,
+	// which spreads the keys across a bounded number of workers, in order within a key.
+	ConsumerConcurrencyKey
+)
+
+// UnmarshalText implements [encoding.TextUnmarshaler].
+// It unmarshals the text to a consumer concurrency.
+func (c *ConsumerConcurrency) UnmarshalText(text []byte) error {
+	switch strings.ToUpper(string(text)) {
+	case "SEQUENTIAL":
+		*c = ConsumerConcurrencySequential
+	case "PARTITION":
+		*c = ConsumerConcurrencyPartition
+	case "KEY":
+		*c = ConsumerConcurrencyKey
+	default:
+		return fmt.Errorf("unknown consumer concurrency: %s", text)
+	}
+	return nil
+}
+
+func (c ConsumerConcurrency) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
 }
@@ -0,0 +1,21 @@
+package config
+
+import "time"
+
+type PostgresMQ struct {
+	Group        string        `env:"POSTGRES_MQ_GROUP,required"`
+	BatchSize    uint32        `env:"POSTGRES_MQ_BATCH_SIZE" envDefault:"100"`
+	PollInterval time.Duration `env:"POSTGRES_MQ_POLL_INTERVAL" envDefault:"5s"`
+
+	// MaxAttempts is how many times a message is handled before the consumer gives up on it for now.
+	MaxAttempts uint32 `env:"POSTGRES_MQ_MAX_ATTEMPTS" envDefault:"5"`
+	// RetryBackoff is the delay before the first retry, doubled on each subsequent retry.
+	RetryBackoff    time.Duration `env:"POSTGRES_MQ_RETRY_BACKOFF" envDefault:"100ms"`
+	MaxRetryBackoff time.Duration `env:"POSTGRES_MQ_MAX_RETRY_BACKOFF" envDefault:"5s"`
+	// DeadLetter publishes messages that exhausted their attempts to <topic>.dlq and moves past them,
+	// instead of retrying them on the next poll and blocking their topic.
+	DeadLetter bool `env:"POSTGRES_MQ_DEAD_LETTER" envDefault:"false"`
+	// SkipUnhandledEvents skips the messages of a topic with event type handlers whose event type
+	// has no handler, instead of failing them.
+	SkipUnhandledEvents bool `env:"POSTGRES_MQ_SKIP_UNHANDLED_EVENTS" envDefault:"false"`
+}
@@ -1,8 +1,60 @@
 package config
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 type Relay struct {
 	BatchSize uint32        `env:"RELAY_BATCH_SIZE" envDefault:"100"`
 	Interval  time.Duration `env:"RELAY_INTERVAL" envDefault:"1s"`
+	Sink      RelaySink     `env:"RELAY_SINK" envDefault:"KAFKA"`
+
+	// ShutdownTimeout is how long the relay waits for in-flight messages before cancelling them on shutdown.
+	ShutdownTimeout time.Duration `env:"RELAY_SHUTDOWN_TIMEOUT" envDefault:"5s"`
+
+	// MaxAttempts is how many times a message is relayed before it is marked failed, failed attempts
+	// are retried after a backoff doubling from RetryBackoff up to MaxRetryBackoff.
+	MaxAttempts     uint32        `env:"RELAY_MAX_ATTEMPTS" envDefault:"5"`
+	RetryBackoff    time.Duration `env:"RELAY_RETRY_BACKOFF" envDefault:"1s"`
+	MaxRetryBackoff time.Duration `env:"RELAY_MAX_RETRY_BACKOFF" envDefault:"5m"`
+
+	// ObserveBacklog exports the pending messages and the age of the oldest one per topic. Those are
+	// read from the whole outbox, so enable it on a single replica, the gauges must not be summed.
+	ObserveBacklog bool `env:"RELAY_OBSERVE_BACKLOG" envDefault:"false"`
+}
+
+// RelaySink represents where the relay delivers outbox messages to.
+type RelaySink uint8
+
+// String returns the string representation of the relay sink.
+func (s RelaySink) String() string {
+	return []string{"KAFKA", "WEBHOOK", "POSTGRES"}[s]
+}
+
+const (
+	RelaySinkKafka RelaySink = iota
+	RelaySinkWebhook
+	RelaySinkPostgres
+)
+
+// UnmarshalText implements [encoding.TextUnmarshaler].
+// It unmarshals the text to a relay sink.
+func (s *RelaySink) UnmarshalText(text []byte) error {
+	switch strings.ToUpper(string(text)) {
+	case "KAFKA":
+		*s = RelaySinkKafka
+	case "WEBHOOK":
+		*s = RelaySinkWebhook
+	case "POSTGRES":
+		*s = RelaySinkPostgres
+	default:
+		return fmt.Errorf("unknown relay sink: %s", text)
+	}
+	return nil
+}
+
+func (s RelaySink) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
 }
@@ -0,0 +1,13 @@
+package config
+
+import "time"
+
+// SchemaRegistry is the config of a Confluent compatible schema registry.
+type SchemaRegistry struct {
+	// Enabled serializes published payloads with their registered schema.
+	Enabled  bool          `env:"SCHEMA_REGISTRY_ENABLED" envDefault:"false"`
+	URL      string        `env:"SCHEMA_REGISTRY_URL" envDefault:"http://localhost:8081"`
+	Username string        `env:"SCHEMA_REGISTRY_USERNAME"`
+	Password string        `env:"SCHEMA_REGISTRY_PASSWORD"`
+	Timeout  time.Duration `env:"SCHEMA_REGISTRY_TIMEOUT" envDefault:"10s"`
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type Webhook struct {
+	Subscriptions   WebhookSubscriptions `env:"WEBHOOK_SUBSCRIPTIONS,required"`
+	Secret          string               `env:"WEBHOOK_SECRET,required"`
+	SignatureHeader string               `env:"WEBHOOK_SIGNATURE_HEADER" envDefault:"X-Outbox-Signature"`
+	Timeout         time.Duration        `env:"WEBHOOK_TIMEOUT" envDefault:"10s"`
+}
+
+// WebhookSubscriptions maps a topic to the URLs of its subscribers.
+//
+// The text form is a list of topic entries separated by ";", each entry being
+// a topic and its subscriber URLs separated by "|", e.g.
+// "product.created=https://a.example/hook|https://b.example/hook;product.updated=https://a.example/hook".
+type WebhookSubscriptions map[string][]string
+
+// UnmarshalText implements [encoding.TextUnmarshaler].
+func (s *WebhookSubscriptions) UnmarshalText(text []byte) error {
+	subs := WebhookSubscriptions{}
+
+	for entry := range strings.SplitSeq(string(text), ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		topic, urls, ok := strings.Cut(entry, "=")
+		topic = strings.TrimSpace(topic)
+		if !ok || topic == "" {
+			return fmt.Errorf("invalid webhook subscription: %s", entry)
+		}
+
+		for rawURL := range strings.SplitSeq(urls, "|") {
+			rawURL = strings.TrimSpace(rawURL)
+			u, err := url.Parse(rawURL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("invalid webhook url for topic %s: %s", topic, rawURL)
+			}
+
+			subs[topic] = append(subs[topic], rawURL)
+		}
+	}
+
+	*s = subs
+	return nil
+}
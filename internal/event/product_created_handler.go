@@ -3,19 +3,29 @@ package event
 import (
 	"context"
 	"log/slog"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
 )
 
-const TopicProductCreated = "product.created"
+const (
+	TopicProductCreated     = "product.created"
+	EventTypeProductCreated = "product.created"
+	// EventVersionProductCreated is the version of ProductCreatedEvent. Bump it along with an upcaster
+	// from the previous version registered on the service upcasters when the event changes, the service
+	// does not run if they disagree.
+	EventVersionProductCreated = 1
+)
 
 type ProductCreatedEvent struct {
-	ProductID     string  `json:"product_id"`
+	ProductID     string  `json:"product_id" validate:"required,uuid"`
 	Name          string  `json:"name"`
-	Sku           string  `json:"sku"`
+	Sku           string  `json:"sku" validate:"required"`
 	Price         float64 `json:"price"`
 	StockQuantity int     `json:"stock_quantity"`
 }
 
-func (s *Service) handleProductCreatedEvent(ctx context.Context, ev ProductCreatedEvent) error {
+func (s *Service) handleProductCreatedEvent(ctx context.Context, _ db.DB, ev ProductCreatedEvent, _ mq.Metadata) error {
 	s.logger.InfoContext(ctx, "handling product created event", slog.Any("event", ev))
 	return nil
 }
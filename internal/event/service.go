@@ -2,47 +2,65 @@ package event
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log/slog"
 
+	"go.opentelemetry.io/otel"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/inbox"
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq/middleware"
+)
+
+var (
+	tracer = otel.Tracer("internal/event")
+	meter  = otel.Meter("internal/event")
 )
 
 // Service is the event service.
 type Service struct {
 	logger     *slog.Logger
 	mqConsumer mq.Consumer
+	inbox      *inbox.Inbox
+
+	middlewares []mq.Middleware
+	upcasters   *mq.Upcasters
 }
 
 // New creates a new event service.
 func New(
 	logger *slog.Logger,
 	mqConsumer mq.Consumer,
+	inbox *inbox.Inbox,
 ) *Service {
 	return &Service{
 		logger:     logger,
 		mqConsumer: mqConsumer,
+		inbox:      inbox,
+		upcasters:  mq.NewUpcasters(),
 	}
 }
 
+// Use appends middlewares installed innermost, after the default ones, when the service runs.
+func (s *Service) Use(middlewares ...mq.Middleware) {
+	s.middlewares = append(s.middlewares, middlewares...)
+}
+
 type CleanupFunc func()
 
 func (s *Service) Run(ctx context.Context) (CleanupFunc, error) {
-	if err := s.mqConsumer.RegisterHandler(
+	if err := s.upcasters.Check(EventTypeProductCreated, EventVersionProductCreated); err != nil {
+		return nil, fmt.Errorf("check upcasters: %w", err)
+	}
+
+	if err := s.registerMiddlewares(); err != nil {
+		return nil, fmt.Errorf("register middlewares: %w", err)
+	}
+
+	if err := s.mqConsumer.RegisterEventHandler(
 		TopicProductCreated,
-		func(ctx context.Context, topic string, payload []byte) error {
-			var ev ProductCreatedEvent
-			if err := json.Unmarshal(payload, &ev); err != nil {
-				return fmt.Errorf("unmarshal product created event: %w", err)
-			}
-
-			if err := s.handleProductCreatedEvent(ctx, ev); err != nil {
-				return fmt.Errorf("handle product created event: %w", err)
-			}
-
-			return nil
-		},
+		EventTypeProductCreated,
+		s.inbox.Handler(TopicProductCreated, inbox.Typed(s.handleProductCreatedEvent)),
 	); err != nil {
 		return nil, fmt.Errorf("register product created event handler: %w", err)
 	}
@@ -58,3 +76,25 @@ func (s *Service) Run(ctx context.Context) (CleanupFunc, error) {
 
 	return cleanup, nil
 }
+
+// registerMiddlewares installs the middlewares wrapping every handler.
+// Panics are recovered inside the span, log and metrics middlewares so they see them as errors, and
+// payloads are upcast innermost, once the middlewares added with Use have unframed them.
+func (s *Service) registerMiddlewares() error {
+	metrics, err := middleware.Metrics(meter)
+	if err != nil {
+		return fmt.Errorf("create metrics middleware: %w", err)
+	}
+
+	s.mqConsumer.Use(
+		middleware.Trace(tracer),
+		middleware.CorrelationID(),
+		middleware.Logging(s.logger),
+		metrics,
+		middleware.Recoverer(s.logger),
+	)
+	s.mqConsumer.Use(s.middlewares...)
+	s.mqConsumer.Use(middleware.Upcast(s.upcasters))
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package event
+
+import (
+	"time"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/topic"
+)
+
+// productCreatedTopic keeps product events for a week, partitioned by product id.
+// The replication factor follows the broker default so the topic fits single broker clusters.
+var productCreatedTopic = topic.Spec{
+	Name:          TopicProductCreated,
+	Partitions:    6,
+	Retention:     7 * 24 * time.Hour,
+	CleanupPolicy: topic.CleanupPolicyDelete,
+}
+
+// Topics returns the specs of the topics events are published to.
+func Topics() []topic.Spec {
+	return []topic.Spec{
+		productCreatedTopic,
+	}
+}
@@ -0,0 +1,90 @@
+// Package eventschema validates event payloads against the JSON Schemas of their topic declared in
+// api-contract, so malformed payloads are rejected before they are written to the outbox.
+package eventschema
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	apicontract "github.com/tuanvumaihuynh/outbox-pattern/api-contract"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/apperr"
+)
+
+// Validator validates event payloads against the compiled schemas of their topic.
+type Validator struct {
+	schemas map[string]*jsonschema.Schema
+}
+
+// NewValidator compiles the schemas, by topic.
+func NewValidator(schemas map[string][]byte) (*Validator, error) {
+	v := &Validator{schemas: make(map[string]*jsonschema.Schema, len(schemas))}
+	for topic, schema := range schemas {
+		sch, err := Compile(string(schema))
+		if err != nil {
+			return nil, fmt.Errorf("schema for topic %s: %w", topic, err)
+		}
+		v.schemas[topic] = sch
+	}
+
+	return v, nil
+}
+
+// Default returns the validator of the schemas declared in api-contract, compiled once.
+var Default = sync.OnceValues(func() (*Validator, error) {
+	return NewValidator(apicontract.GetEventSchemas())
+})
+
+// Validate validates the payload against the schema of the topic, topics without a schema accept any
+// payload. It returns apperr.EventPayloadInvalidErr wrapping the violations if the payload does not
+// validate.
+func (v *Validator) Validate(topic string, payload []byte) error {
+	sch, ok := v.schemas[topic]
+	if !ok {
+		return nil
+	}
+
+	if err := ValidateJSON(sch, payload); err != nil {
+		return apperr.EventPayloadInvalidErr.WrapParent(fmt.Errorf("topic %s: %w", topic, err))
+	}
+
+	return nil
+}
+
+// Compile compiles a JSON Schema definition, without fetching remote references.
+func Compile(schema string) (*jsonschema.Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(strings.NewReader(schema))
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal json schema: %w", err)
+	}
+
+	c := jsonschema.NewCompiler()
+	c.AssertFormat()
+	if err := c.AddResource("schema.json", doc); err != nil {
+		return nil, fmt.Errorf("add json schema: %w", err)
+	}
+
+	sch, err := c.Compile("schema.json")
+	if err != nil {
+		return nil, fmt.Errorf("compile json schema: %w", err)
+	}
+
+	return sch, nil
+}
+
+// ValidateJSON validates the JSON payload against the compiled schema.
+func ValidateJSON(sch *jsonschema.Schema, payload []byte) error {
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("unmarshal json payload: %w", err)
+	}
+
+	if err := sch.Validate(inst); err != nil {
+		return fmt.Errorf("validate json payload: %w", err)
+	}
+
+	return nil
+}
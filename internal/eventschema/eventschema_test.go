@@ -0,0 +1,59 @@
+package eventschema_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/apperr"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/event"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/eventschema"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/zerror"
+)
+
+func TestValidator(t *testing.T) {
+	validator, err := eventschema.Default()
+	require.NoError(t, err)
+
+	t.Run("Should accept the payloads published by the services", func(t *testing.T) {
+		payload, err := json.Marshal(event.ProductCreatedEvent{
+			ProductID:     "0199f0c4-5b7a-7c1e-9d2a-3b4c5d6e7f80",
+			Name:          "Keyboard",
+			Sku:           "KB-001",
+			Price:         49.9,
+			StockQuantity: 10,
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, validator.Validate(event.TopicProductCreated, payload))
+	})
+
+	t.Run("Should reject payloads not matching the schema with a validation error", func(t *testing.T) {
+		for name, payload := range map[string]string{
+			"missing field":  `{"product_id":"0199f0c4-5b7a-7c1e-9d2a-3b4c5d6e7f80","name":"Keyboard","sku":"KB-001","price":49.9}`,
+			"invalid uuid":   `{"product_id":"1","name":"Keyboard","sku":"KB-001","price":49.9,"stock_quantity":10}`,
+			"wrong type":     `{"product_id":"0199f0c4-5b7a-7c1e-9d2a-3b4c5d6e7f80","name":"Keyboard","sku":"KB-001","price":"49.9","stock_quantity":10}`,
+			"malformed json": `{"product_id":`,
+		} {
+			err := validator.Validate(event.TopicProductCreated, []byte(payload))
+
+			var zErr zerror.ZError
+			require.True(t, errors.As(err, &zErr), name)
+			assert.Equal(t, zerror.StatusValidationFailed, zErr.Status(), name)
+			assert.Equal(t, apperr.EventPayloadInvalidErrorCode, zErr.Code(), name)
+			assert.Error(t, zErr.Parent(), name)
+		}
+	})
+
+	t.Run("Should accept any payload of a topic without a schema", func(t *testing.T) {
+		require.NoError(t, validator.Validate("product.unknown", []byte(`{"id":1}`)))
+	})
+
+	t.Run("Should fail on an invalid schema", func(t *testing.T) {
+		_, err := eventschema.NewValidator(map[string][]byte{"product.created": []byte(`{"type":"unknown"}`)})
+		require.ErrorContains(t, err, "schema for topic product.created")
+	})
+}
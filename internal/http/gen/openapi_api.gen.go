@@ -18,13 +18,39 @@ import (
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/go-chi/chi/v5"
+	"github.com/oapi-codegen/runtime"
 	strictnethttp "github.com/oapi-codegen/runtime/strictmiddleware/nethttp"
 	openapi_types "github.com/oapi-codegen/runtime/types"
 )
 
+const (
+	AdminBearerAuthScopes = "adminBearerAuth.Scopes"
+)
+
+// Defines values for OutboxMessageStatus.
+const (
+	OutboxMessageStatusFailed    OutboxMessageStatus = "failed"
+	OutboxMessageStatusPending   OutboxMessageStatus = "pending"
+	OutboxMessageStatusProcessed OutboxMessageStatus = "processed"
+)
+
+// Defines values for OutboxReplayMode.
+const (
+	OutboxReplayModeCopy  OutboxReplayMode = "copy"
+	OutboxReplayModeReset OutboxReplayMode = "reset"
+)
+
 // ListProductsResponse The list of products
 type ListProductsResponse = []ProductResponse
 
+// CountOutboxMessagesResponse defines model for CountOutboxMessagesResponse.
+type CountOutboxMessagesResponse struct {
+	Total OutboxMessageCounts `json:"total"`
+
+	// Topics The counts per topic, sorted by topic
+	Topics []OutboxTopicMessageCounts `json:"topics"`
+}
+
 // CreateProductRequest defines model for CreateProductRequest.
 type CreateProductRequest struct {
 	// Name The name of the product
@@ -59,6 +85,79 @@ type FieldError struct {
 	Message string `json:"message"`
 }
 
+// ListOutboxMessagesResponse defines model for ListOutboxMessagesResponse.
+type ListOutboxMessagesResponse struct {
+	// Items The messages of the page, newest first
+	Items []OutboxMessageResponse `json:"items"`
+
+	// NextCursor The cursor of the next page, absent on the last page
+	NextCursor *string `json:"nextCursor,omitempty"`
+}
+
+// OutboxMessageCounts defines model for OutboxMessageCounts.
+type OutboxMessageCounts struct {
+	// Pending The number of pending messages
+	Pending int64 `json:"pending"`
+
+	// Processed The number of processed messages
+	Processed int64 `json:"processed"`
+
+	// Failed The number of failed messages
+	Failed int64 `json:"failed"`
+}
+
+// OutboxMessageResponse defines model for OutboxMessageResponse.
+type OutboxMessageResponse struct {
+	// Id The unique identifier of the message
+	Id openapi_types.UUID `json:"id"`
+
+	// Topic The topic the message is relayed to
+	Topic string `json:"topic"`
+
+	// Status The relay status of the message, pending messages are waiting to be relayed, failed messages could not be relayed
+	Status OutboxMessageStatus `json:"status"`
+
+	// Headers The headers of the message
+	Headers map[string]string `json:"headers"`
+
+	// Payload The JSON payload of the message
+	Payload json.RawMessage `json:"payload"`
+
+	// PartitionKey The partition key of the message
+	PartitionKey *string `json:"partitionKey,omitempty"`
+
+	// CreatedAt The date and time the message was created
+	CreatedAt time.Time `json:"createdAt"`
+
+	// ProcessedAt The date and time the message was relayed or failed
+	ProcessedAt *time.Time `json:"processedAt,omitempty"`
+
+	// Error The error of the last relay attempt of a failed message
+	Error *string `json:"error,omitempty"`
+}
+
+// OutboxMessageStatus The relay status of the message, pending messages are waiting to be relayed, failed messages could not be relayed
+type OutboxMessageStatus string
+
+// OutboxMessagesAffectedResponse defines model for OutboxMessagesAffectedResponse.
+type OutboxMessagesAffectedResponse struct {
+	// Affected The number of messages requeued or replayed, or that would be on a dry run
+	Affected int64 `json:"affected"`
+
+	// DryRun Whether the messages were only counted
+	DryRun bool `json:"dryRun"`
+}
+
+// OutboxReplayMode How the messages are replayed, reset makes them pending again and keeps their id so consumers deduplicating by message id skip them, copy inserts pending copies with new ids
+type OutboxReplayMode string
+
+// OutboxTopicMessageCounts defines model for OutboxTopicMessageCounts.
+type OutboxTopicMessageCounts struct {
+	// Topic The topic of the messages
+	Topic  string              `json:"topic"`
+	Counts OutboxMessageCounts `json:"counts"`
+}
+
 // ProductResponse defines model for ProductResponse.
 type ProductResponse struct {
 	// Id The unique identifier of the product
@@ -83,11 +182,101 @@ type ProductResponse struct {
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
+// ReplayOutboxMessagesRequest defines model for ReplayOutboxMessagesRequest.
+type ReplayOutboxMessagesRequest struct {
+	// Mode How the messages are replayed, reset makes them pending again and keeps their id so consumers deduplicating by message id skip them, copy inserts pending copies with new ids
+	Mode OutboxReplayMode `json:"mode"`
+
+	// Ids Only replay the messages with the ids
+	Ids *[]openapi_types.UUID `json:"ids,omitempty"`
+
+	// Topic Only replay the messages of the topic
+	Topic *string `json:"topic,omitempty"`
+
+	// PartitionKey Only replay the messages with the partition key
+	PartitionKey *string `json:"partitionKey,omitempty"`
+
+	// CreatedAfter Only replay the messages created at or after the date and time
+	CreatedAfter *time.Time `json:"createdAfter,omitempty"`
+
+	// CreatedBefore Only replay the messages created before the date and time
+	CreatedBefore *time.Time `json:"createdBefore,omitempty"`
+
+	// DryRun Count the messages that would be replayed without replaying them
+	DryRun *bool `json:"dryRun,omitempty"`
+}
+
+// RequeueOutboxMessagesRequest defines model for RequeueOutboxMessagesRequest.
+type RequeueOutboxMessagesRequest struct {
+	// Ids Only requeue the messages with the ids
+	Ids *[]openapi_types.UUID `json:"ids,omitempty"`
+
+	// Topic Only requeue the messages of the topic
+	Topic *string `json:"topic,omitempty"`
+
+	// CreatedAfter Only requeue the messages created at or after the date and time
+	CreatedAfter *time.Time `json:"createdAfter,omitempty"`
+
+	// CreatedBefore Only requeue the messages created before the date and time
+	CreatedBefore *time.Time `json:"createdBefore,omitempty"`
+
+	// Error Only requeue the messages whose error contains the text
+	Error *string `json:"error,omitempty"`
+
+	// DryRun Count the messages that would be requeued without requeuing them
+	DryRun *bool `json:"dryRun,omitempty"`
+}
+
+// ListOutboxMessagesParams defines parameters for ListOutboxMessages.
+type ListOutboxMessagesParams struct {
+	// Topic Only list the messages of the topic
+	Topic *string `form:"topic,omitempty" json:"topic,omitempty"`
+
+	// Status Only list the messages with the status
+	Status *OutboxMessageStatus `form:"status,omitempty" json:"status,omitempty"`
+
+	// CreatedAfter Only list the messages created at or after the date and time
+	CreatedAfter *time.Time `form:"createdAfter,omitempty" json:"createdAfter,omitempty"`
+
+	// CreatedBefore Only list the messages created before the date and time
+	CreatedBefore *time.Time `form:"createdBefore,omitempty" json:"createdBefore,omitempty"`
+
+	// Error Only list the messages whose error contains the text
+	Error *string `form:"error,omitempty" json:"error,omitempty"`
+
+	// Cursor The nextCursor of the previous page
+	Cursor *string `form:"cursor,omitempty" json:"cursor,omitempty"`
+
+	// Limit The number of messages per page
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// ReplayOutboxMessagesJSONRequestBody defines body for ReplayOutboxMessages for application/json ContentType.
+type ReplayOutboxMessagesJSONRequestBody = ReplayOutboxMessagesRequest
+
+// RequeueOutboxMessagesJSONRequestBody defines body for RequeueOutboxMessages for application/json ContentType.
+type RequeueOutboxMessagesJSONRequestBody = RequeueOutboxMessagesRequest
+
 // CreateProductJSONRequestBody defines body for CreateProduct for application/json ContentType.
 type CreateProductJSONRequestBody = CreateProductRequest
 
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
+	// Count outbox messages
+	// (GET /api/v1/admin/outbox/counts)
+	CountOutboxMessages(w http.ResponseWriter, r *http.Request)
+	// List outbox messages
+	// (GET /api/v1/admin/outbox/messages)
+	ListOutboxMessages(w http.ResponseWriter, r *http.Request, params ListOutboxMessagesParams)
+	// Replay processed outbox messages
+	// (POST /api/v1/admin/outbox/messages/replay)
+	ReplayOutboxMessages(w http.ResponseWriter, r *http.Request)
+	// Requeue failed outbox messages
+	// (POST /api/v1/admin/outbox/messages/requeue)
+	RequeueOutboxMessages(w http.ResponseWriter, r *http.Request)
+	// Get an outbox message
+	// (GET /api/v1/admin/outbox/messages/{id})
+	GetOutboxMessage(w http.ResponseWriter, r *http.Request, id openapi_types.UUID)
 	// List all products
 	// (GET /api/v1/products)
 	ListProducts(w http.ResponseWriter, r *http.Request)
@@ -100,6 +289,36 @@ type ServerInterface interface {
 
 type Unimplemented struct{}
 
+// Count outbox messages
+// (GET /api/v1/admin/outbox/counts)
+func (_ Unimplemented) CountOutboxMessages(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// List outbox messages
+// (GET /api/v1/admin/outbox/messages)
+func (_ Unimplemented) ListOutboxMessages(w http.ResponseWriter, r *http.Request, params ListOutboxMessagesParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Replay processed outbox messages
+// (POST /api/v1/admin/outbox/messages/replay)
+func (_ Unimplemented) ReplayOutboxMessages(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Requeue failed outbox messages
+// (POST /api/v1/admin/outbox/messages/requeue)
+func (_ Unimplemented) RequeueOutboxMessages(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Get an outbox message
+// (GET /api/v1/admin/outbox/messages/{id})
+func (_ Unimplemented) GetOutboxMessage(w http.ResponseWriter, r *http.Request, id openapi_types.UUID) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
 // List all products
 // (GET /api/v1/products)
 func (_ Unimplemented) ListProducts(w http.ResponseWriter, r *http.Request) {
@@ -121,6 +340,178 @@ type ServerInterfaceWrapper struct {
 
 type MiddlewareFunc func(http.Handler) http.Handler
 
+// CountOutboxMessages operation middleware
+func (siw *ServerInterfaceWrapper) CountOutboxMessages(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, AdminBearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CountOutboxMessages(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ListOutboxMessages operation middleware
+func (siw *ServerInterfaceWrapper) ListOutboxMessages(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, AdminBearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListOutboxMessagesParams
+
+	// ------------- Optional query parameter "topic" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "topic", r.URL.Query(), &params.Topic)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "topic", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", r.URL.Query(), &params.Status)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "status", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "createdAfter" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "createdAfter", r.URL.Query(), &params.CreatedAfter)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "createdAfter", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "createdBefore" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "createdBefore", r.URL.Query(), &params.CreatedBefore)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "createdBefore", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "error" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "error", r.URL.Query(), &params.Error)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "error", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", r.URL.Query(), &params.Cursor)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "cursor", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListOutboxMessages(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ReplayOutboxMessages operation middleware
+func (siw *ServerInterfaceWrapper) ReplayOutboxMessages(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, AdminBearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ReplayOutboxMessages(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// RequeueOutboxMessages operation middleware
+func (siw *ServerInterfaceWrapper) RequeueOutboxMessages(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, AdminBearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.RequeueOutboxMessages(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetOutboxMessage operation middleware
+func (siw *ServerInterfaceWrapper) GetOutboxMessage(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, AdminBearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetOutboxMessage(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
 // ListProducts operation middleware
 func (siw *ServerInterfaceWrapper) ListProducts(w http.ResponseWriter, r *http.Request) {
 
@@ -262,6 +653,21 @@ func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handl
 		ErrorHandlerFunc:   options.ErrorHandlerFunc,
 	}
 
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/api/v1/admin/outbox/counts", wrapper.CountOutboxMessages)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/api/v1/admin/outbox/messages", wrapper.ListOutboxMessages)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/api/v1/admin/outbox/messages/replay", wrapper.ReplayOutboxMessages)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/api/v1/admin/outbox/messages/requeue", wrapper.RequeueOutboxMessages)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/api/v1/admin/outbox/messages/{id}", wrapper.GetOutboxMessage)
+	})
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/api/v1/products", wrapper.ListProducts)
 	})
@@ -272,6 +678,171 @@ func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handl
 	return r
 }
 
+type CountOutboxMessagesRequestObject struct {
+}
+
+type CountOutboxMessagesResponseObject interface {
+	VisitCountOutboxMessagesResponse(w http.ResponseWriter) error
+}
+
+type CountOutboxMessages200JSONResponse CountOutboxMessagesResponse
+
+func (response CountOutboxMessages200JSONResponse) VisitCountOutboxMessagesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CountOutboxMessages401JSONResponse ErrorResponse
+
+func (response CountOutboxMessages401JSONResponse) VisitCountOutboxMessagesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListOutboxMessagesRequestObject struct {
+	Params ListOutboxMessagesParams
+}
+
+type ListOutboxMessagesResponseObject interface {
+	VisitListOutboxMessagesResponse(w http.ResponseWriter) error
+}
+
+type ListOutboxMessages200JSONResponse ListOutboxMessagesResponse
+
+func (response ListOutboxMessages200JSONResponse) VisitListOutboxMessagesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListOutboxMessages400JSONResponse ErrorResponse
+
+func (response ListOutboxMessages400JSONResponse) VisitListOutboxMessagesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListOutboxMessages401JSONResponse ErrorResponse
+
+func (response ListOutboxMessages401JSONResponse) VisitListOutboxMessagesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ReplayOutboxMessagesRequestObject struct {
+	Body *ReplayOutboxMessagesJSONRequestBody
+}
+
+type ReplayOutboxMessagesResponseObject interface {
+	VisitReplayOutboxMessagesResponse(w http.ResponseWriter) error
+}
+
+type ReplayOutboxMessages200JSONResponse OutboxMessagesAffectedResponse
+
+func (response ReplayOutboxMessages200JSONResponse) VisitReplayOutboxMessagesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ReplayOutboxMessages400JSONResponse ErrorResponse
+
+func (response ReplayOutboxMessages400JSONResponse) VisitReplayOutboxMessagesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ReplayOutboxMessages401JSONResponse ErrorResponse
+
+func (response ReplayOutboxMessages401JSONResponse) VisitReplayOutboxMessagesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type RequeueOutboxMessagesRequestObject struct {
+	Body *RequeueOutboxMessagesJSONRequestBody
+}
+
+type RequeueOutboxMessagesResponseObject interface {
+	VisitRequeueOutboxMessagesResponse(w http.ResponseWriter) error
+}
+
+type RequeueOutboxMessages200JSONResponse OutboxMessagesAffectedResponse
+
+func (response RequeueOutboxMessages200JSONResponse) VisitRequeueOutboxMessagesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type RequeueOutboxMessages400JSONResponse ErrorResponse
+
+func (response RequeueOutboxMessages400JSONResponse) VisitRequeueOutboxMessagesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type RequeueOutboxMessages401JSONResponse ErrorResponse
+
+func (response RequeueOutboxMessages401JSONResponse) VisitRequeueOutboxMessagesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetOutboxMessageRequestObject struct {
+	Id openapi_types.UUID `json:"id"`
+}
+
+type GetOutboxMessageResponseObject interface {
+	VisitGetOutboxMessageResponse(w http.ResponseWriter) error
+}
+
+type GetOutboxMessage200JSONResponse OutboxMessageResponse
+
+func (response GetOutboxMessage200JSONResponse) VisitGetOutboxMessageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetOutboxMessage401JSONResponse ErrorResponse
+
+func (response GetOutboxMessage401JSONResponse) VisitGetOutboxMessageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetOutboxMessage404JSONResponse ErrorResponse
+
+func (response GetOutboxMessage404JSONResponse) VisitGetOutboxMessageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
 type ListProductsRequestObject struct {
 }
 
@@ -325,6 +896,21 @@ func (response CreateProduct400JSONResponse) VisitCreateProductResponse(w http.R
 
 // StrictServerInterface represents all server handlers.
 type StrictServerInterface interface {
+	// Count outbox messages
+	// (GET /api/v1/admin/outbox/counts)
+	CountOutboxMessages(ctx context.Context, request CountOutboxMessagesRequestObject) (CountOutboxMessagesResponseObject, error)
+	// List outbox messages
+	// (GET /api/v1/admin/outbox/messages)
+	ListOutboxMessages(ctx context.Context, request ListOutboxMessagesRequestObject) (ListOutboxMessagesResponseObject, error)
+	// Replay processed outbox messages
+	// (POST /api/v1/admin/outbox/messages/replay)
+	ReplayOutboxMessages(ctx context.Context, request ReplayOutboxMessagesRequestObject) (ReplayOutboxMessagesResponseObject, error)
+	// Requeue failed outbox messages
+	// (POST /api/v1/admin/outbox/messages/requeue)
+	RequeueOutboxMessages(ctx context.Context, request RequeueOutboxMessagesRequestObject) (RequeueOutboxMessagesResponseObject, error)
+	// Get an outbox message
+	// (GET /api/v1/admin/outbox/messages/{id})
+	GetOutboxMessage(ctx context.Context, request GetOutboxMessageRequestObject) (GetOutboxMessageResponseObject, error)
 	// List all products
 	// (GET /api/v1/products)
 	ListProducts(ctx context.Context, request ListProductsRequestObject) (ListProductsResponseObject, error)
@@ -362,6 +948,144 @@ type strictHandler struct {
 	options     StrictHTTPServerOptions
 }
 
+// CountOutboxMessages operation middleware
+func (sh *strictHandler) CountOutboxMessages(w http.ResponseWriter, r *http.Request) {
+	var request CountOutboxMessagesRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.CountOutboxMessages(ctx, request.(CountOutboxMessagesRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "CountOutboxMessages")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(CountOutboxMessagesResponseObject); ok {
+		if err := validResponse.VisitCountOutboxMessagesResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// ListOutboxMessages operation middleware
+func (sh *strictHandler) ListOutboxMessages(w http.ResponseWriter, r *http.Request, params ListOutboxMessagesParams) {
+	var request ListOutboxMessagesRequestObject
+
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ListOutboxMessages(ctx, request.(ListOutboxMessagesRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ListOutboxMessages")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ListOutboxMessagesResponseObject); ok {
+		if err := validResponse.VisitListOutboxMessagesResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// ReplayOutboxMessages operation middleware
+func (sh *strictHandler) ReplayOutboxMessages(w http.ResponseWriter, r *http.Request) {
+	var request ReplayOutboxMessagesRequestObject
+
+	var body ReplayOutboxMessagesJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ReplayOutboxMessages(ctx, request.(ReplayOutboxMessagesRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ReplayOutboxMessages")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ReplayOutboxMessagesResponseObject); ok {
+		if err := validResponse.VisitReplayOutboxMessagesResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// RequeueOutboxMessages operation middleware
+func (sh *strictHandler) RequeueOutboxMessages(w http.ResponseWriter, r *http.Request) {
+	var request RequeueOutboxMessagesRequestObject
+
+	var body RequeueOutboxMessagesJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.RequeueOutboxMessages(ctx, request.(RequeueOutboxMessagesRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "RequeueOutboxMessages")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(RequeueOutboxMessagesResponseObject); ok {
+		if err := validResponse.VisitRequeueOutboxMessagesResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetOutboxMessage operation middleware
+func (sh *strictHandler) GetOutboxMessage(w http.ResponseWriter, r *http.Request, id openapi_types.UUID) {
+	var request GetOutboxMessageRequestObject
+
+	request.Id = id
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetOutboxMessage(ctx, request.(GetOutboxMessageRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetOutboxMessage")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetOutboxMessageResponseObject); ok {
+		if err := validResponse.VisitGetOutboxMessageResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
 // ListProducts operation middleware
 func (sh *strictHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
 	var request ListProductsRequestObject
@@ -420,22 +1144,51 @@ func (sh *strictHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 // Base64 encoded, gzipped, json marshaled Swagger object
 var swaggerSpec = []string{
 
-	"H4sIAAAAAAAC/8xXb2/bthP+KsT9fi9lS7IdZ9O7dmuBoGuStc6bBUHBiGebrUQq5CmNEei7D6RoR7Zl",
-	"JxvSYkCBGuTp/jz33HPMI+S6rLRCRRayR7D5Ekvuf/5mkBNeGi3qnD7hXY2W3HlldIWGJHorxUt0/wu0",
-	"uZEVSa0gg9kSmbthes5oiaxqvUAE+MDLqkDIIHhmKURAq8odWTJSLSCCh4E2Ag1kaRNBZWR+IIa/OhIk",
-	"TZKNc1WXt2i884UehMN5oTlNJ92Q4yYC+63uD/j5w9Wxmj5/uEpH42MFjZx30vm3P2uuSNKqP443YXfB",
-	"5oUVSkW4CCWGcJOmicDgXS0NCsiu2361Ba6R3U3oZuNQ337FnKCJ4J0x2nxCW2llcZ8FuRY9Hbqo6VY/",
-	"sIoToVEMnQ/mTbuYnc3effxyfjH78v7i6vz358ggkLgsfFBJWPof/zc4hwz+Fz9xOQ5Ejt9LLITP3pUR",
-	"XHNj+Gq35yVayxcvLWNtvVUJYcmUJjbXtRLHWbDTloDK2mtfCzqV7OE/d3f7mftjFlr+lGc4OIrzQTQO",
-	"l3/uBl5atqnrnwDQVnAcgT+kpaAatsvF/fEppCU3NGFgLEQvY8tG7YLzI5RxIO2a78+F11DxhvrTFJyQ",
-	"cSUYyRK7E86+c8vCx1sYj5LRySBJB0k6S5LM//sLIphrU3KCDJzHgfN2DPxpE4EU/SnVSt7VyKRARXIu",
-	"0RyTu3Q0xsnJ9HSAv/x6O0hHYjzgk5PpYDKaTtNJejpJkqSbXV1L8RzxfvBCGf38hTL5oQtl/HMXykkT",
-	"QV2Jf8/q8PHrs/p0V1I8116w76LOmHaL25cgF0GquT64Iy7DjnhzeQYRFDLHIAstq+Hj2cyFMAVksCSq",
-	"bBbHukJldW1yHGqziMNHNna2Tn8keYx6I9yjsW0CyTAdJs7eueOVhAzGw2ToZqHitPRqFPNKxvdpvFHF",
-	"7BEW2NNFJ7SMF0VXP52scXd/JoLF5dOlCQroXY6SpH0TKELlvfOqKmTuv46/Whdi/dJ8TpB7Jd/3YTvj",
-	"tQ0zSEbiPQpm6zxHa+d1UawcMJNXTGv7OdSTz1su/B50b2Z3azGvjR/O65sIbF2W3KwOIE18YR1/N0c3",
-	"TrO07WlU+0ZnnCn83hnm7V5tPeShnRG09FaL1asB0vvHQrM9kWRqbPa4kr5aDnvL+yBN1qv1P8aRDSsO",
-	"tLWHGC23jNMByK4fg7TE0Nw0fwcAAP//au0tItsNAAA=",
+	"H4sIAAAAAAAC/+xba1PbuPr/Khr9/y8d4lyAlnf0sl1ON8BC2D1th+ko9mOiYkuuJANpJ9/9jGTZsRPZ",
+	"cSh0ujM7U2aoLev5PfeLxHcc8CTlDJiS+Og7lsEcEmJ+fc0zps4yNeMPE5CS3IC8AJlyJkG/TgVPQSgK",
+	"ZrHiKQ3MbyHIQNBUUc7wEZ7OAQV6I4lSEMgs85DkQkGIZov8AfYwVZCYz/9fQISP8P/1V7j6FlQ/BzPV",
+	"n1hEBqPESw+rRQr4CBMhyAJ7+KHHRQgCHw31O65I3G3ztX2XHhbwNaMCQnz0yW7kFdxel2T57AsESuN4",
+	"LYAoOBc8zAJ1AV8zkGpTXIwk4BaWfoN4hNQcUJrvgj0MDyRJY03J7owGuCQulaDspsr0YOnhVNCggYZ5",
+	"1UJk4Pvl5ixLZiDM5je8Zx9GMSfqYFwlOVp6WN5mboKX76/aeLp8fzUYjtoY0lqUige3f2aEKaoWbjpm",
+	"Cfpq13TkkDIFNyCq5Mbrijf6yhksJLsOyGUMb4XgotlpAh46NJQbIkqJUiAYAr0HMkurMjuZvp18Pj2b",
+	"fv7t7Or0zTZjCEERGhuinTztNwpxaNC3+ZbWeZI7TEc2itU1ThQkiHGFIp6xsN0K1tRipVLs6lJBhZMN",
+	"+Uf63SZy8xhZla9w2getcm6URjP7p9rhqUQlX7sIIOegXQJ/UNk5jJfGselbloQsvYrcgIcY3INUKKJC",
+	"qt3CuAVTYmmxMy1ZBg/qdSYkF254gXlXgNOrLUIyk8AU4sy8iInMX9R0MPlyvDi99O8n+uevP+8nb3j+",
+	"81saTd683f8wnSen767U2bvJ4gP1Rx/fnMZ/TONkMj1Wk+Tk24dvF7enf18NP34J7nfSXy6vJq3ZWF/T",
+	"1ybnMZVK823DnOyqhjJHdVOAKz9uehShMYQNmc1kEo00X1VaVFUVIw9HXCRE5YHZ5JjmOK0DUAos1GLe",
+	"QtMucxIdDHeimmdXHoCU23ktF7op+8PxTrQ3LKhgvwrJK/TgMiy3923mJlPHhMfKzWBIFCDCQqRoAsaz",
+	"LHvonkhkP6752NAf7vf8Qc8fTH3/yPz7iCu86x17erc2BzpcenkodaMyr4oYYFxdQEwWiCgFSWq8hKxZ",
+	"Xw3jTPBbEChj5I7QmMziVjAvlx6eAwlBGImRMKQaC4nP66Vx7ful58BtdymQO6B9x//tvb0DpnrTfDvr",
+	"7XsrURcL/gIh860HeLluALUix8O0wX4zRr9mgGgITNGIgmiBhv3BS/IiGkPvRTCc9Q7DEfReRgO/N5yN",
+	"gnG4DwfRoV/VdJbRcGv1SoQy0nwPDbVeuQLdwqIN3mA4gvH+wWEPXryc9QbDcNQj4/2D3nh4cDAYDw7H",
+	"vu+3wTkwcBYxJw3C+s/l2SmyK1pVmFf9tRLeqvEzDbsiXdZL8S+Ss70Lcj8pCZbA96tx6nGObNwHQsSF",
+	"dZstLj3Y3aVfmNKeqGy3uuEy/2RpmzE3c+ZVjSm64knxGjObDrVDGg+LphCXzKxiw8p8vEpY3RqbL0uh",
+	"bDKWh7Wc0prJeRupDhGhtUmVfqg4mkEhA289FeteXde+XFVWYQ8Dy5Iu2aYiznLlRvyrcSmPowgCBWFz",
+	"KiJ2xbZUWzKhVQNZbrYCUssqF0jNiUL3hsUZ6JKQoFAskMhY1RTGu5cDoVhcZGwT4N9zUHMQVf1IdA9C",
+	"E48X+WSk7lQRiSWU5Gacx0BYq/GV4ilhNJvWhZHGxNl3/s7v6zi12azEJ0CCQgm5BamXJaWZkRtCmQkh",
+	"twCpeUkFoiGSHAWcySwBIVEIYZbGNCDGCmeLlUeGSN7S1OzpoYCnC0SZBKFkSSHgKdVyo2quGw5EQ1mx",
+	"SQNMuxZPF3UbNE8aDdAxR3J06MXznUdHHQJT3XHlY8PRYHNMlUcii95lDuvF/4/Xfxbw89Z/B4+pWVwj",
+	"p45FwU41yzMP9YY/f6g3ftah3ujnDvV0OZSl4eOt2n78HF2Nq57oMHOs1hNV5lwen4f+9UFQw3y62DZS",
+	"4Gi1znTuyjNDPWPYzxBROtsS/bVZUJPr08tPa9aSfgURF/AIyDPzYVe0wx+MYdV6ISJZrMrEX4dtkkkd",
+	"cL2GKfKzSY48U/aBqfTmkOC2SuLQBFO5g7BMBtZPaFgbM7WHyS2nM4mtRrZn2Er1srVF3M5DrYF80o5x",
+	"3Jz8G2HZsFak7kcVAqP1OGJE6w4GpkJ+ymhgNvwlwsGoczhowfzz4sH4aeOBbX1W8UA/6BQPDpqHa83y",
+	"up9zCeUhFVOEMmlWKHhQPzBc298SnFxQnjo6Dbb6sQPFUziy6fHWnFYXSxBkgqrFpY6KtjcOE8peAREg",
+	"jjM1d5c1ZhFS/BaYhySoXFK/T6fnn4/fTE5OP0/P3r89xV5++m90ZXZcQZwrleKlxkBZxBsP+87tYd/x",
+	"+Qn2cEwDsL2FnXxNTqbYw5mI7ZbyqN/nKTDJMxHAHhc3ffuR7Ou1WgpUxdBE4a4cdfp7gz0zG9PbkZTi",
+	"Izza8/fMiI2ouZFVn6S0fzfoG3H0udmwv+rwbsBRFa4cLl+/0vOsmL94yAhXkdgEifKaAzZgBNE7nYTF",
+	"XvWQiz0sbAtmMAx9P287mQJm4JDUds2c9fWsb3VJY1vObLu/YVTpVGHRludyQQKUoHAHIZJZEICUURbH",
+	"Cy3osT94Mqj1c3IHuCtGMjXngn6DsOYK+OiTwwk+XS+vPSyzJCFiUWpxTYPYw4rcSDNC0Rvga72x00jK",
+	"T5rMRJ/WOa0kISqY2+iLIhorEHLj1LRuJpvntcaIBUlAmaOGT85gFBcQmiIR1Uu/ZiAWRXNxtJpZlmpa",
+	"C45LryOxMviW408XvfJlN7twTnw7Q+pafriA1kqeKtwOuX13hC3FRgs4W9w8G7ptqd0FzazezaCm9rz+",
+	"de0EPxVwR3kmi8N6pxzMF4+gtjky1lG7hVJME6pqhMo6beh7OCEPNMkSO4JIKLP/2xhHLJfXzxjxW256",
+	"bA347aHe/3mh/hUJ88JKqn9amjFZ4MezTD/vD00fxqUj25xns5jKeTGgsvcLuqSefFjv6ZAYA9FoWfFu",
+	"7Q5SPSe5hkc47zVBqlc8XDyZktrmVMt6g6tEBstn9KctB1WdfMqOZ/51qce5VG4NzVb+OPcybVuzf03I",
+	"bZ6M7eloJ8+qH4dFPK818oNaxU10XdhDri3et4cuIOAilCgEEvZiUApEfl36PYluSeVUjQgwh7XrCHUB",
+	"od/pCQCPkAx4Cp62xlXkSFAkeGJP6yqE7NkUZVIBcUUCx+To2UJBy5TqHxgL7Gjm31jw2FiQj1vcXvmY",
+	"SPCdhsvGnu4dKETYGpm8z6FKlne2TM9f3u+oO8s7qNdj2xq5jlevTIWqxxqrAtUMture4OwL3COwZ61K",
+	"G677bp1A/JKjB018/POIr4lkdUt+d+9xmnO705Q3ilvHHiSOq3ePN4cZ56uXz9r6bFyXdgi0WPNLdjt1",
+	"nW62FmuSLjRXPrpeeg0VTf5XSYiY6zOro/O1+WT1T5eeKaU7/zyqUyp/OpffuPjeaCblgObXspHVXNOt",
+	"VodhmO9A3BV5J5/B9/Hyevm/AQA1Q8X/AzgAAA==",
 }
 
 // GetSwagger returns the content of the embedded swagger specification file
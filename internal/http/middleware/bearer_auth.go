@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/apperr"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/http/apierr"
+)
+
+// BearerAuth is a middleware that rejects the requests without the token in their
+// Authorization header with a HTTP 401 (Unauthorized) status.
+// Every request is rejected when the token is empty.
+func BearerAuth(token string) func(http.Handler) http.Handler {
+	res := apierr.New(apperr.UnauthorizedErr)
+	errorMsg, err := json.Marshal(res)
+	if err != nil {
+		panic(err)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				w.WriteHeader(res.StatusCode)
+				//nolint:errcheck
+				w.Write(errorMsg)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package http
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/http/gen"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/model"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/service"
+)
+
+type outboxHandler struct {
+	outboxSvc service.OutboxService
+}
+
+func newOutboxHandler(outboxSvc service.OutboxService) *outboxHandler {
+	return &outboxHandler{
+		outboxSvc: outboxSvc,
+	}
+}
+
+func (h *outboxHandler) ListOutboxMessages(ctx context.Context, request gen.ListOutboxMessagesRequestObject) (gen.ListOutboxMessagesResponseObject, error) {
+	params := service.ListOutboxMsgsParams{
+		Topic:         request.Params.Topic,
+		Status:        (*model.OutboxMsgStatus)(request.Params.Status),
+		CreatedAfter:  request.Params.CreatedAfter,
+		CreatedBefore: request.Params.CreatedBefore,
+		ErrorContains: request.Params.Error,
+		Cursor:        request.Params.Cursor,
+	}
+	if request.Params.Limit != nil {
+		params.Limit = *request.Params.Limit
+	}
+
+	result, err := h.outboxSvc.ListOutboxMsgs(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("outbox service list outbox msgs: %w", err)
+	}
+
+	items := make([]gen.OutboxMessageResponse, 0, len(result.Msgs))
+	for _, msg := range result.Msgs {
+		items = append(items, toOutboxMessageResponse(msg))
+	}
+
+	return gen.ListOutboxMessages200JSONResponse{
+		Items:      items,
+		NextCursor: result.NextCursor,
+	}, nil
+}
+
+func (h *outboxHandler) GetOutboxMessage(ctx context.Context, request gen.GetOutboxMessageRequestObject) (gen.GetOutboxMessageResponseObject, error) {
+	msg, err := h.outboxSvc.GetOutboxMsg(ctx, request.Id)
+	if err != nil {
+		return nil, fmt.Errorf("outbox service get outbox msg: %w", err)
+	}
+
+	return gen.GetOutboxMessage200JSONResponse(toOutboxMessageResponse(msg)), nil
+}
+
+func (h *outboxHandler) CountOutboxMessages(ctx context.Context, request gen.CountOutboxMessagesRequestObject) (gen.CountOutboxMessagesResponseObject, error) {
+	result, err := h.outboxSvc.CountOutboxMsgs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("outbox service count outbox msgs: %w", err)
+	}
+
+	topics := make([]gen.OutboxTopicMessageCounts, 0, len(result.Topics))
+	for _, topic := range result.Topics {
+		topics = append(topics, gen.OutboxTopicMessageCounts{
+			Topic:  topic.Topic,
+			Counts: toOutboxMessageCounts(topic.Counts),
+		})
+	}
+
+	return gen.CountOutboxMessages200JSONResponse{
+		Total:  toOutboxMessageCounts(result.Total),
+		Topics: topics,
+	}, nil
+}
+
+func (h *outboxHandler) RequeueOutboxMessages(ctx context.Context, request gen.RequeueOutboxMessagesRequestObject) (gen.RequeueOutboxMessagesResponseObject, error) {
+	params := service.RequeueOutboxMsgsParams{
+		Topic:         request.Body.Topic,
+		CreatedAfter:  request.Body.CreatedAfter,
+		CreatedBefore: request.Body.CreatedBefore,
+		ErrorContains: request.Body.Error,
+	}
+	if request.Body.Ids != nil {
+		params.IDs = *request.Body.Ids
+	}
+	if request.Body.DryRun != nil {
+		params.DryRun = *request.Body.DryRun
+	}
+
+	affected, err := h.outboxSvc.RequeueOutboxMsgs(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("outbox service requeue outbox msgs: %w", err)
+	}
+
+	return gen.RequeueOutboxMessages200JSONResponse{
+		Affected: affected,
+		DryRun:   params.DryRun,
+	}, nil
+}
+
+func (h *outboxHandler) ReplayOutboxMessages(ctx context.Context, request gen.ReplayOutboxMessagesRequestObject) (gen.ReplayOutboxMessagesResponseObject, error) {
+	params := service.ReplayOutboxMsgsParams{
+		Mode:          service.OutboxReplayMode(request.Body.Mode),
+		Topic:         request.Body.Topic,
+		PartitionKey:  request.Body.PartitionKey,
+		CreatedAfter:  request.Body.CreatedAfter,
+		CreatedBefore: request.Body.CreatedBefore,
+	}
+	if request.Body.Ids != nil {
+		params.IDs = *request.Body.Ids
+	}
+	if request.Body.DryRun != nil {
+		params.DryRun = *request.Body.DryRun
+	}
+
+	affected, err := h.outboxSvc.ReplayOutboxMsgs(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("outbox service replay outbox msgs: %w", err)
+	}
+
+	return gen.ReplayOutboxMessages200JSONResponse{
+		Affected: affected,
+		DryRun:   params.DryRun,
+	}, nil
+}
+
+func toOutboxMessageResponse(msg model.OutboxMsg) gen.OutboxMessageResponse {
+	return gen.OutboxMessageResponse{
+		Id:           msg.ID,
+		Topic:        msg.Topic,
+		Status:       gen.OutboxMessageStatus(msg.Status()),
+		Headers:      msg.Headers,
+		Payload:      msg.Payload,
+		PartitionKey: msg.PartitionKey,
+		CreatedAt:    msg.CreatedAt,
+		ProcessedAt:  msg.ProcessedAt,
+		Error:        msg.Error,
+	}
+}
+
+func toOutboxMessageCounts(counts model.OutboxMsgCounts) gen.OutboxMessageCounts {
+	return gen.OutboxMessageCounts{
+		Pending:   counts.Pending,
+		Processed: counts.Processed,
+		Failed:    counts.Failed,
+	}
+}
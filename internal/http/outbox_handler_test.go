@@ -0,0 +1,217 @@
+package http_test
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/apperr"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
+	ophttp "github.com/tuanvumaihuynh/outbox-pattern/internal/http"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/http/gen"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/repository"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/service"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/ptr"
+	"github.com/tuanvumaihuynh/outbox-pattern/test/fake"
+)
+
+const adminToken = "admin-token"
+
+func newTestServer(t *testing.T, cfg config.HTTP, outboxMsgRepo *fake.OutboxMsgRepository) *httptest.Server {
+	t.Helper()
+
+	productSvc := service.NewProductService(fake.NewDB(), fake.NewProductRepository(), outboxMsgRepo)
+	svc := ophttp.New(cfg, slog.New(slog.DiscardHandler), productSvc, service.NewOutboxService(outboxMsgRepo))
+
+	r := chi.NewRouter()
+	svc.RegisterHandlers(r)
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func get(t *testing.T, url, token string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { res.Body.Close() })
+
+	return res
+}
+
+func post(t *testing.T, url, token, body string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { res.Body.Close() })
+
+	return res
+}
+
+func decode[T any](t *testing.T, res *http.Response) T {
+	t.Helper()
+
+	var v T
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&v))
+	return v
+}
+
+func TestOutboxAdminAPI(t *testing.T) {
+	repo := fake.NewOutboxMsgRepository()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var msgs []fake.OutboxMsg
+	for i := range 3 {
+		msg, err := repo.Add(fake.OutboxMsg{
+			Topic:       "product.created",
+			Headers:     map[string]string{"X-Event-Type": "product.created"},
+			Payload:     json.RawMessage(`{"id":1}`),
+			CreatedAt:   base.Add(time.Duration(i) * time.Second),
+			ProcessedAt: ptr.New(base.Add(time.Duration(i) * time.Second)),
+		})
+		require.NoError(t, err)
+		msgs = append(msgs, msg)
+	}
+
+	srv := newTestServer(t, config.HTTP{AdminToken: adminToken}, repo)
+
+	t.Run("Should reject requests without the admin token", func(t *testing.T) {
+		for _, path := range []string{
+			"/api/v1/admin/outbox/messages",
+			"/api/v1/admin/outbox/messages/" + msgs[0].ID.String(),
+			"/api/v1/admin/outbox/counts",
+		} {
+			res := get(t, srv.URL+path, "")
+			assert.Equal(t, http.StatusUnauthorized, res.StatusCode, path)
+			assert.Equal(t, apperr.UnauthorizedErrorCode, decode[gen.ErrorResponse](t, res).Code, path)
+
+			res = get(t, srv.URL+path, "wrong-token")
+			assert.Equal(t, http.StatusUnauthorized, res.StatusCode, path)
+		}
+	})
+
+	t.Run("Should reject every request when no admin token is configured", func(t *testing.T) {
+		srv := newTestServer(t, config.HTTP{}, repo)
+
+		res := get(t, srv.URL+"/api/v1/admin/outbox/counts", "")
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("Should not require the admin token outside the admin API", func(t *testing.T) {
+		res := get(t, srv.URL+"/api/v1/products", "")
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("Should list messages with cursor pagination", func(t *testing.T) {
+		res := get(t, srv.URL+"/api/v1/admin/outbox/messages?status=processed&limit=2", adminToken)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		page := decode[gen.ListOutboxMessagesResponse](t, res)
+		require.Len(t, page.Items, 2)
+		assert.Equal(t, msgs[2].ID, page.Items[0].Id)
+		assert.Equal(t, gen.OutboxMessageStatusProcessed, page.Items[0].Status)
+		require.NotNil(t, page.NextCursor)
+
+		res = get(t, srv.URL+"/api/v1/admin/outbox/messages?limit=2&cursor="+*page.NextCursor, adminToken)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		page = decode[gen.ListOutboxMessagesResponse](t, res)
+		require.Len(t, page.Items, 1)
+		assert.Equal(t, msgs[0].ID, page.Items[0].Id)
+		assert.Nil(t, page.NextCursor)
+	})
+
+	t.Run("Should reject invalid list params", func(t *testing.T) {
+		for _, query := range []string{"limit=0x", "limit=1000", "createdAfter=yesterday", "status=stuck", "cursor=invalid"} {
+			res := get(t, srv.URL+"/api/v1/admin/outbox/messages?"+query, adminToken)
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode, query)
+		}
+	})
+
+	t.Run("Should get message with headers and payload", func(t *testing.T) {
+		res := get(t, srv.URL+"/api/v1/admin/outbox/messages/"+msgs[1].ID.String(), adminToken)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		msg := decode[gen.OutboxMessageResponse](t, res)
+		assert.Equal(t, msgs[1].ID, msg.Id)
+		assert.Equal(t, map[string]string{"X-Event-Type": "product.created"}, msg.Headers)
+		assert.JSONEq(t, `{"id":1}`, string(msg.Payload))
+
+		res = get(t, srv.URL+"/api/v1/admin/outbox/messages/"+uuid.NewString(), adminToken)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		assert.Equal(t, apperr.OutboxMsgNotFoundErrorCode, decode[gen.ErrorResponse](t, res).Code)
+	})
+
+	t.Run("Should count messages", func(t *testing.T) {
+		res := get(t, srv.URL+"/api/v1/admin/outbox/counts", adminToken)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		assert.Equal(t, gen.CountOutboxMessagesResponse{
+			Total: gen.OutboxMessageCounts{Processed: 3},
+			Topics: []gen.OutboxTopicMessageCounts{
+				{Topic: "product.created", Counts: gen.OutboxMessageCounts{Processed: 3}},
+			},
+		}, decode[gen.CountOutboxMessagesResponse](t, res))
+	})
+
+	t.Run("Should requeue and replay messages", func(t *testing.T) {
+		repo := fake.NewOutboxMsgRepository()
+		msg, err := repo.Add(fake.OutboxMsg{
+			Topic:       "product.created",
+			Payload:     json.RawMessage(`{"id":1}`),
+			ProcessedAt: ptr.New(base),
+			Error:       ptr.New("broker unavailable"),
+		})
+		require.NoError(t, err)
+		srv := newTestServer(t, config.HTTP{AdminToken: adminToken}, repo)
+
+		res := post(t, srv.URL+"/api/v1/admin/outbox/messages/requeue", "", `{}`)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		res = post(t, srv.URL+"/api/v1/admin/outbox/messages/requeue", adminToken, `{"error":"unavailable","dryRun":true}`)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, gen.OutboxMessagesAffectedResponse{Affected: 1, DryRun: true}, decode[gen.OutboxMessagesAffectedResponse](t, res))
+		assert.NotNil(t, repo.Messages()[0].ProcessedAt)
+
+		res = post(t, srv.URL+"/api/v1/admin/outbox/messages/requeue", adminToken, `{"ids":["`+msg.ID.String()+`"]}`)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, gen.OutboxMessagesAffectedResponse{Affected: 1}, decode[gen.OutboxMessagesAffectedResponse](t, res))
+		assert.Nil(t, repo.Messages()[0].ProcessedAt)
+
+		require.NoError(t, repo.BulkUpdateOutboxMsgs(t.Context(), repository.BulkUpdateOutboxMsgsParams{
+			Items: []repository.BulkUpdateOutboxMsgsItem{{ID: msg.ID}},
+		}))
+
+		res = post(t, srv.URL+"/api/v1/admin/outbox/messages/replay", adminToken, `{"mode":"copy"}`)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		res = post(t, srv.URL+"/api/v1/admin/outbox/messages/replay", adminToken, `{"mode":"copy","topic":"product.created"}`)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, gen.OutboxMessagesAffectedResponse{Affected: 1}, decode[gen.OutboxMessagesAffectedResponse](t, res))
+		require.Len(t, repo.Messages(), 2)
+		assert.Nil(t, repo.Messages()[1].ProcessedAt)
+	})
+}
@@ -29,6 +29,7 @@ type Service struct {
 	logger *slog.Logger
 
 	productSvc service.ProductService
+	outboxSvc  service.OutboxService
 }
 
 type CleanupFunc func(ctx context.Context) error
@@ -37,11 +38,13 @@ func New(
 	cfg config.HTTP,
 	log *slog.Logger,
 	productSvc service.ProductService,
+	outboxSvc service.OutboxService,
 ) *Service {
 	return &Service{
 		cfg:        cfg,
 		logger:     log.With(slog.String("service", "http")),
 		productSvc: productSvc,
+		outboxSvc:  outboxSvc,
 	}
 }
 
@@ -109,7 +112,21 @@ func (s *Service) RegisterHandlers(r chi.Router) {
 	gen.HandlerWithOptions(strictHandlers, gen.ChiServerOptions{
 		BaseRouter:       r,
 		ErrorHandlerFunc: s.handleResponseError,
-		Middlewares:      []gen.MiddlewareFunc{},
+		Middlewares:      []gen.MiddlewareFunc{s.adminAuth},
+	})
+}
+
+// adminAuth authenticates the requests to the operations secured with the adminBearerAuth scheme.
+func (s *Service) adminAuth(next http.Handler) http.Handler {
+	authenticated := middleware.BearerAuth(s.cfg.AdminToken)(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, secured := r.Context().Value(gen.AdminBearerAuthScopes).([]string); secured {
+			authenticated.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
 	})
 }
 
@@ -150,10 +167,12 @@ var _ gen.StrictServerInterface = (*handler)(nil)
 
 type handler struct {
 	*productHandler
+	*outboxHandler
 }
 
 func (s *Service) newHandler() *handler {
 	return &handler{
 		productHandler: newProductHandler(s.productSvc),
+		outboxHandler:  newOutboxHandler(s.outboxSvc),
 	}
 }
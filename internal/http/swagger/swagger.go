@@ -15,9 +15,15 @@ const (
 
 	// swaggerSpecURL is the URL path where the OpenAPI specification will be served
 	swaggerSpecURL = "/docs/openapi.yml"
+
+	// asyncAPIURL is the URL path where the AsyncAPI viewer will be served
+	asyncAPIURL = "/docs/asyncapi"
+
+	// asyncAPISpecURL is the URL path where the AsyncAPI specification of the events will be served
+	asyncAPISpecURL = "/docs/asyncapi.yml"
 )
 
-// Register registers the swagger handler on the given router
+// Register registers the swagger and AsyncAPI handlers on the given router
 func Register(r chi.Router) {
 	template := getTemplate(swaggerSpecURL)
 	templateBytes := []byte(template)
@@ -36,6 +42,22 @@ func Register(r chi.Router) {
 		//nolint:errcheck
 		w.Write(specBytes)
 	})
+
+	asyncAPITemplateBytes := []byte(getAsyncAPITemplate(asyncAPISpecURL))
+	r.Get(asyncAPIURL, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		//nolint:errcheck
+		w.Write(asyncAPITemplateBytes)
+	})
+
+	asyncAPISpecBytes := apicontract.GetAsyncAPISpecBytes()
+	r.Get(asyncAPISpecURL, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		w.WriteHeader(http.StatusOK)
+		//nolint:errcheck
+		w.Write(asyncAPISpecBytes)
+	})
 }
 
 // getTemplate returns the HTML template for Swagger UI
@@ -69,3 +91,34 @@ func getTemplate(specPath string) string {
 </html>
 `, specPath)
 }
+
+// getAsyncAPITemplate returns the HTML template for the AsyncAPI viewer
+func getAsyncAPITemplate(specPath string) string {
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <meta name="viewport" content="width=device-width, initial-scale=1" />
+  <meta name="description" content="AsyncAPI" />
+  <title>AsyncAPI</title>
+  <link rel="stylesheet" href="https://unpkg.com/@asyncapi/react-component@2.6.5/styles/default.min.css" />
+</head>
+<body>
+<div id="asyncapi"></div>
+<script src="https://unpkg.com/@asyncapi/react-component@2.6.5/browser/standalone/index.js" crossorigin></script>
+<script>
+  AsyncApiStandalone.render({
+    schema: {
+      url: '%s',
+      options: { method: 'GET', mode: 'cors' },
+    },
+    config: {
+      show: { sidebar: true },
+    },
+  }, document.getElementById('asyncapi'));
+</script>
+</body>
+</html>
+`, specPath)
+}
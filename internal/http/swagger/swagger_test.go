@@ -35,4 +35,26 @@ func TestSwaggerDocsRoute(t *testing.T) {
 		assert.Equal(t, http.StatusOK, resp.Code)
 		assert.Contains(t, resp.Header().Get("Content-Type"), "application/yaml")
 	})
+
+	t.Run("Should get asyncapi viewer successfully", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/docs/asyncapi", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Header().Get("Content-Type"), "text/html")
+		assert.Contains(t, resp.Body.String(), "/docs/asyncapi.yml")
+	})
+
+	t.Run("Should get asyncapi.yml successfully", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/docs/asyncapi.yml", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Header().Get("Content-Type"), "application/yaml")
+		assert.Contains(t, resp.Body.String(), "asyncapi: 3.0.0")
+	})
 }
@@ -0,0 +1,127 @@
+// Package inbox makes message handlers idempotent by recording the messages they handled.
+package inbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/repository"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/outbox"
+)
+
+var tracer = otel.Tracer("internal/inbox")
+
+// HandlerFunc handles a message. Its DB work must go through tx, the transaction in which
+// the message is recorded as handled.
+type HandlerFunc func(ctx context.Context, tx db.DB, msg mq.Message) error
+
+// TypedHandlerFunc handles a message decoded into a T, see HandlerFunc.
+type TypedHandlerFunc[T any] func(ctx context.Context, tx db.DB, v T, md mq.Metadata) error
+
+// Inbox records handled messages in the inbox_messages table.
+type Inbox struct {
+	db           db.DB
+	inboxMsgRepo repository.InboxMsgRepository
+	logger       *slog.Logger
+}
+
+// New creates a new inbox.
+func New(db db.DB, inboxMsgRepo repository.InboxMsgRepository, logger *slog.Logger) *Inbox {
+	return &Inbox{
+		db:           db,
+		inboxMsgRepo: inboxMsgRepo,
+		logger:       logger,
+	}
+}
+
+// Handler wraps fn into an mq.HandlerFunc handling each message at most once for the consumer,
+// the name the handled messages are recorded under.
+//
+// The message is recorded in the same transaction as the DB work of fn, so a failing fn leaves it
+// unrecorded and it is handled again when redelivered. Messages already recorded are skipped.
+// The outbox message id is read from the outbox.MessageIDHeader header. Messages without one cannot
+// be recognized and are always handled.
+func (i *Inbox) Handler(consumer string, fn HandlerFunc) mq.HandlerFunc {
+	return func(ctx context.Context, msg mq.Message) error {
+		ctx, span := tracer.Start(ctx, "Inbox.Handle",
+			trace.WithAttributes(
+				attribute.String("consumer", consumer),
+				attribute.String("topic", msg.Topic),
+			),
+		)
+		defer span.End()
+
+		messageID, ok := msg.Headers[outbox.MessageIDHeader]
+		if !ok {
+			i.logger.WarnContext(ctx, "message without outbox message id, handling without deduplication",
+				slog.String("consumer", consumer),
+				slog.String("topic", msg.Topic),
+			)
+		}
+
+		return i.db.WithTx(ctx, func(tx db.DB) error {
+			if ok {
+				created, err := i.inboxMsgRepo.WithDB(tx).CreateInboxMsg(ctx, repository.CreateInboxMsgParams{
+					Consumer:  consumer,
+					MessageID: messageID,
+					Topic:     msg.Topic,
+				})
+				if err != nil {
+					return fmt.Errorf("create inbox msg: %w", err)
+				}
+
+				if !created {
+					span.SetAttributes(attribute.Bool("duplicate", true))
+					i.logger.InfoContext(ctx, "skipping already handled message",
+						slog.String("consumer", consumer),
+						slog.String("outbox_msg_id", messageID),
+					)
+					return nil
+				}
+			}
+
+			return fn(newTxContext(ctx, tx), tx, msg)
+		})
+	}
+}
+
+// Middleware is the mq.Middleware form of Handler, for handlers written as mq.HandlerFunc.
+// They get the transaction through the context, see TxFromContext.
+func (i *Inbox) Middleware(consumer string) mq.Middleware {
+	return func(next mq.HandlerFunc) mq.HandlerFunc {
+		return i.Handler(consumer, func(ctx context.Context, _ db.DB, msg mq.Message) error {
+			return next(ctx, msg)
+		})
+	}
+}
+
+type txCtxKey struct{}
+
+// TxFromContext retrieves the transaction the handled message is recorded in.
+func TxFromContext(ctx context.Context) (db.DB, bool) {
+	tx, ok := ctx.Value(txCtxKey{}).(db.DB)
+	return tx, ok
+}
+
+func newTxContext(ctx context.Context, tx db.DB) context.Context {
+	return context.WithValue(ctx, txCtxKey{}, tx)
+}
+
+// Typed adapts fn into a HandlerFunc decoding each message into a T before calling it, see mq.Typed.
+func Typed[T any](fn TypedHandlerFunc[T], opts ...mq.DecodeOption) HandlerFunc {
+	typed := mq.Typed(func(ctx context.Context, v T, md mq.Metadata) error {
+		tx, _ := TxFromContext(ctx)
+		return fn(ctx, tx, v, md)
+	}, opts...)
+
+	return func(ctx context.Context, tx db.DB, msg mq.Message) error {
+		return typed(newTxContext(ctx, tx), msg)
+	}
+}
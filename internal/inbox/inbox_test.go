@@ -0,0 +1,123 @@
+package inbox_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/inbox"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/outbox"
+	"github.com/tuanvumaihuynh/outbox-pattern/test/fake"
+)
+
+func newInbox() (*inbox.Inbox, *fake.InboxMsgRepository) {
+	repo := fake.NewInboxMsgRepository()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return inbox.New(fake.NewDB(), repo, logger), repo
+}
+
+var msg = mq.Message{
+	Metadata: mq.Metadata{
+		Topic:   "product.created",
+		Headers: map[string]string{outbox.MessageIDHeader: "msg-1"},
+	},
+	Payload: []byte(`{}`),
+}
+
+func TestInbox(t *testing.T) {
+	t.Run("Should handle a redelivered message once", func(t *testing.T) {
+		ib, repo := newInbox()
+
+		calls := 0
+		handler := ib.Handler("consumer", func(_ context.Context, tx db.DB, _ mq.Message) error {
+			assert.NotNil(t, tx)
+			calls++
+			return nil
+		})
+
+		require.NoError(t, handler(context.Background(), msg))
+		require.NoError(t, handler(context.Background(), msg))
+
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, 1, repo.Count("consumer"))
+	})
+
+	t.Run("Should handle a message again after the handler failed", func(t *testing.T) {
+		ib, repo := newInbox()
+
+		calls := 0
+		handler := ib.Handler("consumer", func(context.Context, db.DB, mq.Message) error {
+			calls++
+			if calls == 1 {
+				return errors.New("handler failed")
+			}
+			return nil
+		})
+
+		require.Error(t, handler(context.Background(), msg))
+		assert.Equal(t, 0, repo.Count("consumer"))
+
+		require.NoError(t, handler(context.Background(), msg))
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, 1, repo.Count("consumer"))
+	})
+
+	t.Run("Should handle a message once per consumer", func(t *testing.T) {
+		ib, _ := newInbox()
+
+		calls := map[string]int{}
+		for _, consumer := range []string{"a", "b"} {
+			handler := ib.Handler(consumer, func(context.Context, db.DB, mq.Message) error {
+				calls[consumer]++
+				return nil
+			})
+
+			require.NoError(t, handler(context.Background(), msg))
+			require.NoError(t, handler(context.Background(), msg))
+		}
+
+		assert.Equal(t, map[string]int{"a": 1, "b": 1}, calls)
+	})
+
+	t.Run("Should always handle messages without an outbox message id", func(t *testing.T) {
+		ib, repo := newInbox()
+
+		calls := 0
+		handler := ib.Handler("consumer", func(context.Context, db.DB, mq.Message) error {
+			calls++
+			return nil
+		})
+
+		noID := mq.Message{Metadata: mq.Metadata{Topic: "product.created"}, Payload: msg.Payload}
+		require.NoError(t, handler(context.Background(), noID))
+		require.NoError(t, handler(context.Background(), noID))
+
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, 0, repo.Count("consumer"))
+	})
+
+	t.Run("Should dedupe as a middleware passing the transaction through the context", func(t *testing.T) {
+		ib, repo := newInbox()
+
+		calls := 0
+		handler := mq.Chain(func(ctx context.Context, _ mq.Message) error {
+			_, ok := inbox.TxFromContext(ctx)
+			assert.True(t, ok)
+			calls++
+			return nil
+		}, ib.Middleware("consumer"))
+
+		require.NoError(t, handler(context.Background(), msg))
+		require.NoError(t, handler(context.Background(), msg))
+
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, 1, repo.Count("consumer"))
+	})
+}
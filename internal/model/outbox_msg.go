@@ -0,0 +1,77 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxMsgStatus is the relay status of an outbox message.
+type OutboxMsgStatus string
+
+const (
+	// OutboxMsgStatusPending is the status of a message waiting to be relayed, or to be retried after
+	// a failed attempt, see OutboxMsg.NextAttemptAt.
+	OutboxMsgStatusPending OutboxMsgStatus = "pending"
+	// OutboxMsgStatusProcessed is the status of a message relayed successfully.
+	OutboxMsgStatusProcessed OutboxMsgStatus = "processed"
+	// OutboxMsgStatusFailed is the status of a message the relay gave up on, see OutboxMsg.Error.
+	OutboxMsgStatusFailed OutboxMsgStatus = "failed"
+)
+
+// Valid returns whether the status is one of the defined statuses.
+func (s OutboxMsgStatus) Valid() bool {
+	switch s {
+	case OutboxMsgStatusPending, OutboxMsgStatusProcessed, OutboxMsgStatusFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+// OutboxMsg is a message of the outbox. Error is why the last attempt to relay it failed, a pending
+// message with an error is retried at NextAttemptAt.
+type OutboxMsg struct {
+	ID            uuid.UUID         `json:"id"`
+	Topic         string            `json:"topic"`
+	Headers       map[string]string `json:"headers"`
+	Payload       json.RawMessage   `json:"payload"`
+	PartitionKey  *string           `json:"partition_key"`
+	CreatedAt     time.Time         `json:"created_at"`
+	ProcessedAt   *time.Time        `json:"processed_at"`
+	Error         *string           `json:"error"`
+	Attempts      int32             `json:"attempts"`
+	NextAttemptAt *time.Time        `json:"next_attempt_at"`
+}
+
+// Status returns the relay status of the message.
+func (m OutboxMsg) Status() OutboxMsgStatus {
+	switch {
+	case m.ProcessedAt == nil:
+		return OutboxMsgStatusPending
+	case m.Error == nil:
+		return OutboxMsgStatusProcessed
+	default:
+		return OutboxMsgStatusFailed
+	}
+}
+
+// OutboxMsgCounts is the number of outbox messages by status.
+type OutboxMsgCounts struct {
+	Pending   int64 `json:"pending"`
+	Processed int64 `json:"processed"`
+	Failed    int64 `json:"failed"`
+}
+
+// Add adds n messages of the status to the counts.
+func (c *OutboxMsgCounts) Add(status OutboxMsgStatus, n int64) {
+	switch status {
+	case OutboxMsgStatusPending:
+		c.Pending += n
+	case OutboxMsgStatusProcessed:
+		c.Processed += n
+	case OutboxMsgStatusFailed:
+		c.Failed += n
+	}
+}
@@ -0,0 +1,170 @@
+package relay
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/repository"
+)
+
+var meter = otel.Meter("internal/relay")
+
+// latencyBuckets are the relay latency histogram boundaries in seconds, from a relay interval to
+// an outage of a few minutes.
+var latencyBuckets = []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300}
+
+type metrics struct {
+	produced      metric.Int64Counter
+	latency       metric.Float64Histogram
+	batchSize     metric.Int64Histogram
+	cycleDuration metric.Float64Histogram
+}
+
+func newMetrics() (*metrics, error) {
+	produced, err := meter.Int64Counter("outbox.relay.messages",
+		metric.WithDescription("Number of messages produced by the relay."),
+		metric.WithUnit("{message}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create messages counter: %w", err)
+	}
+
+	latency, err := meter.Float64Histogram("outbox.relay.latency",
+		metric.WithDescription("Duration from the creation of a message to its produce acknowledgement."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(latencyBuckets...),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create latency histogram: %w", err)
+	}
+
+	batchSize, err := meter.Int64Histogram("outbox.relay.batch.size",
+		metric.WithDescription("Number of messages relayed by a relay cycle."),
+		metric.WithUnit("{message}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create batch size histogram: %w", err)
+	}
+
+	cycleDuration, err := meter.Float64Histogram("outbox.relay.cycle.duration",
+		metric.WithDescription("Duration of a relay cycle."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create cycle duration histogram: %w", err)
+	}
+
+	return &metrics{
+		produced:      produced,
+		latency:       latency,
+		batchSize:     batchSize,
+		cycleDuration: cycleDuration,
+	}, nil
+}
+
+// recordProduced records the outcome of producing a message, and its latency when it was acknowledged.
+func (m *metrics) recordProduced(ctx context.Context, msg repository.ListUnprocessedOutboxMsgsResult, err error) {
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+
+	m.produced.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("topic", msg.Topic),
+		attribute.String("status", status),
+	))
+	if err == nil {
+		m.latency.Record(ctx, time.Since(msg.CreatedAt).Seconds(), metric.WithAttributes(
+			attribute.String("topic", msg.Topic),
+		))
+	}
+}
+
+// recordCycle records the number of messages relayed by a cycle and its duration.
+// Idle cycles are skipped, so that polling an empty outbox does not skew the histograms.
+func (m *metrics) recordCycle(ctx context.Context, batchSize int, start time.Time, err error) {
+	if batchSize == 0 && err == nil {
+		return
+	}
+
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+
+	if batchSize > 0 {
+		m.batchSize.Record(ctx, int64(batchSize))
+	}
+	m.cycleDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(
+		attribute.String("status", status),
+	))
+}
+
+// observeBacklog reports the pending messages and the age of the oldest one per topic whenever the
+// metrics are collected. Topics keep being reported once drained, with zero, so that alerts resolve.
+//
+// The backlog is the same for every replica, so the gauges must not be summed across them.
+// The returned registration stops the observations once unregistered.
+func observeBacklog(outboxMsgRepo repository.OutboxMsgRepository) (metric.Registration, error) {
+	pending, err := meter.Int64ObservableGauge("outbox.messages.pending",
+		metric.WithDescription("Number of messages waiting to be relayed."),
+		metric.WithUnit("{message}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create pending gauge: %w", err)
+	}
+
+	oldestAge, err := meter.Float64ObservableGauge("outbox.messages.oldest_pending.age",
+		metric.WithDescription("Age of the oldest message waiting to be relayed."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create oldest pending age gauge: %w", err)
+	}
+
+	var (
+		mu     sync.Mutex
+		topics = map[string]struct{}{}
+	)
+	registration, err := meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		backlog, err := outboxMsgRepo.ListOutboxBacklog(ctx)
+		if err != nil {
+			return fmt.Errorf("list outbox backlog: %w", err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		now := time.Now()
+		drained := make(map[string]struct{}, len(topics))
+		for topic := range topics {
+			drained[topic] = struct{}{}
+		}
+		for _, topic := range backlog {
+			topics[topic.Topic] = struct{}{}
+			delete(drained, topic.Topic)
+
+			attrs := metric.WithAttributes(attribute.String("topic", topic.Topic))
+			o.ObserveInt64(pending, topic.Count, attrs)
+			o.ObserveFloat64(oldestAge, now.Sub(topic.OldestCreatedAt).Seconds(), attrs)
+		}
+		for topic := range drained {
+			attrs := metric.WithAttributes(attribute.String("topic", topic))
+			o.ObserveInt64(pending, 0, attrs)
+			o.ObserveFloat64(oldestAge, 0, attrs)
+		}
+
+		return nil
+	}, pending, oldestAge)
+	if err != nil {
+		return nil, fmt.Errorf("register backlog callback: %w", err)
+	}
+
+	return registration, nil
+}
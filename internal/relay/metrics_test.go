@@ -0,0 +1,154 @@
+package relay_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// metricReader reads the relay metrics. The relay meter is global and only delegates to the first
+// provider set, so the provider is set once.
+var metricReader = sync.OnceValue(func() sdkmetric.Reader {
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	return reader
+})
+
+func collect(t *testing.T, reader sdkmetric.Reader) map[string]metricdata.Metrics {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	metrics := map[string]metricdata.Metrics{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m
+		}
+	}
+	return metrics
+}
+
+// gauge returns the value of the gauge data point with the attributes.
+func gauge[N int64 | float64](t *testing.T, m metricdata.Metrics, attrs ...attribute.KeyValue) N {
+	t.Helper()
+
+	data, ok := m.Data.(metricdata.Gauge[N])
+	require.Truef(t, ok, "%s is a %T", m.Name, m.Data)
+
+	set := attribute.NewSet(attrs...)
+	for _, point := range data.DataPoints {
+		if point.Attributes.Equals(&set) {
+			return point.Value
+		}
+	}
+	require.Failf(t, "missing data point", "%s has no data point with %v", m.Name, attrs)
+	return 0
+}
+
+// count returns the value of the counter, or the count of the histogram, with the attributes,
+// zero before anything was recorded.
+func count(m metricdata.Metrics, attrs ...attribute.KeyValue) int64 {
+	set := attribute.NewSet(attrs...)
+	switch data := m.Data.(type) {
+	case metricdata.Sum[int64]:
+		for _, point := range data.DataPoints {
+			if point.Attributes.Equals(&set) {
+				return point.Value
+			}
+		}
+	case metricdata.Histogram[float64]:
+		for _, point := range data.DataPoints {
+			if point.Attributes.Equals(&set) {
+				//nolint:gosec
+				return int64(point.Count)
+			}
+		}
+	case metricdata.Histogram[int64]:
+		for _, point := range data.DataPoints {
+			if point.Attributes.Equals(&set) {
+				//nolint:gosec
+				return int64(point.Count)
+			}
+		}
+	}
+	return 0
+}
+
+func TestRelayMetrics(t *testing.T) {
+	// the other relays record metrics too, so the topic is unique to this test
+	reader := metricReader()
+	topic := attribute.String("topic", "metrics.created")
+	ok := attribute.String("status", "ok")
+	failed := attribute.String("status", "error")
+
+	cfg := relayCfg
+	cfg.ObserveBacklog = true
+
+	t.Run("Should export the backlog, produce outcomes and latency", func(t *testing.T) {
+		f := newRelayFixture(t, cfg)
+		f.broker.FailNthProduce(2, errors.New("broker unavailable"))
+		f.createMsg(t, "metrics.created", nil, `{"id":1}`)
+		f.createMsg(t, "metrics.created", nil, `{"id":2}`)
+		f.createMsg(t, "metrics.created", nil, `{"id":3}`)
+
+		before := collect(t, reader)
+		assert.Equal(t, int64(3), gauge[int64](t, before["outbox.messages.pending"], topic))
+		assert.Greater(t, gauge[float64](t, before["outbox.messages.oldest_pending.age"], topic), 0.0)
+
+		cleanup := f.svc.Run(context.Background())
+		defer cleanup()
+
+		require.Eventually(t, f.allProcessed, time.Second, 5*time.Millisecond)
+
+		after := collect(t, reader)
+		assert.Equal(t, int64(0), gauge[int64](t, after["outbox.messages.pending"], topic))
+		assert.Equal(t, 0.0, gauge[float64](t, after["outbox.messages.oldest_pending.age"], topic))
+
+		delta := func(name string, attrs ...attribute.KeyValue) int64 {
+			return count(after[name], attrs...) - count(before[name], attrs...)
+		}
+		assert.Equal(t, int64(2), delta("outbox.relay.messages", topic, ok))
+		assert.Equal(t, int64(1), delta("outbox.relay.messages", topic, failed))
+		assert.Equal(t, int64(2), delta("outbox.relay.latency", topic))
+		assert.Positive(t, delta("outbox.relay.batch.size"))
+		assert.Positive(t, delta("outbox.relay.cycle.duration", ok))
+	})
+
+	t.Run("Should skip idle cycles", func(t *testing.T) {
+		f := newRelayFixture(t, relayCfg)
+
+		before := collect(t, reader)
+		cleanup := f.svc.Run(context.Background())
+		time.Sleep(10 * relayCfg.Interval)
+		cleanup()
+		after := collect(t, reader)
+
+		assert.Equal(t, count(before["outbox.relay.batch.size"]), count(after["outbox.relay.batch.size"]))
+		assert.Equal(t, count(before["outbox.relay.cycle.duration"], ok), count(after["outbox.relay.cycle.duration"], ok))
+	})
+
+	t.Run("Should stop observing the backlog after cleanup", func(t *testing.T) {
+		stopped := attribute.String("topic", "metrics.stopped")
+
+		f := newRelayFixture(t, cfg)
+		f.svc.Run(context.Background())()
+		f.createMsg(t, "metrics.stopped", nil, `{"id":1}`)
+
+		metrics := collect(t, reader)
+		data, _ := metrics["outbox.messages.pending"].Data.(metricdata.Gauge[int64])
+		set := attribute.NewSet(stopped)
+		for _, point := range data.DataPoints {
+			assert.False(t, point.Attributes.Equals(&set), "backlog still observed after cleanup")
+		}
+	})
+}
@@ -2,11 +2,15 @@ package relay
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/metric"
+
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/repository"
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db"
@@ -15,12 +19,20 @@ import (
 	"github.com/tuanvumaihuynh/outbox-pattern/pkg/ptr"
 )
 
+// Service relays the pending outbox messages to the producer.
+//
+// A message failing to be produced stays pending and is retried after an exponential backoff, until
+// its attempts are exhausted and it is marked failed with the error of its last attempt. The later
+// messages of its partition key wait for it meanwhile, so that they are not relayed out of order.
 type Service struct {
 	cfg           config.Relay
 	logger        *slog.Logger
 	db            db.DB
 	outboxMsgRepo repository.OutboxMsgRepository
 	mqProducer    mq.Producer
+	metrics       *metrics
+	// backlog is the registration of the backlog gauges, nil unless they are observed.
+	backlog metric.Registration
 
 	stopChan chan struct{}
 }
@@ -31,15 +43,30 @@ func NewService(
 	db db.DB,
 	outboxMsgRepo repository.OutboxMsgRepository,
 	mqProducer mq.Producer,
-) *Service {
+) (*Service, error) {
+	metrics, err := newMetrics()
+	if err != nil {
+		return nil, fmt.Errorf("create metrics: %w", err)
+	}
+
+	var backlog metric.Registration
+	if cfg.ObserveBacklog {
+		backlog, err = observeBacklog(outboxMsgRepo)
+		if err != nil {
+			return nil, fmt.Errorf("observe backlog: %w", err)
+		}
+	}
+
 	return &Service{
 		cfg:           cfg,
 		logger:        logger.With(slog.String("service", "relay")),
 		db:            db,
 		outboxMsgRepo: outboxMsgRepo,
 		mqProducer:    mqProducer,
+		metrics:       metrics,
+		backlog:       backlog,
 		stopChan:      make(chan struct{}),
-	}
+	}, nil
 }
 
 type CleanupFunc func()
@@ -57,8 +84,16 @@ func (s *Service) Run(ctx context.Context) CleanupFunc {
 		close(s.stopChan)
 		select {
 		case <-stoppedChan:
-		case <-time.After(5 * time.Second):
+		case <-time.After(s.cfg.ShutdownTimeout):
 			cancel()
+			<-stoppedChan
+		}
+		cancel()
+
+		if s.backlog != nil {
+			if err := s.backlog.Unregister(); err != nil {
+				s.logger.ErrorContext(ctx, "error unregistering backlog metrics", slog.Any("error", err))
+			}
 		}
 	}
 }
@@ -71,7 +106,9 @@ func (s *Service) run(ctx context.Context) {
 		case <-s.stopChan:
 			return
 		case <-time.After(s.cfg.Interval):
-			if err := s.db.WithTx(ctx, func(db db.DB) error {
+			start := time.Now()
+			batchSize := 0
+			err := s.db.WithTx(ctx, func(db db.DB) error {
 				outboxMsgs, err := s.outboxMsgRepo.
 					WithDB(db).
 					ListUnprocessedOutboxMsgs(ctx, repository.ListUnprocessedOutboxMsgsParams{
@@ -82,6 +119,7 @@ func (s *Service) run(ctx context.Context) {
 					return fmt.Errorf("list unprocessed outbox msgs: %w", err)
 				}
 
+				batchSize = len(outboxMsgs)
 				if len(outboxMsgs) == 0 {
 					return nil
 				}
@@ -93,25 +131,33 @@ func (s *Service) run(ctx context.Context) {
 
 				for _, outboxMsg := range outboxMsgs {
 					msg := outboxMsg
+					headers := make(map[string]string, len(msg.Headers)+1)
+					maps.Copy(headers, msg.Headers)
+					headers[outbox.MessageIDHeader] = msg.ID.String()
+
 					produceCtx := outbox.ExtractContextFromHeaders(ctx, msg.Headers)
 					wg.Go(func() {
 						produceMsg := mq.ProduceMsg{
 							Topic:        msg.Topic,
-							Headers:      msg.Headers,
+							Headers:      headers,
 							Payload:      msg.Payload,
 							PartitionKey: msg.PartitionKey,
 						}
 
-						if err := s.mqProducer.Produce(produceCtx, produceMsg); err != nil {
+						err := s.mqProducer.Produce(produceCtx, produceMsg)
+						s.metrics.recordProduced(produceCtx, msg, err)
+						if err != nil {
 							s.logger.ErrorContext(produceCtx,
 								"error producing message",
 								slog.String("outbox_msg_id", msg.ID.String()),
 								slog.String("topic", msg.Topic),
+								slog.Int("attempt", int(msg.Attempts)+1),
 								slog.Any("error", err),
 							)
 							resultChan <- repository.BulkUpdateOutboxMsgsItem{
-								ID:    msg.ID,
-								Error: ptr.New(err.Error()),
+								ID:      msg.ID,
+								Error:   ptr.New(err.Error()),
+								RetryAt: s.retryAt(msg.Attempts+1, err),
 							}
 							return
 						}
@@ -144,10 +190,37 @@ func (s *Service) run(ctx context.Context) {
 				}
 
 				return nil
-			}); err != nil {
+			})
+			s.metrics.recordCycle(ctx, batchSize, start, err)
+			if err != nil {
 				s.logger.ErrorContext(ctx, "error relaying outbox msgs", slog.Any("error", err))
 				continue
 			}
 		}
 	}
 }
+
+// retryAt returns when a message failing its attempt with err is retried, nil once its attempts are
+// exhausted and it is marked failed. A delay requested by a webhook subscriber through Retry-After
+// replaces the backoff, both are capped at MaxRetryBackoff.
+func (s *Service) retryAt(attempt int32, err error) *time.Time {
+	//nolint:gosec
+	if uint32(attempt) >= max(s.cfg.MaxAttempts, 1) {
+		return nil
+	}
+
+	var statusErr *mq.WebhookStatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		return ptr.New(time.Now().Add(min(statusErr.RetryAfter, s.cfg.MaxRetryBackoff)))
+	}
+
+	backoff := s.cfg.RetryBackoff
+	for range attempt - 1 {
+		if backoff >= s.cfg.MaxRetryBackoff {
+			break
+		}
+		backoff *= 2
+	}
+
+	return ptr.New(time.Now().Add(min(backoff, s.cfg.MaxRetryBackoff)))
+}
@@ -0,0 +1,327 @@
+package relay_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/relay"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/repository"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/outbox"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/ptr"
+	"github.com/tuanvumaihuynh/outbox-pattern/test/fake"
+)
+
+var relayCfg = config.Relay{
+	BatchSize:       10,
+	Interval:        10 * time.Millisecond,
+	ShutdownTimeout: 200 * time.Millisecond,
+}
+
+type relayFixture struct {
+	repo   *fake.OutboxMsgRepository
+	broker *fake.Broker
+	svc    *relay.Service
+}
+
+func newRelayFixture(t *testing.T, cfg config.Relay) relayFixture {
+	t.Helper()
+
+	repo := fake.NewOutboxMsgRepository()
+	broker := fake.NewBroker()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	svc, err := relay.NewService(cfg, logger, fake.NewDB(), repo, broker)
+	require.NoError(t, err)
+
+	return relayFixture{
+		repo:   repo,
+		broker: broker,
+		svc:    svc,
+	}
+}
+
+func (f relayFixture) createMsg(t *testing.T, topic string, partitionKey *string, payload string) {
+	t.Helper()
+
+	err := f.repo.CreateOutboxMsg(context.Background(), repository.CreateOutboxMsgParams{
+		Topic:        topic,
+		Headers:      map[string]string{"X-Correlation-Id": payload},
+		Payload:      []byte(payload),
+		PartitionKey: partitionKey,
+	})
+	require.NoError(t, err)
+}
+
+func (f relayFixture) allProcessed() bool {
+	for _, msg := range f.repo.Messages() {
+		if msg.ProcessedAt == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRelayService(t *testing.T) {
+	t.Run("Should relay all messages and mark them processed", func(t *testing.T) {
+		f := newRelayFixture(t, relayCfg)
+		f.createMsg(t, "product.created", ptr.New("p1"), `{"id":1}`)
+		f.createMsg(t, "product.created", nil, `{"id":2}`)
+		f.createMsg(t, "product.updated", ptr.New("p1"), `{"id":3}`)
+
+		cleanup := f.svc.Run(context.Background())
+		defer cleanup()
+
+		require.Eventually(t, f.allProcessed, time.Second, 5*time.Millisecond)
+
+		for _, msg := range f.repo.Messages() {
+			assert.Nil(t, msg.Error)
+		}
+
+		ids := map[string]struct{}{}
+		for _, msg := range f.repo.Messages() {
+			ids[msg.ID.String()] = struct{}{}
+		}
+
+		produced := f.broker.Produced()
+		require.Len(t, produced, 3)
+		for _, msg := range produced {
+			assert.Equal(t, string(msg.Payload), msg.Headers["X-Correlation-Id"])
+			assert.Contains(t, ids, msg.Headers[outbox.MessageIDHeader])
+		}
+	})
+
+	t.Run("Should record the error of failed messages and relay the others", func(t *testing.T) {
+		f := newRelayFixture(t, relayCfg)
+		f.broker.FailNthProduce(2, errors.New("broker unavailable"))
+		f.createMsg(t, "product.created", ptr.New("p1"), `{"id":1}`)
+		f.createMsg(t, "product.created", ptr.New("p2"), `{"id":2}`)
+		f.createMsg(t, "product.created", ptr.New("p3"), `{"id":3}`)
+
+		cleanup := f.svc.Run(context.Background())
+		defer cleanup()
+
+		require.Eventually(t, f.allProcessed, time.Second, 5*time.Millisecond)
+
+		var failed []fake.OutboxMsg
+		for _, msg := range f.repo.Messages() {
+			if msg.Error != nil {
+				failed = append(failed, msg)
+			}
+		}
+		require.Len(t, failed, 1)
+		assert.Equal(t, "broker unavailable", *failed[0].Error)
+		assert.Len(t, f.broker.Produced(), 2)
+		assert.Equal(t, 3, f.broker.ProduceCalls())
+	})
+
+	t.Run("Should retry a failed message until it is relayed", func(t *testing.T) {
+		cfg := relayCfg
+		cfg.MaxAttempts = 3
+		cfg.RetryBackoff = time.Millisecond
+		cfg.MaxRetryBackoff = 10 * time.Millisecond
+
+		f := newRelayFixture(t, cfg)
+		f.broker.FailNthProduce(1, errors.New("broker unavailable"))
+		f.broker.FailNthProduce(2, errors.New("broker unavailable"))
+		f.createMsg(t, "product.created", nil, `{"id":1}`)
+
+		cleanup := f.svc.Run(context.Background())
+		defer cleanup()
+
+		require.Eventually(t, f.allProcessed, time.Second, 5*time.Millisecond)
+
+		msg := f.repo.Messages()[0]
+		assert.Nil(t, msg.Error)
+		assert.Equal(t, int32(3), msg.Attempts)
+		assert.Len(t, f.broker.Produced(), 1)
+	})
+
+	t.Run("Should keep a failed message pending until its retry is due", func(t *testing.T) {
+		cfg := relayCfg
+		cfg.MaxAttempts = 3
+		cfg.RetryBackoff = time.Minute
+		cfg.MaxRetryBackoff = time.Minute
+
+		f := newRelayFixture(t, cfg)
+		f.broker.FailNthProduce(1, errors.New("broker unavailable"))
+		f.createMsg(t, "product.created", nil, `{"id":1}`)
+
+		cleanup := f.svc.Run(context.Background())
+		require.Eventually(t, func() bool {
+			return f.repo.Messages()[0].Attempts == 1
+		}, time.Second, 5*time.Millisecond)
+		// no other attempt is made once the relay has stopped
+		cleanup()
+
+		msg := f.repo.Messages()[0]
+		assert.Nil(t, msg.ProcessedAt)
+		assert.Equal(t, "broker unavailable", *msg.Error)
+		require.NotNil(t, msg.NextAttemptAt)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), *msg.NextAttemptAt, 5*time.Second)
+		assert.Equal(t, 1, f.broker.ProduceCalls())
+	})
+
+	t.Run("Should retry a message after the Retry-After of the webhook, capped at the max backoff", func(t *testing.T) {
+		cfg := relayCfg
+		cfg.MaxAttempts = 3
+		cfg.RetryBackoff = time.Millisecond
+		cfg.MaxRetryBackoff = time.Hour
+
+		f := newRelayFixture(t, cfg)
+		f.broker.FailNthProduce(1, &mq.WebhookStatusError{StatusCode: 429, RetryAfter: 10 * time.Minute})
+		f.broker.FailNthProduce(2, &mq.WebhookStatusError{StatusCode: 429, RetryAfter: 2 * time.Hour})
+		f.createMsg(t, "product.created", nil, `{"id":1}`)
+		f.createMsg(t, "product.created", nil, `{"id":2}`)
+
+		cleanup := f.svc.Run(context.Background())
+		require.Eventually(t, func() bool {
+			msgs := f.repo.Messages()
+			return msgs[0].Attempts == 1 && msgs[1].Attempts == 1
+		}, time.Second, 5*time.Millisecond)
+		cleanup()
+
+		var retries []time.Time
+		for _, msg := range f.repo.Messages() {
+			require.NotNil(t, msg.NextAttemptAt)
+			retries = append(retries, *msg.NextAttemptAt)
+		}
+		slices.SortFunc(retries, time.Time.Compare)
+		assert.WithinDuration(t, time.Now().Add(10*time.Minute), retries[0], 5*time.Second)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), retries[1], 5*time.Second)
+	})
+
+	t.Run("Should hold back the messages of a partition key while an earlier one waits for its retry", func(t *testing.T) {
+		cfg := relayCfg
+		cfg.MaxAttempts = 3
+		cfg.RetryBackoff = time.Minute
+		cfg.MaxRetryBackoff = time.Minute
+
+		f := newRelayFixture(t, cfg)
+		f.broker.FailNthProduce(1, errors.New("broker unavailable"))
+		f.createMsg(t, "product.created", ptr.New("a"), `{"id":1}`)
+
+		cleanup := f.svc.Run(context.Background())
+		defer cleanup()
+		require.Eventually(t, func() bool {
+			return f.repo.Messages()[0].Attempts == 1
+		}, time.Second, 5*time.Millisecond)
+
+		f.createMsg(t, "product.created", ptr.New("a"), `{"id":2}`)
+		f.createMsg(t, "product.created", ptr.New("b"), `{"id":3}`)
+
+		require.Eventually(t, func() bool {
+			return f.repo.Messages()[2].ProcessedAt != nil
+		}, time.Second, 5*time.Millisecond)
+		assert.Nil(t, f.repo.Messages()[1].ProcessedAt)
+		assert.Equal(t, 2, f.broker.ProduceCalls())
+	})
+
+	t.Run("Should mark a message failed once its attempts are exhausted", func(t *testing.T) {
+		cfg := relayCfg
+		cfg.MaxAttempts = 2
+		cfg.RetryBackoff = time.Millisecond
+
+		f := newRelayFixture(t, cfg)
+		f.broker.FailNthProduce(1, errors.New("broker unavailable"))
+		f.broker.FailNthProduce(2, errors.New("still unavailable"))
+		f.createMsg(t, "product.created", nil, `{"id":1}`)
+
+		cleanup := f.svc.Run(context.Background())
+		defer cleanup()
+
+		require.Eventually(t, f.allProcessed, time.Second, 5*time.Millisecond)
+
+		msg := f.repo.Messages()[0]
+		assert.Equal(t, "still unavailable", *msg.Error)
+		assert.Equal(t, int32(2), msg.Attempts)
+		assert.Nil(t, msg.NextAttemptAt)
+		assert.Equal(t, 2, f.broker.ProduceCalls())
+	})
+
+	t.Run("Should mark dropped messages processed", func(t *testing.T) {
+		f := newRelayFixture(t, relayCfg)
+		f.broker.DropNthProduce(1)
+		f.createMsg(t, "product.created", nil, `{"id":1}`)
+
+		cleanup := f.svc.Run(context.Background())
+		defer cleanup()
+
+		require.Eventually(t, f.allProcessed, time.Second, 5*time.Millisecond)
+		assert.Empty(t, f.broker.Produced())
+	})
+
+	t.Run("Should deliver relayed messages to consumers", func(t *testing.T) {
+		f := newRelayFixture(t, relayCfg)
+
+		var mu sync.Mutex
+		var received []string
+		err := f.broker.RegisterHandler("product.created", func(_ context.Context, msg mq.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			received = append(received, string(msg.Payload))
+			return nil
+		})
+		require.NoError(t, err)
+
+		consumerCleanup, err := f.broker.Run(context.Background())
+		require.NoError(t, err)
+		defer consumerCleanup()
+
+		f.createMsg(t, "product.created", ptr.New("p1"), `{"id":1}`)
+		f.createMsg(t, "product.created", ptr.New("p1"), `{"id":2}`)
+
+		cleanup := f.svc.Run(context.Background())
+		defer cleanup()
+
+		require.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(received) == 2
+		}, time.Second, 5*time.Millisecond)
+		assert.ElementsMatch(t, []string{`{"id":1}`, `{"id":2}`}, received)
+	})
+
+	t.Run("Should stop relaying after shutdown", func(t *testing.T) {
+		f := newRelayFixture(t, relayCfg)
+
+		f.createMsg(t, "product.created", nil, `{"id":1}`)
+
+		cleanup := f.svc.Run(context.Background())
+		require.Eventually(t, f.allProcessed, time.Second, 5*time.Millisecond)
+		// cleanup returns once the relay loop has stopped, nothing is relayed after it
+		cleanup()
+
+		f.createMsg(t, "product.created", nil, `{"id":2}`)
+
+		assert.Equal(t, 1, f.broker.ProduceCalls())
+		assert.False(t, f.allProcessed())
+	})
+
+	t.Run("Should cancel in-flight messages after the shutdown timeout", func(t *testing.T) {
+		f := newRelayFixture(t, relayCfg)
+		f.broker.SetProduceDelay(time.Minute)
+		f.createMsg(t, "product.created", nil, `{"id":1}`)
+
+		cleanup := f.svc.Run(context.Background())
+		require.Eventually(t, func() bool {
+			return f.broker.ProduceCalls() == 1
+		}, time.Second, 5*time.Millisecond)
+
+		start := time.Now()
+		cleanup()
+
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Empty(t, f.broker.Produced())
+	})
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db/sqlc"
+)
+
+type CreateInboxMsgParams struct {
+	Consumer  string
+	MessageID string
+	Topic     string
+}
+
+type InboxMsgRepository interface {
+	WithDB(db db.DB) InboxMsgRepository
+	// CreateInboxMsg records the message as handled by the consumer.
+	// It returns false if the message was already recorded.
+	CreateInboxMsg(ctx context.Context, params CreateInboxMsgParams) (bool, error)
+}
+
+type inboxMsgRepository struct {
+	db      db.DB
+	queries sqlc.Queries
+}
+
+func NewInboxMsgRepository(db db.DB, queries sqlc.Queries) InboxMsgRepository {
+	return &inboxMsgRepository{
+		db:      db,
+		queries: queries,
+	}
+}
+
+func (r inboxMsgRepository) WithDB(db db.DB) InboxMsgRepository {
+	return &inboxMsgRepository{
+		db:      db,
+		queries: r.queries,
+	}
+}
+
+func (r inboxMsgRepository) CreateInboxMsg(ctx context.Context, params CreateInboxMsgParams) (bool, error) {
+	rows, err := r.queries.InboxMsgCreate(ctx, r.db, sqlc.InboxMsgCreateParams{
+		Consumer:    params.Consumer,
+		MessageID:   params.MessageID,
+		Topic:       params.Topic,
+		ProcessedAt: time.Now(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("inbox msg create: %w", err)
+	}
+
+	return rows > 0, nil
+}
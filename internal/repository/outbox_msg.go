@@ -9,8 +9,12 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/apperr"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/eventschema"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/model"
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db"
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db/sqlc"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/ptr"
 )
 
 type CreateOutboxMsgParams struct {
@@ -30,44 +34,136 @@ type ListUnprocessedOutboxMsgsResult struct {
 	Headers      map[string]string
 	Payload      json.RawMessage
 	PartitionKey *string
+	CreatedAt    time.Time
+	// Attempts is how many times the message was relayed before, and failed.
+	Attempts int32
 }
 
 type BulkUpdateOutboxMsgsItem struct {
 	ID    uuid.UUID
 	Error *string
+	// RetryAt keeps a failed message pending until then instead of marking it processed.
+	RetryAt *time.Time
 }
 
 type BulkUpdateOutboxMsgsParams struct {
 	Items []BulkUpdateOutboxMsgsItem
 }
 
+// OutboxMsgCursor is the position of a message in the messages listed by ListOutboxMsgs.
+type OutboxMsgCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// ListOutboxMsgsParams filters the messages listed by ListOutboxMsgs, nil fields match every message.
+type ListOutboxMsgsParams struct {
+	Topic         *string
+	Status        *model.OutboxMsgStatus
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	ErrorContains *string
+	// After lists the messages following the cursor.
+	After *OutboxMsgCursor
+	Limit int32
+}
+
+type CountOutboxMsgsResult struct {
+	Topic  string
+	Status model.OutboxMsgStatus
+	Count  int64
+}
+
+// MatchOutboxMsgsParams selects the relayed messages to requeue or replay, nil fields match every message.
+type MatchOutboxMsgsParams struct {
+	// Failed selects the failed messages instead of the processed ones, pending messages are never selected.
+	Failed        bool
+	IDs           []uuid.UUID
+	Topic         *string
+	PartitionKey  *string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	ErrorContains *string
+}
+
+// OutboxBacklogResult is the backlog of pending messages of a topic.
+type OutboxBacklogResult struct {
+	Topic           string
+	Count           int64
+	OldestCreatedAt time.Time
+}
+
+// ListRelayedOutboxMsgsParams filters the messages listed by ListRelayedOutboxMsgs.
+type ListRelayedOutboxMsgsParams struct {
+	Topic *string
+	// ProcessedAfter and AfterID list the messages relayed after the message relayed at ProcessedAfter
+	// with the id AfterID, uuid.Nil lists every message relayed after ProcessedAfter.
+	ProcessedAfter time.Time
+	AfterID        uuid.UUID
+	Limit          int32
+}
+
 type OutboxMsgRepository interface {
 	WithDB(db db.DB) OutboxMsgRepository
 	CreateOutboxMsg(ctx context.Context, params CreateOutboxMsgParams) error
+	// ListUnprocessedOutboxMsgs locks the pending messages due for an attempt, oldest first. The messages
+	// of a partition key are held back while an earlier one of the key waits for its retry.
 	ListUnprocessedOutboxMsgs(ctx context.Context, params ListUnprocessedOutboxMsgsParams) ([]ListUnprocessedOutboxMsgsResult, error)
 	BulkUpdateOutboxMsgs(ctx context.Context, params BulkUpdateOutboxMsgsParams) error
+	// ListOutboxMsgs lists the messages matching the params, newest first.
+	ListOutboxMsgs(ctx context.Context, params ListOutboxMsgsParams) ([]model.OutboxMsg, error)
+	// GetOutboxMsg returns the message with the id, or apperr.OutboxMsgNotFoundErr.
+	GetOutboxMsg(ctx context.Context, id uuid.UUID) (model.OutboxMsg, error)
+	// CountOutboxMsgs counts the messages by topic and status, sorted by topic.
+	CountOutboxMsgs(ctx context.Context) ([]CountOutboxMsgsResult, error)
+	// CountMatchingOutboxMsgs counts the messages matching the params.
+	CountMatchingOutboxMsgs(ctx context.Context, params MatchOutboxMsgsParams) (int64, error)
+	// ResetOutboxMsgs makes the messages matching the params pending again, with their attempts reset,
+	// and returns how many were reset.
+	// They keep their id, so consumers deduplicating by message id skip them.
+	ResetOutboxMsgs(ctx context.Context, params MatchOutboxMsgsParams) (int64, error)
+	// CopyOutboxMsgs inserts a pending copy of each message matching the params, with a new id,
+	// and returns how many were copied.
+	CopyOutboxMsgs(ctx context.Context, params MatchOutboxMsgsParams) (int64, error)
+	// DeleteOutboxMsgs deletes the messages matching the params and returns how many were deleted.
+	DeleteOutboxMsgs(ctx context.Context, params MatchOutboxMsgsParams) (int64, error)
+	// ListOutboxBacklog returns the pending messages per topic, sorted by topic.
+	ListOutboxBacklog(ctx context.Context) ([]OutboxBacklogResult, error)
+	// ListRelayedOutboxMsgs lists the messages relayed after the params position, in relay order.
+	ListRelayedOutboxMsgs(ctx context.Context, params ListRelayedOutboxMsgsParams) ([]model.OutboxMsg, error)
 }
 
 type outboxMsgRepository struct {
-	db      db.DB
-	queries sqlc.Queries
+	db        db.DB
+	queries   sqlc.Queries
+	validator *eventschema.Validator
 }
 
-func NewOutboxMsgRepository(db db.DB, queries sqlc.Queries) OutboxMsgRepository {
+// NewOutboxMsgRepository creates a repository validating the payloads of the messages it creates
+// with the validator.
+func NewOutboxMsgRepository(db db.DB, queries sqlc.Queries, validator *eventschema.Validator) OutboxMsgRepository {
 	return &outboxMsgRepository{
-		db:      db,
-		queries: queries,
+		db:        db,
+		queries:   queries,
+		validator: validator,
 	}
 }
 
 func (r outboxMsgRepository) WithDB(db db.DB) OutboxMsgRepository {
 	return &outboxMsgRepository{
-		db:      db,
-		queries: r.queries,
+		db:        db,
+		queries:   r.queries,
+		validator: r.validator,
 	}
 }
 
+// CreateOutboxMsg inserts the message, rejecting payloads that do not validate against the schema
+// of their topic with apperr.EventPayloadInvalidErr so the business transaction rolls back.
 func (r outboxMsgRepository) CreateOutboxMsg(ctx context.Context, params CreateOutboxMsgParams) error {
+	if err := r.validator.Validate(params.Topic, params.Payload); err != nil {
+		return err
+	}
+
 	headersBytes, err := json.Marshal(params.Headers)
 	if err != nil {
 		return fmt.Errorf("marshal headers: %w", err)
@@ -110,38 +206,49 @@ func (r outboxMsgRepository) ListUnprocessedOutboxMsgs(ctx context.Context, para
 			Headers:      headers,
 			Payload:      msg.Payload,
 			PartitionKey: msg.PartitionKey,
+			CreatedAt:    msg.CreatedAt,
+			Attempts:     msg.Attempts,
 		})
 	}
 
 	return results, nil
 }
 
+// BulkUpdateOutboxMsgs records the outcome of relaying the messages, counting it as an attempt.
+// Messages with a RetryAt stay pending until then, the others are marked processed.
 func (r outboxMsgRepository) BulkUpdateOutboxMsgs(ctx context.Context, params BulkUpdateOutboxMsgsParams) error {
 	ids := make([]uuid.UUID, len(params.Items))
 	errs := make([]*string, len(params.Items))
+	retryAts := make([]*time.Time, len(params.Items))
 	for i, item := range params.Items {
 		ids[i] = item.ID
 		errs[i] = item.Error
+		retryAts[i] = item.RetryAt
 	}
 
 	_, err := r.db.Exec(ctx, `
 		UPDATE outbox_messages AS o
 		SET
-			processed_at = NOW(),
-			error        = e.error
+			processed_at    = CASE WHEN e.retry_at IS NULL THEN NOW() END,
+			error           = e.error,
+			attempts        = o.attempts + 1,
+			next_attempt_at = e.retry_at
 		FROM (
 			SELECT
 				id,
-				error
+				error,
+				retry_at
 			FROM (
 				SELECT UNNEST(@ids::uuid[])  AS id,
-					UNNEST(@errors::text[]) AS error
+					UNNEST(@errors::text[]) AS error,
+					UNNEST(@retry_ats::timestamptz[]) AS retry_at
 			) AS t
 		) AS e
 		WHERE o.id = e.id;
 	`, pgx.NamedArgs{
-		"ids":    ids,
-		"errors": errs,
+		"ids":       ids,
+		"errors":    errs,
+		"retry_ats": retryAts,
 	})
 	if err != nil {
 		return fmt.Errorf("outbox msg bulk update: %w", err)
@@ -149,3 +256,193 @@ func (r outboxMsgRepository) BulkUpdateOutboxMsgs(ctx context.Context, params Bu
 
 	return nil
 }
+
+func (r outboxMsgRepository) ListOutboxMsgs(ctx context.Context, params ListOutboxMsgsParams) ([]model.OutboxMsg, error) {
+	arg := sqlc.OutboxMsgListParams{
+		Topic:         params.Topic,
+		CreatedAfter:  params.CreatedAfter,
+		CreatedBefore: params.CreatedBefore,
+		ErrorContains: params.ErrorContains,
+		PageSize:      params.Limit,
+	}
+	if params.Status != nil {
+		processed, failed := statusFilter(*params.Status)
+		arg.Processed = &processed
+		arg.Failed = failed
+	}
+	if params.After != nil {
+		arg.CursorCreatedAt = &params.After.CreatedAt
+		arg.CursorID = &params.After.ID
+	}
+
+	msgs, err := r.queries.OutboxMsgList(ctx, r.db, arg)
+	if err != nil {
+		return nil, fmt.Errorf("outbox msg list: %w", err)
+	}
+
+	results := make([]model.OutboxMsg, 0, len(msgs))
+	for _, msg := range msgs {
+		result, err := toOutboxMsg(msg)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (r outboxMsgRepository) GetOutboxMsg(ctx context.Context, id uuid.UUID) (model.OutboxMsg, error) {
+	msg, err := r.queries.OutboxMsgGet(ctx, r.db, id)
+	if err != nil {
+		if db.IsNoRowsError(err) {
+			return model.OutboxMsg{}, apperr.OutboxMsgNotFoundErr
+		}
+		return model.OutboxMsg{}, fmt.Errorf("outbox msg get: %w", err)
+	}
+
+	return toOutboxMsg(msg)
+}
+
+func (r outboxMsgRepository) CountOutboxMsgs(ctx context.Context) ([]CountOutboxMsgsResult, error) {
+	rows, err := r.queries.OutboxMsgCountByStatus(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("outbox msg count by status: %w", err)
+	}
+
+	results := make([]CountOutboxMsgsResult, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, CountOutboxMsgsResult{
+			Topic:  row.Topic,
+			Status: model.OutboxMsgStatus(row.Status),
+			Count:  row.Count,
+		})
+	}
+
+	return results, nil
+}
+
+func (r outboxMsgRepository) CountMatchingOutboxMsgs(ctx context.Context, params MatchOutboxMsgsParams) (int64, error) {
+	count, err := r.queries.OutboxMsgCountMatching(ctx, r.db, sqlc.OutboxMsgCountMatchingParams(toOutboxMsgResetParams(params)))
+	if err != nil {
+		return 0, fmt.Errorf("outbox msg count matching: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r outboxMsgRepository) ResetOutboxMsgs(ctx context.Context, params MatchOutboxMsgsParams) (int64, error) {
+	count, err := r.queries.OutboxMsgReset(ctx, r.db, toOutboxMsgResetParams(params))
+	if err != nil {
+		return 0, fmt.Errorf("outbox msg reset: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r outboxMsgRepository) CopyOutboxMsgs(ctx context.Context, params MatchOutboxMsgsParams) (int64, error) {
+	count, err := r.queries.OutboxMsgCopy(ctx, r.db, sqlc.OutboxMsgCopyParams(toOutboxMsgResetParams(params)))
+	if err != nil {
+		return 0, fmt.Errorf("outbox msg copy: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r outboxMsgRepository) DeleteOutboxMsgs(ctx context.Context, params MatchOutboxMsgsParams) (int64, error) {
+	count, err := r.queries.OutboxMsgDelete(ctx, r.db, sqlc.OutboxMsgDeleteParams(toOutboxMsgResetParams(params)))
+	if err != nil {
+		return 0, fmt.Errorf("outbox msg delete: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r outboxMsgRepository) ListOutboxBacklog(ctx context.Context) ([]OutboxBacklogResult, error) {
+	rows, err := r.queries.OutboxMsgBacklog(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("outbox msg backlog: %w", err)
+	}
+
+	results := make([]OutboxBacklogResult, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, OutboxBacklogResult{
+			Topic:           row.Topic,
+			Count:           row.Count,
+			OldestCreatedAt: row.OldestCreatedAt,
+		})
+	}
+
+	return results, nil
+}
+
+func (r outboxMsgRepository) ListRelayedOutboxMsgs(ctx context.Context, params ListRelayedOutboxMsgsParams) ([]model.OutboxMsg, error) {
+	msgs, err := r.queries.OutboxMsgListRelayed(ctx, r.db, sqlc.OutboxMsgListRelayedParams{
+		ProcessedAfter: params.ProcessedAfter,
+		AfterID:        params.AfterID,
+		Topic:          params.Topic,
+		PageSize:       params.Limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("outbox msg list relayed: %w", err)
+	}
+
+	results := make([]model.OutboxMsg, 0, len(msgs))
+	for _, msg := range msgs {
+		result, err := toOutboxMsg(msg)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// toOutboxMsgResetParams converts the params to the arguments shared by the queries matching messages.
+func toOutboxMsgResetParams(params MatchOutboxMsgsParams) sqlc.OutboxMsgResetParams {
+	return sqlc.OutboxMsgResetParams{
+		Failed:        params.Failed,
+		Ids:           params.IDs,
+		Topic:         params.Topic,
+		PartitionKey:  params.PartitionKey,
+		CreatedAfter:  params.CreatedAfter,
+		CreatedBefore: params.CreatedBefore,
+		ErrorContains: params.ErrorContains,
+	}
+}
+
+// statusFilter returns whether the messages of the status are processed, and whether they failed,
+// nil if it does not matter.
+func statusFilter(status model.OutboxMsgStatus) (bool, *bool) {
+	switch status {
+	case model.OutboxMsgStatusProcessed:
+		return true, ptr.New(false)
+	case model.OutboxMsgStatusFailed:
+		return true, ptr.New(true)
+	default:
+		return false, nil
+	}
+}
+
+func toOutboxMsg(msg sqlc.OutboxMessage) (model.OutboxMsg, error) {
+	headers := map[string]string{}
+	if msg.Headers != nil {
+		if err := json.Unmarshal(*msg.Headers, &headers); err != nil {
+			return model.OutboxMsg{}, fmt.Errorf("unmarshal headers: %w", err)
+		}
+	}
+
+	return model.OutboxMsg{
+		ID:            msg.ID,
+		Topic:         msg.Topic,
+		Headers:       headers,
+		Payload:       msg.Payload,
+		PartitionKey:  msg.PartitionKey,
+		CreatedAt:     msg.CreatedAt,
+		ProcessedAt:   msg.ProcessedAt,
+		Error:         msg.Error,
+		Attempts:      msg.Attempts,
+		NextAttemptAt: msg.NextAttemptAt,
+	}, nil
+}
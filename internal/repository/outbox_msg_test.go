@@ -0,0 +1,52 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/eventschema"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/repository"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db/sqlc"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/ptr"
+	"github.com/tuanvumaihuynh/outbox-pattern/test/pgtest"
+)
+
+func TestOutboxMsgRepository(t *testing.T) {
+	t.Run("Should hold back the messages of a partition key while an earlier one waits for its retry", func(t *testing.T) {
+		ctx := context.Background()
+		validator, err := eventschema.NewValidator(nil)
+		require.NoError(t, err)
+		repo := repository.NewOutboxMsgRepository(db.NewClient(pgtest.NewPool(t)), *sqlc.New(), validator)
+
+		for _, key := range []*string{ptr.New("a"), ptr.New("a"), ptr.New("b"), nil} {
+			require.NoError(t, repo.CreateOutboxMsg(ctx, repository.CreateOutboxMsgParams{
+				Topic:        "product.created",
+				Payload:      []byte(`{}`),
+				PartitionKey: key,
+			}))
+		}
+
+		msgs, err := repo.ListUnprocessedOutboxMsgs(ctx, repository.ListUnprocessedOutboxMsgsParams{BatchSize: 10})
+		require.NoError(t, err)
+		require.Len(t, msgs, 4)
+
+		require.NoError(t, repo.BulkUpdateOutboxMsgs(ctx, repository.BulkUpdateOutboxMsgsParams{
+			Items: []repository.BulkUpdateOutboxMsgsItem{{
+				ID:      msgs[0].ID,
+				Error:   ptr.New("broker unavailable"),
+				RetryAt: ptr.New(time.Now().Add(time.Minute)),
+			}},
+		}))
+
+		pending, err := repo.ListUnprocessedOutboxMsgs(ctx, repository.ListUnprocessedOutboxMsgsParams{BatchSize: 10})
+		require.NoError(t, err)
+		require.Len(t, pending, 2)
+		assert.Equal(t, msgs[2].ID, pending[0].ID)
+		assert.Equal(t, msgs[3].ID, pending[1].ID)
+	})
+}
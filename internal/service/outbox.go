@@ -0,0 +1,439 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/apperr"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/model"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/repository"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/ptr"
+)
+
+const (
+	// DefaultOutboxMsgPageSize is the number of messages listed when no limit is given.
+	DefaultOutboxMsgPageSize = 20
+	// MaxOutboxMsgPageSize is the maximum number of messages listed at once.
+	MaxOutboxMsgPageSize = 100
+	// DefaultOutboxTailInterval is how often TailOutboxMsgs polls when no interval is given.
+	DefaultOutboxTailInterval = time.Second
+
+	outboxTailPageSize = 100
+)
+
+type ListOutboxMsgsParams struct {
+	Topic         *string
+	Status        *model.OutboxMsgStatus
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	ErrorContains *string
+	// Cursor is the NextCursor of the previous page, nil for the first page.
+	Cursor *string
+	// Limit is the page size, DefaultOutboxMsgPageSize if zero.
+	Limit int
+}
+
+type ListOutboxMsgsResult struct {
+	Msgs []model.OutboxMsg
+	// NextCursor lists the next page, nil on the last page.
+	NextCursor *string
+}
+
+type TopicOutboxMsgCounts struct {
+	Topic  string
+	Counts model.OutboxMsgCounts
+}
+
+type CountOutboxMsgsResult struct {
+	Total  model.OutboxMsgCounts
+	Topics []TopicOutboxMsgCounts
+}
+
+// OutboxReplayMode is how ReplayOutboxMsgs publishes processed messages again.
+type OutboxReplayMode string
+
+const (
+	// OutboxReplayModeReset makes the messages pending again. They keep their id, so consumers
+	// deduplicating by message id skip them.
+	OutboxReplayModeReset OutboxReplayMode = "reset"
+	// OutboxReplayModeCopy inserts pending copies of the messages, with new ids.
+	OutboxReplayModeCopy OutboxReplayMode = "copy"
+)
+
+// RequeueOutboxMsgsParams selects the failed messages to requeue, at least one filter is required.
+type RequeueOutboxMsgsParams struct {
+	IDs           []uuid.UUID
+	Topic         *string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	ErrorContains *string
+	// DryRun counts the messages that would be requeued without requeuing them.
+	DryRun bool
+}
+
+// ReplayOutboxMsgsParams selects the processed messages to replay, at least one filter is required.
+type ReplayOutboxMsgsParams struct {
+	Mode          OutboxReplayMode
+	IDs           []uuid.UUID
+	Topic         *string
+	PartitionKey  *string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// DryRun counts the messages that would be replayed without replaying them.
+	DryRun bool
+}
+
+// TopicOutboxBacklog is the backlog of pending messages of a topic.
+type TopicOutboxBacklog struct {
+	Topic           string
+	Pending         int64
+	OldestCreatedAt time.Time
+}
+
+// PurgeOutboxMsgsParams selects the relayed messages to delete.
+type PurgeOutboxMsgsParams struct {
+	// Failed purges the failed messages instead of the processed ones.
+	Failed bool
+	Topic  *string
+	// CreatedBefore is required, so that recent messages are kept for inspection and replay.
+	CreatedBefore time.Time
+	// DryRun counts the messages that would be purged without purging them.
+	DryRun bool
+}
+
+type TailOutboxMsgsParams struct {
+	Topic *string
+	// Since streams the messages relayed after it.
+	Since time.Time
+	// Interval is how often relayed messages are polled, DefaultOutboxTailInterval if zero.
+	Interval time.Duration
+}
+
+// OutboxService lets operators inspect the outbox and publish messages again.
+type OutboxService interface {
+	// ListOutboxMsgs lists a page of the messages matching the params, newest first.
+	ListOutboxMsgs(ctx context.Context, params ListOutboxMsgsParams) (ListOutboxMsgsResult, error)
+	GetOutboxMsg(ctx context.Context, id uuid.UUID) (model.OutboxMsg, error)
+	CountOutboxMsgs(ctx context.Context) (CountOutboxMsgsResult, error)
+	// RequeueOutboxMsgs makes the failed messages matching the params pending again, for the relay to retry
+	// them, and returns how many were requeued.
+	RequeueOutboxMsgs(ctx context.Context, params RequeueOutboxMsgsParams) (int64, error)
+	// ReplayOutboxMsgs publishes the processed messages matching the params again and returns how many were
+	// replayed.
+	ReplayOutboxMsgs(ctx context.Context, params ReplayOutboxMsgsParams) (int64, error)
+	// PurgeOutboxMsgs deletes the relayed messages matching the params and returns how many were purged.
+	PurgeOutboxMsgs(ctx context.Context, params PurgeOutboxMsgsParams) (int64, error)
+	// ListOutboxBacklog returns the pending messages per topic, sorted by topic.
+	ListOutboxBacklog(ctx context.Context) ([]TopicOutboxBacklog, error)
+	// TailOutboxMsgs calls fn with the messages relayed after params.Since, in relay order, until the
+	// context is done or fn fails. It is best effort: a message whose relay transaction commits after a
+	// message relayed later was streamed is skipped.
+	TailOutboxMsgs(ctx context.Context, params TailOutboxMsgsParams, fn func(model.OutboxMsg) error) error
+}
+
+type outboxService struct {
+	outboxMsgRepo repository.OutboxMsgRepository
+}
+
+func NewOutboxService(outboxMsgRepo repository.OutboxMsgRepository) OutboxService {
+	return &outboxService{
+		outboxMsgRepo: outboxMsgRepo,
+	}
+}
+
+func (s *outboxService) ListOutboxMsgs(ctx context.Context, params ListOutboxMsgsParams) (ListOutboxMsgsResult, error) {
+	ctx, span := tracer.Start(ctx, "outboxService.ListOutboxMsgs")
+	defer span.End()
+
+	limit := params.Limit
+	if limit == 0 {
+		limit = DefaultOutboxMsgPageSize
+	}
+	if limit < 1 || limit > MaxOutboxMsgPageSize {
+		return ListOutboxMsgsResult{}, apperr.ValidationErr.WrapParent(
+			fmt.Errorf("limit must be between 1 and %d, got %d", MaxOutboxMsgPageSize, limit))
+	}
+
+	if params.Status != nil && !params.Status.Valid() {
+		return ListOutboxMsgsResult{}, apperr.ValidationErr.WrapParent(
+			fmt.Errorf("unknown outbox message status %q", *params.Status))
+	}
+
+	var after *repository.OutboxMsgCursor
+	if params.Cursor != nil {
+		cursor, err := decodeOutboxMsgCursor(*params.Cursor)
+		if err != nil {
+			return ListOutboxMsgsResult{}, apperr.OutboxCursorInvalidErr.WrapParent(err)
+		}
+		after = &cursor
+	}
+
+	// one more message is listed to know whether there is a next page
+	msgs, err := s.outboxMsgRepo.ListOutboxMsgs(ctx, repository.ListOutboxMsgsParams{
+		Topic:         params.Topic,
+		Status:        params.Status,
+		CreatedAfter:  params.CreatedAfter,
+		CreatedBefore: params.CreatedBefore,
+		ErrorContains: params.ErrorContains,
+		After:         after,
+		//nolint:gosec
+		Limit: int32(limit + 1),
+	})
+	if err != nil {
+		return ListOutboxMsgsResult{}, fmt.Errorf("outbox msg repository list outbox msgs: %w", err)
+	}
+
+	result := ListOutboxMsgsResult{Msgs: msgs}
+	if len(msgs) > limit {
+		result.Msgs = msgs[:limit]
+		last := result.Msgs[limit-1]
+		result.NextCursor = ptr.New(encodeOutboxMsgCursor(repository.OutboxMsgCursor{
+			CreatedAt: last.CreatedAt,
+			ID:        last.ID,
+		}))
+	}
+
+	return result, nil
+}
+
+func (s *outboxService) GetOutboxMsg(ctx context.Context, id uuid.UUID) (model.OutboxMsg, error) {
+	ctx, span := tracer.Start(ctx, "outboxService.GetOutboxMsg")
+	defer span.End()
+
+	msg, err := s.outboxMsgRepo.GetOutboxMsg(ctx, id)
+	if err != nil {
+		return model.OutboxMsg{}, fmt.Errorf("outbox msg repository get outbox msg: %w", err)
+	}
+
+	return msg, nil
+}
+
+func (s *outboxService) CountOutboxMsgs(ctx context.Context) (CountOutboxMsgsResult, error) {
+	ctx, span := tracer.Start(ctx, "outboxService.CountOutboxMsgs")
+	defer span.End()
+
+	counts, err := s.outboxMsgRepo.CountOutboxMsgs(ctx)
+	if err != nil {
+		return CountOutboxMsgsResult{}, fmt.Errorf("outbox msg repository count outbox msgs: %w", err)
+	}
+
+	// the counts are sorted by topic
+	result := CountOutboxMsgsResult{Topics: []TopicOutboxMsgCounts{}}
+	for _, count := range counts {
+		if n := len(result.Topics); n == 0 || result.Topics[n-1].Topic != count.Topic {
+			result.Topics = append(result.Topics, TopicOutboxMsgCounts{Topic: count.Topic})
+		}
+		result.Topics[len(result.Topics)-1].Counts.Add(count.Status, count.Count)
+		result.Total.Add(count.Status, count.Count)
+	}
+
+	return result, nil
+}
+
+func (s *outboxService) RequeueOutboxMsgs(ctx context.Context, params RequeueOutboxMsgsParams) (int64, error) {
+	ctx, span := tracer.Start(ctx, "outboxService.RequeueOutboxMsgs")
+	defer span.End()
+
+	if params.IDs == nil && params.Topic == nil && params.CreatedAfter == nil &&
+		params.CreatedBefore == nil && params.ErrorContains == nil {
+		return 0, apperr.ValidationErr.WrapParent(
+			errors.New("requeueing requires ids, a topic, a time range or an error substring"))
+	}
+
+	match := repository.MatchOutboxMsgsParams{
+		Failed:        true,
+		IDs:           params.IDs,
+		Topic:         params.Topic,
+		CreatedAfter:  params.CreatedAfter,
+		CreatedBefore: params.CreatedBefore,
+		ErrorContains: params.ErrorContains,
+	}
+
+	if params.DryRun {
+		count, err := s.outboxMsgRepo.CountMatchingOutboxMsgs(ctx, match)
+		if err != nil {
+			return 0, fmt.Errorf("outbox msg repository count matching outbox msgs: %w", err)
+		}
+		return count, nil
+	}
+
+	count, err := s.outboxMsgRepo.ResetOutboxMsgs(ctx, match)
+	if err != nil {
+		return 0, fmt.Errorf("outbox msg repository reset outbox msgs: %w", err)
+	}
+
+	return count, nil
+}
+
+func (s *outboxService) ReplayOutboxMsgs(ctx context.Context, params ReplayOutboxMsgsParams) (int64, error) {
+	ctx, span := tracer.Start(ctx, "outboxService.ReplayOutboxMsgs")
+	defer span.End()
+
+	if params.IDs == nil && params.Topic == nil && params.PartitionKey == nil &&
+		params.CreatedAfter == nil && params.CreatedBefore == nil {
+		return 0, apperr.ValidationErr.WrapParent(
+			errors.New("replaying requires ids, a topic, a partition key or a time range"))
+	}
+
+	match := repository.MatchOutboxMsgsParams{
+		Failed:        false,
+		IDs:           params.IDs,
+		Topic:         params.Topic,
+		PartitionKey:  params.PartitionKey,
+		CreatedAfter:  params.CreatedAfter,
+		CreatedBefore: params.CreatedBefore,
+	}
+
+	var (
+		count int64
+		err   error
+	)
+	switch {
+	case params.Mode != OutboxReplayModeReset && params.Mode != OutboxReplayModeCopy:
+		return 0, apperr.ValidationErr.WrapParent(fmt.Errorf("unknown replay mode %q", params.Mode))
+	case params.DryRun:
+		count, err = s.outboxMsgRepo.CountMatchingOutboxMsgs(ctx, match)
+	case params.Mode == OutboxReplayModeReset:
+		count, err = s.outboxMsgRepo.ResetOutboxMsgs(ctx, match)
+	default:
+		count, err = s.outboxMsgRepo.CopyOutboxMsgs(ctx, match)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("outbox msg repository replay outbox msgs: %w", err)
+	}
+
+	return count, nil
+}
+
+func (s *outboxService) PurgeOutboxMsgs(ctx context.Context, params PurgeOutboxMsgsParams) (int64, error) {
+	ctx, span := tracer.Start(ctx, "outboxService.PurgeOutboxMsgs")
+	defer span.End()
+
+	if params.CreatedBefore.IsZero() {
+		return 0, apperr.ValidationErr.WrapParent(errors.New("purging requires a creation time to purge messages before"))
+	}
+
+	match := repository.MatchOutboxMsgsParams{
+		Failed:        params.Failed,
+		Topic:         params.Topic,
+		CreatedBefore: &params.CreatedBefore,
+	}
+
+	if params.DryRun {
+		count, err := s.outboxMsgRepo.CountMatchingOutboxMsgs(ctx, match)
+		if err != nil {
+			return 0, fmt.Errorf("outbox msg repository count matching outbox msgs: %w", err)
+		}
+		return count, nil
+	}
+
+	count, err := s.outboxMsgRepo.DeleteOutboxMsgs(ctx, match)
+	if err != nil {
+		return 0, fmt.Errorf("outbox msg repository delete outbox msgs: %w", err)
+	}
+
+	return count, nil
+}
+
+func (s *outboxService) ListOutboxBacklog(ctx context.Context) ([]TopicOutboxBacklog, error) {
+	ctx, span := tracer.Start(ctx, "outboxService.ListOutboxBacklog")
+	defer span.End()
+
+	backlog, err := s.outboxMsgRepo.ListOutboxBacklog(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("outbox msg repository list outbox backlog: %w", err)
+	}
+
+	results := make([]TopicOutboxBacklog, 0, len(backlog))
+	for _, topic := range backlog {
+		results = append(results, TopicOutboxBacklog{
+			Topic:           topic.Topic,
+			Pending:         topic.Count,
+			OldestCreatedAt: topic.OldestCreatedAt,
+		})
+	}
+
+	return results, nil
+}
+
+func (s *outboxService) TailOutboxMsgs(ctx context.Context, params TailOutboxMsgsParams, fn func(model.OutboxMsg) error) error {
+	interval := params.Interval
+	if interval == 0 {
+		interval = DefaultOutboxTailInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	after := repository.ListRelayedOutboxMsgsParams{
+		Topic:          params.Topic,
+		ProcessedAfter: params.Since,
+		Limit:          outboxTailPageSize,
+	}
+	for {
+		// the pages are drained before waiting for the next tick, to catch up with a backlog
+		for {
+			msgs, err := s.outboxMsgRepo.ListRelayedOutboxMsgs(ctx, after)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return fmt.Errorf("outbox msg repository list relayed outbox msgs: %w", err)
+			}
+
+			for _, msg := range msgs {
+				if err := fn(msg); err != nil {
+					return err
+				}
+				after.ProcessedAfter = *msg.ProcessedAt
+				after.AfterID = msg.ID
+			}
+
+			if len(msgs) < outboxTailPageSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// encodeOutboxMsgCursor returns the opaque cursor of the messages following the cursor.
+func encodeOutboxMsgCursor(cursor repository.OutboxMsgCursor) string {
+	raw := cursor.CreatedAt.UTC().Format(time.RFC3339Nano) + "_" + cursor.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeOutboxMsgCursor(s string) (repository.OutboxMsgCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return repository.OutboxMsgCursor{}, fmt.Errorf("decode base64: %w", err)
+	}
+
+	createdAtStr, idStr, ok := strings.Cut(string(raw), "_")
+	if !ok {
+		return repository.OutboxMsgCursor{}, fmt.Errorf("malformed cursor %q", raw)
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtStr)
+	if err != nil {
+		return repository.OutboxMsgCursor{}, fmt.Errorf("parse created at: %w", err)
+	}
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return repository.OutboxMsgCursor{}, fmt.Errorf("parse id: %w", err)
+	}
+
+	return repository.OutboxMsgCursor{CreatedAt: createdAt, ID: id}, nil
+}
@@ -0,0 +1,343 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/apperr"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/model"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/service"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/ptr"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/zerror"
+	"github.com/tuanvumaihuynh/outbox-pattern/test/fake"
+)
+
+// seedOutboxMsgs stores a pending, a processed and a failed message per topic, one second apart.
+func seedOutboxMsgs(t *testing.T, repo *fake.OutboxMsgRepository, base time.Time, topics ...string) []fake.OutboxMsg {
+	t.Helper()
+
+	var msgs []fake.OutboxMsg
+	for _, topic := range topics {
+		for _, status := range []model.OutboxMsgStatus{
+			model.OutboxMsgStatusPending,
+			model.OutboxMsgStatusProcessed,
+			model.OutboxMsgStatusFailed,
+		} {
+			msg := fake.OutboxMsg{
+				Topic:     topic,
+				Payload:   []byte(`{"id":1}`),
+				CreatedAt: base.Add(time.Duration(len(msgs)) * time.Second),
+			}
+			if status != model.OutboxMsgStatusPending {
+				msg.ProcessedAt = ptr.New(msg.CreatedAt.Add(time.Millisecond))
+			}
+			if status == model.OutboxMsgStatusFailed {
+				msg.Error = ptr.New("broker unavailable")
+			}
+
+			msg, err := repo.Add(msg)
+			require.NoError(t, err)
+			msgs = append(msgs, msg)
+		}
+	}
+
+	return msgs
+}
+
+func outboxMsgStatus(t *testing.T, repo *fake.OutboxMsgRepository, id uuid.UUID) model.OutboxMsgStatus {
+	t.Helper()
+
+	msg, err := repo.GetOutboxMsg(context.Background(), id)
+	require.NoError(t, err)
+	return msg.Status()
+}
+
+func assertErrorCode(t *testing.T, code string, err error) {
+	t.Helper()
+
+	var zErr zerror.ZError
+	if assert.ErrorAs(t, err, &zErr) {
+		assert.Equal(t, code, zErr.Code())
+	}
+}
+
+func TestOutboxService(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Should list pages of messages newest first", func(t *testing.T) {
+		repo := fake.NewOutboxMsgRepository()
+		msgs := seedOutboxMsgs(t, repo, base, "a", "b")
+		svc := service.NewOutboxService(repo)
+
+		var ids []uuid.UUID
+		params := service.ListOutboxMsgsParams{Limit: 4}
+		for {
+			result, err := svc.ListOutboxMsgs(ctx, params)
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(result.Msgs), 4)
+
+			for _, msg := range result.Msgs {
+				ids = append(ids, msg.ID)
+			}
+			if result.NextCursor == nil {
+				break
+			}
+			params.Cursor = result.NextCursor
+		}
+
+		require.Len(t, ids, len(msgs))
+		for i, id := range ids {
+			assert.Equal(t, msgs[len(msgs)-1-i].ID, id)
+		}
+	})
+
+	t.Run("Should filter messages", func(t *testing.T) {
+		repo := fake.NewOutboxMsgRepository()
+		msgs := seedOutboxMsgs(t, repo, base, "a", "b")
+		svc := service.NewOutboxService(repo)
+
+		result, err := svc.ListOutboxMsgs(ctx, service.ListOutboxMsgsParams{
+			Topic:  ptr.New("b"),
+			Status: ptr.New(model.OutboxMsgStatusFailed),
+		})
+		require.NoError(t, err)
+		require.Len(t, result.Msgs, 1)
+		assert.Equal(t, msgs[5].ID, result.Msgs[0].ID)
+		assert.Equal(t, model.OutboxMsgStatusFailed, result.Msgs[0].Status())
+		assert.Nil(t, result.NextCursor)
+
+		result, err = svc.ListOutboxMsgs(ctx, service.ListOutboxMsgsParams{
+			CreatedAfter:  ptr.New(base.Add(time.Second)),
+			CreatedBefore: ptr.New(base.Add(3 * time.Second)),
+		})
+		require.NoError(t, err)
+		require.Len(t, result.Msgs, 2)
+		assert.Equal(t, msgs[2].ID, result.Msgs[0].ID)
+		assert.Equal(t, msgs[1].ID, result.Msgs[1].ID)
+
+		result, err = svc.ListOutboxMsgs(ctx, service.ListOutboxMsgsParams{
+			ErrorContains: ptr.New("unavailable"),
+		})
+		require.NoError(t, err)
+		assert.Len(t, result.Msgs, 2)
+	})
+
+	t.Run("Should reject invalid list params", func(t *testing.T) {
+		svc := service.NewOutboxService(fake.NewOutboxMsgRepository())
+
+		_, err := svc.ListOutboxMsgs(ctx, service.ListOutboxMsgsParams{Cursor: ptr.New("not a cursor")})
+		assertErrorCode(t, apperr.OutboxCursorInvalidErrorCode, err)
+
+		_, err = svc.ListOutboxMsgs(ctx, service.ListOutboxMsgsParams{Limit: service.MaxOutboxMsgPageSize + 1})
+		assertErrorCode(t, apperr.ValidationErrorCode, err)
+
+		_, err = svc.ListOutboxMsgs(ctx, service.ListOutboxMsgsParams{Status: ptr.New(model.OutboxMsgStatus("stuck"))})
+		assertErrorCode(t, apperr.ValidationErrorCode, err)
+	})
+
+	t.Run("Should get message or return not found", func(t *testing.T) {
+		repo := fake.NewOutboxMsgRepository()
+		msgs := seedOutboxMsgs(t, repo, base, "a")
+		svc := service.NewOutboxService(repo)
+
+		msg, err := svc.GetOutboxMsg(ctx, msgs[2].ID)
+		require.NoError(t, err)
+		assert.Equal(t, "a", msg.Topic)
+		assert.Equal(t, "broker unavailable", *msg.Error)
+
+		_, err = svc.GetOutboxMsg(ctx, uuid.New())
+		assertErrorCode(t, apperr.OutboxMsgNotFoundErrorCode, err)
+	})
+
+	t.Run("Should count messages by status per topic", func(t *testing.T) {
+		repo := fake.NewOutboxMsgRepository()
+		seedOutboxMsgs(t, repo, base, "b", "a", "b")
+		svc := service.NewOutboxService(repo)
+
+		result, err := svc.CountOutboxMsgs(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, model.OutboxMsgCounts{Pending: 3, Processed: 3, Failed: 3}, result.Total)
+		assert.Equal(t, []service.TopicOutboxMsgCounts{
+			{Topic: "a", Counts: model.OutboxMsgCounts{Pending: 1, Processed: 1, Failed: 1}},
+			{Topic: "b", Counts: model.OutboxMsgCounts{Pending: 2, Processed: 2, Failed: 2}},
+		}, result.Topics)
+	})
+
+	t.Run("Should requeue failed messages", func(t *testing.T) {
+		repo := fake.NewOutboxMsgRepository()
+		msgs := seedOutboxMsgs(t, repo, base, "a", "b")
+		svc := service.NewOutboxService(repo)
+
+		count, err := svc.RequeueOutboxMsgs(ctx, service.RequeueOutboxMsgsParams{
+			CreatedAfter: ptr.New(base),
+			DryRun:       true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+		assert.Equal(t, model.OutboxMsgStatusFailed, outboxMsgStatus(t, repo, msgs[2].ID))
+
+		count, err = svc.RequeueOutboxMsgs(ctx, service.RequeueOutboxMsgsParams{Topic: ptr.New("b")})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+		assert.Equal(t, model.OutboxMsgStatusFailed, outboxMsgStatus(t, repo, msgs[2].ID))
+		assert.Equal(t, model.OutboxMsgStatusPending, outboxMsgStatus(t, repo, msgs[5].ID))
+		assert.Equal(t, model.OutboxMsgStatusProcessed, outboxMsgStatus(t, repo, msgs[4].ID))
+	})
+
+	t.Run("Should reject requeueing without a filter", func(t *testing.T) {
+		repo := fake.NewOutboxMsgRepository()
+		msgs := seedOutboxMsgs(t, repo, base, "a")
+		svc := service.NewOutboxService(repo)
+
+		_, err := svc.RequeueOutboxMsgs(ctx, service.RequeueOutboxMsgsParams{})
+		assertErrorCode(t, apperr.ValidationErrorCode, err)
+		assert.Equal(t, model.OutboxMsgStatusFailed, outboxMsgStatus(t, repo, msgs[2].ID))
+	})
+
+	t.Run("Should replay processed messages by resetting them", func(t *testing.T) {
+		repo := fake.NewOutboxMsgRepository()
+		msgs := seedOutboxMsgs(t, repo, base, "a", "b")
+		svc := service.NewOutboxService(repo)
+
+		count, err := svc.ReplayOutboxMsgs(ctx, service.ReplayOutboxMsgsParams{
+			Mode:   service.OutboxReplayModeReset,
+			Topic:  ptr.New("a"),
+			DryRun: true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+		assert.Equal(t, model.OutboxMsgStatusProcessed, outboxMsgStatus(t, repo, msgs[1].ID))
+
+		count, err = svc.ReplayOutboxMsgs(ctx, service.ReplayOutboxMsgsParams{
+			Mode:  service.OutboxReplayModeReset,
+			Topic: ptr.New("a"),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+		assert.Equal(t, model.OutboxMsgStatusPending, outboxMsgStatus(t, repo, msgs[1].ID))
+		assert.Equal(t, model.OutboxMsgStatusFailed, outboxMsgStatus(t, repo, msgs[2].ID))
+		assert.Equal(t, model.OutboxMsgStatusProcessed, outboxMsgStatus(t, repo, msgs[4].ID))
+		assert.Len(t, repo.Messages(), len(msgs))
+	})
+
+	t.Run("Should replay processed messages by copying them", func(t *testing.T) {
+		repo := fake.NewOutboxMsgRepository()
+		msgs := seedOutboxMsgs(t, repo, base, "a", "b")
+		svc := service.NewOutboxService(repo)
+
+		count, err := svc.ReplayOutboxMsgs(ctx, service.ReplayOutboxMsgsParams{
+			Mode:         service.OutboxReplayModeCopy,
+			CreatedAfter: ptr.New(base),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+
+		stored := repo.Messages()
+		require.Len(t, stored, len(msgs)+2)
+		assert.Equal(t, model.OutboxMsgStatusProcessed, outboxMsgStatus(t, repo, msgs[1].ID))
+		for i, copied := range []fake.OutboxMsg{msgs[1], msgs[4]} {
+			msg := stored[len(msgs)+i]
+			assert.NotEqual(t, copied.ID, msg.ID)
+			assert.Equal(t, copied.Topic, msg.Topic)
+			assert.Equal(t, copied.Payload, msg.Payload)
+			assert.Nil(t, msg.ProcessedAt)
+		}
+		assert.True(t, stored[len(msgs)].CreatedAt.Before(stored[len(msgs)+1].CreatedAt))
+	})
+
+	t.Run("Should reject invalid replay params", func(t *testing.T) {
+		svc := service.NewOutboxService(fake.NewOutboxMsgRepository())
+
+		_, err := svc.ReplayOutboxMsgs(ctx, service.ReplayOutboxMsgsParams{Mode: service.OutboxReplayModeCopy})
+		assertErrorCode(t, apperr.ValidationErrorCode, err)
+
+		_, err = svc.ReplayOutboxMsgs(ctx, service.ReplayOutboxMsgsParams{
+			Mode:  service.OutboxReplayMode("rewind"),
+			Topic: ptr.New("a"),
+		})
+		assertErrorCode(t, apperr.ValidationErrorCode, err)
+	})
+	t.Run("Should purge relayed messages created before a time", func(t *testing.T) {
+		repo := fake.NewOutboxMsgRepository()
+		msgs := seedOutboxMsgs(t, repo, base, "a", "b")
+		svc := service.NewOutboxService(repo)
+
+		_, err := svc.PurgeOutboxMsgs(ctx, service.PurgeOutboxMsgsParams{})
+		assertErrorCode(t, apperr.ValidationErrorCode, err)
+
+		count, err := svc.PurgeOutboxMsgs(ctx, service.PurgeOutboxMsgsParams{
+			CreatedBefore: base.Add(4 * time.Second),
+			DryRun:        true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+		assert.Len(t, repo.Messages(), len(msgs))
+
+		count, err = svc.PurgeOutboxMsgs(ctx, service.PurgeOutboxMsgsParams{
+			Failed:        true,
+			CreatedBefore: base.Add(time.Hour),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+
+		stored := repo.Messages()
+		require.Len(t, stored, len(msgs)-2)
+		for _, msg := range stored {
+			assert.NotEqual(t, model.OutboxMsgStatusFailed, outboxMsgStatus(t, repo, msg.ID))
+		}
+	})
+
+	t.Run("Should list the pending backlog per topic", func(t *testing.T) {
+		repo := fake.NewOutboxMsgRepository()
+		msgs := seedOutboxMsgs(t, repo, base, "b", "a", "b")
+		svc := service.NewOutboxService(repo)
+
+		backlog, err := svc.ListOutboxBacklog(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []service.TopicOutboxBacklog{
+			{Topic: "a", Pending: 1, OldestCreatedAt: msgs[3].CreatedAt},
+			{Topic: "b", Pending: 2, OldestCreatedAt: msgs[0].CreatedAt},
+		}, backlog)
+	})
+
+	t.Run("Should tail messages in relay order", func(t *testing.T) {
+		repo := fake.NewOutboxMsgRepository()
+		msgs := seedOutboxMsgs(t, repo, base, "a", "b")
+		svc := service.NewOutboxService(repo)
+
+		tailCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		var tailed []uuid.UUID
+		done := make(chan error, 1)
+		go func() {
+			done <- svc.TailOutboxMsgs(tailCtx, service.TailOutboxMsgsParams{
+				Topic:    ptr.New("b"),
+				Since:    base.Add(5 * time.Second),
+				Interval: time.Millisecond,
+			}, func(msg model.OutboxMsg) error {
+				tailed = append(tailed, msg.ID)
+				if len(tailed) == 2 {
+					cancel()
+				}
+				return nil
+			})
+		}()
+
+		late, err := repo.Add(fake.OutboxMsg{
+			Topic:       "b",
+			Payload:     []byte(`{"id":1}`),
+			ProcessedAt: ptr.New(base.Add(time.Hour)),
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, <-done)
+		assert.Equal(t, []uuid.UUID{msgs[5].ID, late.ID}, tailed)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,6 +14,7 @@ import (
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/repository"
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db"
 	"github.com/tuanvumaihuynh/outbox-pattern/pkg/outbox"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/ptr"
 )
 
 type CreateProductParams struct {
@@ -78,6 +80,10 @@ func (s *productService) CreateProduct(ctx context.Context, params CreateProduct
 		return model.Product{}, fmt.Errorf("marshal event: %w", err)
 	}
 
+	headers := outbox.BuildHeaders(ctx)
+	headers[outbox.EventTypeHeader] = event.EventTypeProductCreated
+	headers[outbox.EventVersionHeader] = strconv.Itoa(event.EventVersionProductCreated)
+
 	if err := s.db.WithTx(ctx, func(db db.DB) error {
 		if err := s.productRepo.
 			WithDB(db).
@@ -88,9 +94,10 @@ func (s *productService) CreateProduct(ctx context.Context, params CreateProduct
 		if err := s.outboxMsgRepo.
 			WithDB(db).
 			CreateOutboxMsg(ctx, repository.CreateOutboxMsgParams{
-				Topic:   event.TopicProductCreated,
-				Headers: outbox.BuildHeaders(ctx),
-				Payload: evBytes,
+				Topic:        event.TopicProductCreated,
+				Headers:      headers,
+				Payload:      evBytes,
+				PartitionKey: ptr.New(product.ID.String()),
 			}); err != nil {
 			return fmt.Errorf("outbox msg repository create outbox msg: %w", err)
 		}
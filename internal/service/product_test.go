@@ -0,0 +1,93 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/apperr"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/event"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/eventschema"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/model"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/repository"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/service"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db/sqlc"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/correlationid"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/outbox"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/zerror"
+	"github.com/tuanvumaihuynh/outbox-pattern/test/fake"
+	"github.com/tuanvumaihuynh/outbox-pattern/test/pgtest"
+)
+
+func TestProductService(t *testing.T) {
+	t.Run("Should create product and enqueue product created event", func(t *testing.T) {
+		productRepo := fake.NewProductRepository()
+		outboxMsgRepo := fake.NewOutboxMsgRepository()
+		svc := service.NewProductService(fake.NewDB(), productRepo, outboxMsgRepo)
+
+		ctx := correlationid.NewContext(context.Background(), "correlation-id")
+		product, err := svc.CreateProduct(ctx, service.CreateProductParams{
+			Name:          "Product 1",
+			Sku:           "SKU1",
+			Price:         10.5,
+			StockQuantity: 3,
+		})
+		require.NoError(t, err)
+
+		products, err := svc.ListAllProducts(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []model.Product{product}, products)
+
+		msgs := outboxMsgRepo.Messages()
+		require.Len(t, msgs, 1)
+		assert.Equal(t, event.TopicProductCreated, msgs[0].Topic)
+		require.NotNil(t, msgs[0].PartitionKey)
+		assert.Equal(t, product.ID.String(), *msgs[0].PartitionKey)
+		assert.Equal(t, "correlation-id", msgs[0].Headers[correlationid.Header])
+		assert.Equal(t, event.EventTypeProductCreated, msgs[0].Headers[outbox.EventTypeHeader])
+		assert.Equal(t, "1", msgs[0].Headers[outbox.EventVersionHeader])
+
+		var ev event.ProductCreatedEvent
+		require.NoError(t, json.Unmarshal(msgs[0].Payload, &ev))
+		assert.Equal(t, event.ProductCreatedEvent{
+			ProductID:     product.ID.String(),
+			Name:          "Product 1",
+			Sku:           "SKU1",
+			Price:         10.5,
+			StockQuantity: 3,
+		}, ev)
+	})
+
+	t.Run("Should reject an invalid event payload and roll back the product", func(t *testing.T) {
+		validator, err := eventschema.Default()
+		require.NoError(t, err)
+
+		dbClient := db.NewClient(pgtest.NewPool(t))
+		queries := *sqlc.New()
+		outboxMsgRepo := repository.NewOutboxMsgRepository(dbClient, queries, validator)
+		svc := service.NewProductService(dbClient, repository.NewProductRepository(dbClient, queries), outboxMsgRepo)
+
+		// the product created event requires a non-empty sku
+		_, err = svc.CreateProduct(context.Background(), service.CreateProductParams{
+			Name:          "Product 1",
+			Sku:           "",
+			Price:         10.5,
+			StockQuantity: 3,
+		})
+		var zErr zerror.ZError
+		require.ErrorAs(t, err, &zErr)
+		assert.Equal(t, apperr.EventPayloadInvalidErrorCode, zErr.Code())
+
+		products, err := svc.ListAllProducts(context.Background())
+		require.NoError(t, err)
+		assert.Empty(t, products)
+
+		backlog, err := outboxMsgRepo.ListOutboxBacklog(context.Background())
+		require.NoError(t, err)
+		assert.Empty(t, backlog)
+	})
+}
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE mq_messages (
+	id              BIGSERIAL PRIMARY KEY,
+	topic           TEXT NOT NULL,
+	partition_key   TEXT,
+	headers         JSONB,
+	payload         BYTEA NOT NULL,
+	created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_mq_messages_topic_id ON mq_messages (topic, id);
+
+CREATE TABLE mq_consumer_offsets (
+	consumer_group  TEXT NOT NULL,
+	topic           TEXT NOT NULL,
+	last_id         BIGINT NOT NULL DEFAULT 0,
+	updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (consumer_group, topic)
+);
+
+CREATE FUNCTION mq_messages_notify() RETURNS TRIGGER AS $$
+BEGIN
+	PERFORM pg_notify('mq_messages', NEW.topic);
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER mq_messages_notify
+AFTER INSERT ON mq_messages
+FOR EACH ROW EXECUTE FUNCTION mq_messages_notify();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER mq_messages_notify ON mq_messages;
+DROP FUNCTION mq_messages_notify();
+DROP TABLE mq_consumer_offsets;
+DROP TABLE mq_messages;
+-- +goose StatementEnd
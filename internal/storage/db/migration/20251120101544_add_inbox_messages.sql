@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE inbox_messages (
+	consumer        TEXT NOT NULL,
+	message_id      TEXT NOT NULL,
+	topic           TEXT NOT NULL,
+	processed_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (consumer, message_id)
+);
+
+CREATE INDEX idx_inbox_messages_processed_at ON inbox_messages (processed_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE inbox_messages;
+-- +goose StatementEnd
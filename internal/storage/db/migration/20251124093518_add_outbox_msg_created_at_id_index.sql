@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX idx_outbox_messages_created_at_id_desc
+ON outbox_messages (created_at DESC, id DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_outbox_messages_created_at_id_desc;
+-- +goose StatementEnd
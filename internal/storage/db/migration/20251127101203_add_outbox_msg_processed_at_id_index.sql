@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX idx_outbox_messages_processed_at_id_asc
+ON outbox_messages (processed_at ASC, id ASC)
+WHERE processed_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_outbox_messages_processed_at_id_asc;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE outbox_messages
+	ADD COLUMN attempts        INTEGER NOT NULL DEFAULT 0,
+	ADD COLUMN next_attempt_at TIMESTAMPTZ;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE outbox_messages
+	DROP COLUMN next_attempt_at,
+	DROP COLUMN attempts;
+-- +goose StatementEnd
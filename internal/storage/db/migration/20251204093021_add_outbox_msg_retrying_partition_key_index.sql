@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX idx_outbox_messages_retrying_partition_key_created_at
+ON outbox_messages (partition_key, created_at)
+WHERE processed_at IS NULL AND next_attempt_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_outbox_messages_retrying_partition_key_created_at;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- name: InboxMsgCreate :execrows
+INSERT INTO inbox_messages (
+	consumer,
+	message_id,
+	topic,
+	processed_at
+) VALUES (
+	@consumer,
+	@message_id,
+	@topic,
+	@processed_at
+)
+ON CONFLICT (consumer, message_id) DO NOTHING;
@@ -12,15 +12,40 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-type OutboxMessage struct {
-	ID           uuid.UUID        `json:"id"`
+type InboxMessage struct {
+	Consumer    string    `json:"consumer"`
+	MessageID   string    `json:"message_id"`
+	Topic       string    `json:"topic"`
+	ProcessedAt time.Time `json:"processed_at"`
+}
+
+type MqConsumerOffset struct {
+	ConsumerGroup string    `json:"consumer_group"`
+	Topic         string    `json:"topic"`
+	LastID        int64     `json:"last_id"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type MqMessage struct {
+	ID           int64            `json:"id"`
 	Topic        string           `json:"topic"`
-	Headers      *json.RawMessage `json:"headers"`
-	Payload      json.RawMessage  `json:"payload"`
 	PartitionKey *string          `json:"partition_key"`
+	Headers      *json.RawMessage `json:"headers"`
+	Payload      []byte           `json:"payload"`
 	CreatedAt    time.Time        `json:"created_at"`
-	ProcessedAt  *time.Time       `json:"processed_at"`
-	Error        *string          `json:"error"`
+}
+
+type OutboxMessage struct {
+	ID            uuid.UUID        `json:"id"`
+	Topic         string           `json:"topic"`
+	Headers       *json.RawMessage `json:"headers"`
+	Payload       json.RawMessage  `json:"payload"`
+	PartitionKey  *string          `json:"partition_key"`
+	CreatedAt     time.Time        `json:"created_at"`
+	ProcessedAt   *time.Time       `json:"processed_at"`
+	Error         *string          `json:"error"`
+	Attempts      int32            `json:"attempts"`
+	NextAttemptAt *time.Time       `json:"next_attempt_at"`
 }
 
 type Product struct {
@@ -0,0 +1,56 @@
+-- name: MqTopicLock :exec
+SELECT pg_advisory_xact_lock(hashtext(@topic::text));
+
+-- name: MqMessageCreate :exec
+INSERT INTO mq_messages (
+	topic,
+	partition_key,
+	headers,
+	payload,
+	created_at
+) VALUES (
+	@topic,
+	@partition_key,
+	@headers,
+	@payload,
+	@created_at
+);
+
+-- name: MqMessageListAfter :many
+SELECT
+	id,
+	topic,
+	partition_key,
+	headers,
+	payload,
+	created_at
+FROM mq_messages
+WHERE topic = @topic
+	AND id > @after_id
+ORDER BY id ASC
+LIMIT @batch_size;
+
+-- name: MqConsumerOffsetInit :exec
+INSERT INTO mq_consumer_offsets (
+	consumer_group,
+	topic
+) VALUES (
+	@consumer_group,
+	@topic
+)
+ON CONFLICT (consumer_group, topic) DO NOTHING;
+
+-- name: MqConsumerOffsetClaim :one
+SELECT last_id
+FROM mq_consumer_offsets
+WHERE consumer_group = @consumer_group
+	AND topic = @topic
+FOR UPDATE SKIP LOCKED;
+
+-- name: MqConsumerOffsetUpdate :exec
+UPDATE mq_consumer_offsets
+SET
+	last_id    = @last_id,
+	updated_at = NOW()
+WHERE consumer_group = @consumer_group
+	AND topic = @topic;
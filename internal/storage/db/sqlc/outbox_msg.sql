@@ -23,9 +23,169 @@ SELECT
 	topic,
 	headers,
 	payload,
-	partition_key
+	partition_key,
+	created_at,
+	attempts
 FROM outbox_messages
 WHERE processed_at IS NULL
+	AND (next_attempt_at IS NULL OR next_attempt_at <= NOW())
+	-- keep the order of a partition key while an earlier message of the key waits for its retry
+	AND NOT EXISTS (
+		SELECT 1
+		FROM outbox_messages AS earlier
+		WHERE earlier.partition_key = outbox_messages.partition_key
+			AND earlier.processed_at IS NULL
+			AND earlier.next_attempt_at > NOW()
+			AND earlier.created_at < outbox_messages.created_at
+	)
 ORDER BY created_at ASC
 LIMIT @batchSize
 FOR UPDATE SKIP LOCKED;
+
+-- name: OutboxMsgGet :one
+SELECT
+	id,
+	topic,
+	headers,
+	payload,
+	partition_key,
+	created_at,
+	processed_at,
+	error,
+	attempts,
+	next_attempt_at
+FROM outbox_messages
+WHERE id = @id;
+
+-- name: OutboxMsgList :many
+SELECT
+	id,
+	topic,
+	headers,
+	payload,
+	partition_key,
+	created_at,
+	processed_at,
+	error,
+	attempts,
+	next_attempt_at
+FROM outbox_messages
+WHERE (sqlc.narg('topic')::text IS NULL OR topic = sqlc.narg('topic'))
+	AND (sqlc.narg('processed')::boolean IS NULL OR (processed_at IS NOT NULL) = sqlc.narg('processed'))
+	AND (sqlc.narg('failed')::boolean IS NULL OR (error IS NOT NULL) = sqlc.narg('failed'))
+	AND (sqlc.narg('created_after')::timestamptz IS NULL OR created_at >= sqlc.narg('created_after'))
+	AND (sqlc.narg('created_before')::timestamptz IS NULL OR created_at < sqlc.narg('created_before'))
+	AND (sqlc.narg('error_contains')::text IS NULL OR strpos(error, sqlc.narg('error_contains')) > 0)
+	AND (
+		sqlc.narg('cursor_created_at')::timestamptz IS NULL
+		OR (created_at, id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid)
+	)
+ORDER BY created_at DESC, id DESC
+LIMIT @page_size;
+
+-- name: OutboxMsgCountByStatus :many
+SELECT
+	topic,
+	(CASE
+		WHEN processed_at IS NULL THEN 'pending'
+		WHEN error IS NULL THEN 'processed'
+		ELSE 'failed'
+	END)::text AS status,
+	COUNT(*) AS count
+FROM outbox_messages
+GROUP BY topic, status
+ORDER BY topic, status;
+
+-- name: OutboxMsgCountMatching :one
+SELECT COUNT(*)
+FROM outbox_messages
+WHERE processed_at IS NOT NULL
+	AND (error IS NOT NULL) = @failed::boolean
+	AND (sqlc.narg('ids')::uuid[] IS NULL OR id = ANY(sqlc.narg('ids')::uuid[]))
+	AND (sqlc.narg('topic')::text IS NULL OR topic = sqlc.narg('topic'))
+	AND (sqlc.narg('partition_key')::text IS NULL OR partition_key = sqlc.narg('partition_key'))
+	AND (sqlc.narg('created_after')::timestamptz IS NULL OR created_at >= sqlc.narg('created_after'))
+	AND (sqlc.narg('created_before')::timestamptz IS NULL OR created_at < sqlc.narg('created_before'))
+	AND (sqlc.narg('error_contains')::text IS NULL OR strpos(error, sqlc.narg('error_contains')) > 0);
+
+-- name: OutboxMsgReset :execrows
+UPDATE outbox_messages
+SET
+	processed_at    = NULL,
+	error           = NULL,
+	attempts        = 0,
+	next_attempt_at = NULL
+WHERE processed_at IS NOT NULL
+	AND (error IS NOT NULL) = @failed::boolean
+	AND (sqlc.narg('ids')::uuid[] IS NULL OR id = ANY(sqlc.narg('ids')::uuid[]))
+	AND (sqlc.narg('topic')::text IS NULL OR topic = sqlc.narg('topic'))
+	AND (sqlc.narg('partition_key')::text IS NULL OR partition_key = sqlc.narg('partition_key'))
+	AND (sqlc.narg('created_after')::timestamptz IS NULL OR created_at >= sqlc.narg('created_after'))
+	AND (sqlc.narg('created_before')::timestamptz IS NULL OR created_at < sqlc.narg('created_before'))
+	AND (sqlc.narg('error_contains')::text IS NULL OR strpos(error, sqlc.narg('error_contains')) > 0);
+
+-- name: OutboxMsgCopy :execrows
+-- The copies are created one microsecond apart, in the order of the messages they copy,
+-- so the relay produces them in the same order.
+INSERT INTO outbox_messages (
+	topic,
+	headers,
+	payload,
+	partition_key,
+	created_at
+)
+SELECT
+	topic,
+	headers,
+	payload,
+	partition_key,
+	NOW() + ROW_NUMBER() OVER (ORDER BY created_at, id) * INTERVAL '1 microsecond'
+FROM outbox_messages
+WHERE processed_at IS NOT NULL
+	AND (error IS NOT NULL) = @failed::boolean
+	AND (sqlc.narg('ids')::uuid[] IS NULL OR id = ANY(sqlc.narg('ids')::uuid[]))
+	AND (sqlc.narg('topic')::text IS NULL OR topic = sqlc.narg('topic'))
+	AND (sqlc.narg('partition_key')::text IS NULL OR partition_key = sqlc.narg('partition_key'))
+	AND (sqlc.narg('created_after')::timestamptz IS NULL OR created_at >= sqlc.narg('created_after'))
+	AND (sqlc.narg('created_before')::timestamptz IS NULL OR created_at < sqlc.narg('created_before'))
+	AND (sqlc.narg('error_contains')::text IS NULL OR strpos(error, sqlc.narg('error_contains')) > 0);
+
+-- name: OutboxMsgDelete :execrows
+DELETE FROM outbox_messages
+WHERE processed_at IS NOT NULL
+	AND (error IS NOT NULL) = @failed::boolean
+	AND (sqlc.narg('ids')::uuid[] IS NULL OR id = ANY(sqlc.narg('ids')::uuid[]))
+	AND (sqlc.narg('topic')::text IS NULL OR topic = sqlc.narg('topic'))
+	AND (sqlc.narg('partition_key')::text IS NULL OR partition_key = sqlc.narg('partition_key'))
+	AND (sqlc.narg('created_after')::timestamptz IS NULL OR created_at >= sqlc.narg('created_after'))
+	AND (sqlc.narg('created_before')::timestamptz IS NULL OR created_at < sqlc.narg('created_before'))
+	AND (sqlc.narg('error_contains')::text IS NULL OR strpos(error, sqlc.narg('error_contains')) > 0);
+
+-- name: OutboxMsgBacklog :many
+SELECT
+	topic,
+	COUNT(*) AS count,
+	MIN(created_at)::timestamptz AS oldest_created_at
+FROM outbox_messages
+WHERE processed_at IS NULL
+GROUP BY topic
+ORDER BY topic;
+
+-- name: OutboxMsgListRelayed :many
+SELECT
+	id,
+	topic,
+	headers,
+	payload,
+	partition_key,
+	created_at,
+	processed_at,
+	error,
+	attempts,
+	next_attempt_at
+FROM outbox_messages
+WHERE processed_at IS NOT NULL
+	AND (processed_at, id) > (@processed_after::timestamptz, @after_id::uuid)
+	AND (sqlc.narg('topic')::text IS NULL OR topic = sqlc.narg('topic'))
+ORDER BY processed_at ASC, id ASC
+LIMIT @page_size;
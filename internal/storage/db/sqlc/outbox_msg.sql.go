@@ -13,6 +13,174 @@ import (
 	"github.com/google/uuid"
 )
 
+const outboxMsgBacklog = `-- name: OutboxMsgBacklog :many
+SELECT
+	topic,
+	COUNT(*) AS count,
+	MIN(created_at)::timestamptz AS oldest_created_at
+FROM outbox_messages
+WHERE processed_at IS NULL
+GROUP BY topic
+ORDER BY topic
+`
+
+type OutboxMsgBacklogRow struct {
+	Topic           string    `json:"topic"`
+	Count           int64     `json:"count"`
+	OldestCreatedAt time.Time `json:"oldest_created_at"`
+}
+
+func (q *Queries) OutboxMsgBacklog(ctx context.Context, db DBTX) ([]OutboxMsgBacklogRow, error) {
+	rows, err := db.Query(ctx, outboxMsgBacklog)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OutboxMsgBacklogRow{}
+	for rows.Next() {
+		var i OutboxMsgBacklogRow
+		if err := rows.Scan(&i.Topic, &i.Count, &i.OldestCreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const outboxMsgCopy = `-- name: OutboxMsgCopy :execrows
+INSERT INTO outbox_messages (
+	topic,
+	headers,
+	payload,
+	partition_key,
+	created_at
+)
+SELECT
+	topic,
+	headers,
+	payload,
+	partition_key,
+	NOW() + ROW_NUMBER() OVER (ORDER BY created_at, id) * INTERVAL '1 microsecond'
+FROM outbox_messages
+WHERE processed_at IS NOT NULL
+	AND (error IS NOT NULL) = $1::boolean
+	AND ($2::uuid[] IS NULL OR id = ANY($2::uuid[]))
+	AND ($3::text IS NULL OR topic = $3)
+	AND ($4::text IS NULL OR partition_key = $4)
+	AND ($5::timestamptz IS NULL OR created_at >= $5)
+	AND ($6::timestamptz IS NULL OR created_at < $6)
+	AND ($7::text IS NULL OR strpos(error, $7) > 0)
+`
+
+type OutboxMsgCopyParams struct {
+	Failed        bool        `json:"failed"`
+	Ids           []uuid.UUID `json:"ids"`
+	Topic         *string     `json:"topic"`
+	PartitionKey  *string     `json:"partition_key"`
+	CreatedAfter  *time.Time  `json:"created_after"`
+	CreatedBefore *time.Time  `json:"created_before"`
+	ErrorContains *string     `json:"error_contains"`
+}
+
+// The copies are created one microsecond apart, in the order of the messages they copy,
+// so the relay produces them in the same order.
+func (q *Queries) OutboxMsgCopy(ctx context.Context, db DBTX, arg OutboxMsgCopyParams) (int64, error) {
+	result, err := db.Exec(ctx, outboxMsgCopy,
+		arg.Failed,
+		arg.Ids,
+		arg.Topic,
+		arg.PartitionKey,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.ErrorContains,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const outboxMsgCountByStatus = `-- name: OutboxMsgCountByStatus :many
+SELECT
+	topic,
+	(CASE
+		WHEN processed_at IS NULL THEN 'pending'
+		WHEN error IS NULL THEN 'processed'
+		ELSE 'failed'
+	END)::text AS status,
+	COUNT(*) AS count
+FROM outbox_messages
+GROUP BY topic, status
+ORDER BY topic, status
+`
+
+type OutboxMsgCountByStatusRow struct {
+	Topic  string `json:"topic"`
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+func (q *Queries) OutboxMsgCountByStatus(ctx context.Context, db DBTX) ([]OutboxMsgCountByStatusRow, error) {
+	rows, err := db.Query(ctx, outboxMsgCountByStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OutboxMsgCountByStatusRow{}
+	for rows.Next() {
+		var i OutboxMsgCountByStatusRow
+		if err := rows.Scan(&i.Topic, &i.Status, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const outboxMsgCountMatching = `-- name: OutboxMsgCountMatching :one
+SELECT COUNT(*)
+FROM outbox_messages
+WHERE processed_at IS NOT NULL
+	AND (error IS NOT NULL) = $1::boolean
+	AND ($2::uuid[] IS NULL OR id = ANY($2::uuid[]))
+	AND ($3::text IS NULL OR topic = $3)
+	AND ($4::text IS NULL OR partition_key = $4)
+	AND ($5::timestamptz IS NULL OR created_at >= $5)
+	AND ($6::timestamptz IS NULL OR created_at < $6)
+	AND ($7::text IS NULL OR strpos(error, $7) > 0)
+`
+
+type OutboxMsgCountMatchingParams struct {
+	Failed        bool        `json:"failed"`
+	Ids           []uuid.UUID `json:"ids"`
+	Topic         *string     `json:"topic"`
+	PartitionKey  *string     `json:"partition_key"`
+	CreatedAfter  *time.Time  `json:"created_after"`
+	CreatedBefore *time.Time  `json:"created_before"`
+	ErrorContains *string     `json:"error_contains"`
+}
+
+func (q *Queries) OutboxMsgCountMatching(ctx context.Context, db DBTX, arg OutboxMsgCountMatchingParams) (int64, error) {
+	row := db.QueryRow(ctx, outboxMsgCountMatching,
+		arg.Failed,
+		arg.Ids,
+		arg.Topic,
+		arg.PartitionKey,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.ErrorContains,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const outboxMsgCreate = `-- name: OutboxMsgCreate :exec
 INSERT INTO outbox_messages (
 	topic,
@@ -56,15 +224,242 @@ func (q *Queries) OutboxMsgCreate(ctx context.Context, db DBTX, arg OutboxMsgCre
 	return err
 }
 
+const outboxMsgDelete = `-- name: OutboxMsgDelete :execrows
+DELETE FROM outbox_messages
+WHERE processed_at IS NOT NULL
+	AND (error IS NOT NULL) = $1::boolean
+	AND ($2::uuid[] IS NULL OR id = ANY($2::uuid[]))
+	AND ($3::text IS NULL OR topic = $3)
+	AND ($4::text IS NULL OR partition_key = $4)
+	AND ($5::timestamptz IS NULL OR created_at >= $5)
+	AND ($6::timestamptz IS NULL OR created_at < $6)
+	AND ($7::text IS NULL OR strpos(error, $7) > 0)
+`
+
+type OutboxMsgDeleteParams struct {
+	Failed        bool        `json:"failed"`
+	Ids           []uuid.UUID `json:"ids"`
+	Topic         *string     `json:"topic"`
+	PartitionKey  *string     `json:"partition_key"`
+	CreatedAfter  *time.Time  `json:"created_after"`
+	CreatedBefore *time.Time  `json:"created_before"`
+	ErrorContains *string     `json:"error_contains"`
+}
+
+func (q *Queries) OutboxMsgDelete(ctx context.Context, db DBTX, arg OutboxMsgDeleteParams) (int64, error) {
+	result, err := db.Exec(ctx, outboxMsgDelete,
+		arg.Failed,
+		arg.Ids,
+		arg.Topic,
+		arg.PartitionKey,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.ErrorContains,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const outboxMsgGet = `-- name: OutboxMsgGet :one
+SELECT
+	id,
+	topic,
+	headers,
+	payload,
+	partition_key,
+	created_at,
+	processed_at,
+	error,
+	attempts,
+	next_attempt_at
+FROM outbox_messages
+WHERE id = $1
+`
+
+func (q *Queries) OutboxMsgGet(ctx context.Context, db DBTX, id uuid.UUID) (OutboxMessage, error) {
+	row := db.QueryRow(ctx, outboxMsgGet, id)
+	var i OutboxMessage
+	err := row.Scan(
+		&i.ID,
+		&i.Topic,
+		&i.Headers,
+		&i.Payload,
+		&i.PartitionKey,
+		&i.CreatedAt,
+		&i.ProcessedAt,
+		&i.Error,
+		&i.Attempts,
+		&i.NextAttemptAt,
+	)
+	return i, err
+}
+
+const outboxMsgList = `-- name: OutboxMsgList :many
+SELECT
+	id,
+	topic,
+	headers,
+	payload,
+	partition_key,
+	created_at,
+	processed_at,
+	error,
+	attempts,
+	next_attempt_at
+FROM outbox_messages
+WHERE ($1::text IS NULL OR topic = $1)
+	AND ($2::boolean IS NULL OR (processed_at IS NOT NULL) = $2)
+	AND ($3::boolean IS NULL OR (error IS NOT NULL) = $3)
+	AND ($4::timestamptz IS NULL OR created_at >= $4)
+	AND ($5::timestamptz IS NULL OR created_at < $5)
+	AND ($6::text IS NULL OR strpos(error, $6) > 0)
+	AND (
+		$7::timestamptz IS NULL
+		OR (created_at, id) < ($7, $8::uuid)
+	)
+ORDER BY created_at DESC, id DESC
+LIMIT $9
+`
+
+type OutboxMsgListParams struct {
+	Topic           *string    `json:"topic"`
+	Processed       *bool      `json:"processed"`
+	Failed          *bool      `json:"failed"`
+	CreatedAfter    *time.Time `json:"created_after"`
+	CreatedBefore   *time.Time `json:"created_before"`
+	ErrorContains   *string    `json:"error_contains"`
+	CursorCreatedAt *time.Time `json:"cursor_created_at"`
+	CursorID        *uuid.UUID `json:"cursor_id"`
+	PageSize        int32      `json:"page_size"`
+}
+
+func (q *Queries) OutboxMsgList(ctx context.Context, db DBTX, arg OutboxMsgListParams) ([]OutboxMessage, error) {
+	rows, err := db.Query(ctx, outboxMsgList,
+		arg.Topic,
+		arg.Processed,
+		arg.Failed,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.ErrorContains,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OutboxMessage{}
+	for rows.Next() {
+		var i OutboxMessage
+		if err := rows.Scan(
+			&i.ID,
+			&i.Topic,
+			&i.Headers,
+			&i.Payload,
+			&i.PartitionKey,
+			&i.CreatedAt,
+			&i.ProcessedAt,
+			&i.Error,
+			&i.Attempts,
+			&i.NextAttemptAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const outboxMsgListRelayed = `-- name: OutboxMsgListRelayed :many
+SELECT
+	id,
+	topic,
+	headers,
+	payload,
+	partition_key,
+	created_at,
+	processed_at,
+	error,
+	attempts,
+	next_attempt_at
+FROM outbox_messages
+WHERE processed_at IS NOT NULL
+	AND (processed_at, id) > ($1::timestamptz, $2::uuid)
+	AND ($3::text IS NULL OR topic = $3)
+ORDER BY processed_at ASC, id ASC
+LIMIT $4
+`
+
+type OutboxMsgListRelayedParams struct {
+	ProcessedAfter time.Time `json:"processed_after"`
+	AfterID        uuid.UUID `json:"after_id"`
+	Topic          *string   `json:"topic"`
+	PageSize       int32     `json:"page_size"`
+}
+
+func (q *Queries) OutboxMsgListRelayed(ctx context.Context, db DBTX, arg OutboxMsgListRelayedParams) ([]OutboxMessage, error) {
+	rows, err := db.Query(ctx, outboxMsgListRelayed,
+		arg.ProcessedAfter,
+		arg.AfterID,
+		arg.Topic,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OutboxMessage{}
+	for rows.Next() {
+		var i OutboxMessage
+		if err := rows.Scan(
+			&i.ID,
+			&i.Topic,
+			&i.Headers,
+			&i.Payload,
+			&i.PartitionKey,
+			&i.CreatedAt,
+			&i.ProcessedAt,
+			&i.Error,
+			&i.Attempts,
+			&i.NextAttemptAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const outboxMsgListUnprocessed = `-- name: OutboxMsgListUnprocessed :many
 SELECT
 	id,
 	topic,
 	headers,
 	payload,
-	partition_key
+	partition_key,
+	created_at,
+	attempts
 FROM outbox_messages
 WHERE processed_at IS NULL
+	AND (next_attempt_at IS NULL OR next_attempt_at <= NOW())
+	-- keep the order of a partition key while an earlier message of the key waits for its retry
+	AND NOT EXISTS (
+		SELECT 1
+		FROM outbox_messages AS earlier
+		WHERE earlier.partition_key = outbox_messages.partition_key
+			AND earlier.processed_at IS NULL
+			AND earlier.next_attempt_at > NOW()
+			AND earlier.created_at < outbox_messages.created_at
+	)
 ORDER BY created_at ASC
 LIMIT $1
 FOR UPDATE SKIP LOCKED
@@ -76,6 +471,8 @@ type OutboxMsgListUnprocessedRow struct {
 	Headers      *json.RawMessage `json:"headers"`
 	Payload      json.RawMessage  `json:"payload"`
 	PartitionKey *string          `json:"partition_key"`
+	CreatedAt    time.Time        `json:"created_at"`
+	Attempts     int32            `json:"attempts"`
 }
 
 func (q *Queries) OutboxMsgListUnprocessed(ctx context.Context, db DBTX, batchsize int32) ([]OutboxMsgListUnprocessedRow, error) {
@@ -93,6 +490,8 @@ func (q *Queries) OutboxMsgListUnprocessed(ctx context.Context, db DBTX, batchsi
 			&i.Headers,
 			&i.Payload,
 			&i.PartitionKey,
+			&i.CreatedAt,
+			&i.Attempts,
 		); err != nil {
 			return nil, err
 		}
@@ -103,3 +502,46 @@ func (q *Queries) OutboxMsgListUnprocessed(ctx context.Context, db DBTX, batchsi
 	}
 	return items, nil
 }
+
+const outboxMsgReset = `-- name: OutboxMsgReset :execrows
+UPDATE outbox_messages
+SET
+	processed_at    = NULL,
+	error           = NULL,
+	attempts        = 0,
+	next_attempt_at = NULL
+WHERE processed_at IS NOT NULL
+	AND (error IS NOT NULL) = $1::boolean
+	AND ($2::uuid[] IS NULL OR id = ANY($2::uuid[]))
+	AND ($3::text IS NULL OR topic = $3)
+	AND ($4::text IS NULL OR partition_key = $4)
+	AND ($5::timestamptz IS NULL OR created_at >= $5)
+	AND ($6::timestamptz IS NULL OR created_at < $6)
+	AND ($7::text IS NULL OR strpos(error, $7) > 0)
+`
+
+type OutboxMsgResetParams struct {
+	Failed        bool        `json:"failed"`
+	Ids           []uuid.UUID `json:"ids"`
+	Topic         *string     `json:"topic"`
+	PartitionKey  *string     `json:"partition_key"`
+	CreatedAfter  *time.Time  `json:"created_after"`
+	CreatedBefore *time.Time  `json:"created_before"`
+	ErrorContains *string     `json:"error_contains"`
+}
+
+func (q *Queries) OutboxMsgReset(ctx context.Context, db DBTX, arg OutboxMsgResetParams) (int64, error) {
+	result, err := db.Exec(ctx, outboxMsgReset,
+		arg.Failed,
+		arg.Ids,
+		arg.Topic,
+		arg.PartitionKey,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.ErrorContains,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
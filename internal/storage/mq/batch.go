@@ -0,0 +1,294 @@
+package mq
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// BatchHandlerFunc handles a batch of messages of a topic, in order within each partition.
+type BatchHandlerFunc func(ctx context.Context, msgs []Message) error
+
+// BatchOptions bounds the batches passed to a BatchHandlerFunc.
+type BatchOptions struct {
+	// MaxSize is the maximum number of messages in a batch.
+	MaxSize int
+	// MaxWait is how long the first message of a batch waits for the batch to fill up.
+	MaxWait time.Duration
+}
+
+// batch buffers the records of a topic with a batch handler until they are handled.
+type batch struct {
+	fn   BatchHandlerFunc
+	opts BatchOptions
+
+	records []*kgo.Record
+	// since is when the oldest buffered record was buffered
+	since time.Time
+}
+
+// due returns whether a batch of the buffered records must be handled.
+func (b *batch) due() bool {
+	if len(b.records) == 0 {
+		return false
+	}
+
+	return len(b.records) >= b.opts.MaxSize || !time.Now().Before(b.deadline())
+}
+
+// deadline returns when the oldest buffered record has waited long enough.
+func (b *batch) deadline() time.Time {
+	return b.since.Add(b.opts.MaxWait)
+}
+
+// RegisterBatchHandler registers a handler receiving the messages of the topic in batches of up to
+// opts.MaxSize messages, or fewer once the first message has waited opts.MaxWait.
+//
+// A batch is retried like a single message. Once its attempts are exhausted, its records are
+// republished to the dead-letter topic if enabled, retry topics are not used for batches. Otherwise
+// the partitions of the buffered records are rewound. Offsets are committed only after the batch is done.
+// Middlewares installed with Use only wrap single message handlers.
+func (c *KafkaConsumer) RegisterBatchHandler(topic string, handler BatchHandlerFunc, opts BatchOptions) error {
+	if opts.MaxSize <= 0 || opts.MaxWait <= 0 {
+		return fmt.Errorf("batch handler for topic %s: max size and max wait must be positive", topic)
+	}
+
+	if _, exists := c.router.Handler(topic); exists {
+		return fmt.Errorf("handler for topic %s already registered", topic)
+	}
+
+	c.batchMu.Lock()
+	defer c.batchMu.Unlock()
+
+	if _, exists := c.batches[topic]; exists {
+		return fmt.Errorf("handler for topic %s already registered", topic)
+	}
+	c.batches[topic] = &batch{fn: handler, opts: opts}
+
+	c.subscribe(topic)
+	return nil
+}
+
+// isBatchTopic returns whether the topic has a batch handler.
+func (c *KafkaConsumer) isBatchTopic(topic string) bool {
+	c.batchMu.Lock()
+	defer c.batchMu.Unlock()
+
+	_, exists := c.batches[topic]
+	return exists
+}
+
+// bufferBatch buffers fetched records of a topic with a batch handler.
+func (c *KafkaConsumer) bufferBatch(topic string, records []*kgo.Record) {
+	c.batchMu.Lock()
+	defer c.batchMu.Unlock()
+
+	b := c.batches[topic]
+	if len(b.records) == 0 {
+		b.since = time.Now()
+	}
+	b.records = append(b.records, records...)
+}
+
+// flushBatched hands the buffered records of the partitions to their handlers right away, as the
+// partitions are about to be revoked, and marks them for commit. The records of a failed batch are
+// dropped, they are fetched again by the member the partitions are assigned to.
+func (c *KafkaConsumer) flushBatched(ctx context.Context, partitions map[string][]int32) {
+	c.batchMu.Lock()
+	defer c.batchMu.Unlock()
+
+	for topic, b := range c.batches {
+		revoked, ok := partitions[topic]
+		if !ok {
+			continue
+		}
+
+		var flush, kept []*kgo.Record
+		for _, rec := range b.records {
+			if slices.Contains(revoked, rec.Partition) {
+				flush = append(flush, rec)
+			} else {
+				kept = append(kept, rec)
+			}
+		}
+		b.records = kept
+
+		for len(flush) > 0 && ctx.Err() == nil {
+			records := flush[:min(len(flush), b.opts.MaxSize)]
+			if !c.processBatch(ctx, topic, b.fn, records) {
+				break
+			}
+
+			c.client().MarkCommitRecords(records...)
+			flush = flush[len(records):]
+		}
+	}
+}
+
+// dropBatched drops the buffered records of the partitions, which are no longer consumed.
+// They are not committed, so they are fetched again by the member the partitions are assigned to.
+func (c *KafkaConsumer) dropBatched(partitions map[string][]int32) {
+	c.batchMu.Lock()
+	defer c.batchMu.Unlock()
+
+	for topic, b := range c.batches {
+		revoked, ok := partitions[topic]
+		if !ok {
+			continue
+		}
+
+		kept := b.records[:0]
+		for _, rec := range b.records {
+			if !slices.Contains(revoked, rec.Partition) {
+				kept = append(kept, rec)
+			}
+		}
+		b.records = kept
+	}
+}
+
+// clearBatches drops all buffered records, they are not committed.
+func (c *KafkaConsumer) clearBatches() {
+	c.batchMu.Lock()
+	defer c.batchMu.Unlock()
+
+	for _, b := range c.batches {
+		b.records = nil
+	}
+}
+
+// nextBatchDeadline returns when the earliest buffered batch is due because of its max wait,
+// false if nothing is buffered.
+func (c *KafkaConsumer) nextBatchDeadline() (time.Time, bool) {
+	c.batchMu.Lock()
+	defer c.batchMu.Unlock()
+
+	var deadline time.Time
+	for _, b := range c.batches {
+		if len(b.records) == 0 {
+			continue
+		}
+		if d := b.deadline(); deadline.IsZero() || d.Before(deadline) {
+			deadline = d
+		}
+	}
+
+	return deadline, !deadline.IsZero()
+}
+
+// handleBatches hands the batches that are full or have waited long enough, or all buffered records
+// if force is set, to their handlers and marks their records for commit.
+func (c *KafkaConsumer) handleBatches(ctx context.Context, force bool) {
+	c.batchMu.Lock()
+	defer c.batchMu.Unlock()
+
+	for topic, b := range c.batches {
+		for (b.due() || force && len(b.records) > 0) && ctx.Err() == nil {
+			records := b.records[:min(len(b.records), b.opts.MaxSize)]
+			if !c.processBatch(ctx, topic, b.fn, records) {
+				if ctx.Err() == nil {
+					// the buffered records after the batch are dropped as well, so the partitions
+					// are rewound to their first buffered record
+					c.rewind(ctx, firstPerPartition(b.records)...)
+				}
+				b.records = nil
+				break
+			}
+
+			c.client().MarkCommitRecords(records...)
+			b.records = b.records[len(records):]
+		}
+	}
+}
+
+// processBatch handles the batch, republishing its records to the dead-letter topic if it fails.
+// It returns whether the batch is done with.
+func (c *KafkaConsumer) processBatch(ctx context.Context, topic string, fn BatchHandlerFunc, records []*kgo.Record) bool {
+	msgs := make([]Message, len(records))
+	for i, rec := range records {
+		msgs[i] = messageFromRecord(topic, rec)
+	}
+
+	err := withRetries(ctx, c.retryPolicy(), func() error {
+		return callBatchHandler(ctx, fn, msgs)
+	}, func(attempt uint32, err error) {
+		c.logger.WarnContext(ctx, "error handling message batch",
+			slog.String("topic", topic),
+			slog.Int("size", len(msgs)),
+			slog.Int("attempt", int(attempt)),
+			slog.Any("error", err),
+		)
+	})
+	if err == nil {
+		return true
+	}
+
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if c.cfg.ConsumerDeadLetter {
+		retryRecords := make([]*kgo.Record, len(records))
+		for i, rec := range records {
+			retryRecords[i] = buildRetryRecord(rec, DeadLetterTopic(topic), 1, err)
+		}
+
+		if err := c.client().ProduceSync(ctx, retryRecords...).FirstErr(); err != nil {
+			c.logger.ErrorContext(ctx, "error republishing message batch",
+				slog.String("topic", DeadLetterTopic(topic)),
+				slog.Any("error", err),
+			)
+		} else {
+			c.logger.WarnContext(ctx, "republished failed message batch",
+				slog.String("topic", topic),
+				slog.Int("size", len(records)),
+				slog.String("to", DeadLetterTopic(topic)),
+			)
+			return true
+		}
+	}
+
+	c.logger.ErrorContext(ctx, "giving up handling message batch, rewinding partitions",
+		slog.String("topic", topic),
+		slog.Int("size", len(records)),
+		slog.Any("error", err),
+	)
+	return false
+}
+
+// callBatchHandler calls the handler, turning a panic into an error since batches are not wrapped
+// by middlewares.
+func callBatchHandler(ctx context.Context, fn BatchHandlerFunc, msgs []Message) (err error) {
+	defer func() {
+		if rvr := recover(); rvr != nil {
+			err = fmt.Errorf("panic: %v", rvr)
+		}
+	}()
+
+	return fn(ctx, msgs)
+}
+
+// firstPerPartition returns the first record of each partition in records.
+func firstPerPartition(records []*kgo.Record) []*kgo.Record {
+	type partition struct {
+		topic string
+		id    int32
+	}
+
+	seen := make(map[partition]struct{})
+	var first []*kgo.Record
+	for _, rec := range records {
+		p := partition{rec.Topic, rec.Partition}
+		if _, ok := seen[p]; ok {
+			continue
+		}
+		seen[p] = struct{}{}
+		first = append(first, rec)
+	}
+
+	return first
+}
@@ -0,0 +1,193 @@
+package mq_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+	"github.com/tuanvumaihuynh/outbox-pattern/test/kafkatest"
+)
+
+// batches collects the batches handlers received, safe for concurrent use.
+type batches struct {
+	mu  sync.Mutex
+	all [][]string
+}
+
+func (b *batches) add(msgs []mq.Message) {
+	payloads := make([]string, len(msgs))
+	for i, msg := range msgs {
+		payloads[i] = string(msg.Payload)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.all = append(b.all, payloads)
+}
+
+func (b *batches) get() [][]string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([][]string(nil), b.all...)
+}
+
+func runBatchConsumer(t *testing.T, cfg config.Kafka, topic string, fn mq.BatchHandlerFunc, opts mq.BatchOptions) mq.CleanupFunc {
+	t.Helper()
+
+	c, err := mq.NewKafkaConsumer(context.Background(), cfg, discardLogger)
+	require.NoError(t, err)
+
+	require.NoError(t, c.RegisterBatchHandler(topic, fn, opts))
+
+	cleanup, err := c.Run(context.Background())
+	require.NoError(t, err)
+
+	return cleanup
+}
+
+func produceN(t *testing.T, cfg config.Kafka, topic string, n int) {
+	t.Helper()
+
+	records := make([]*kgo.Record, n)
+	for i := range records {
+		records[i] = &kgo.Record{Topic: topic, Value: []byte(strconv.Itoa(i + 1))}
+	}
+	kafkatest.Produce(t, cfg, records...)
+}
+
+func TestKafkaConsumerBatch(t *testing.T) {
+	t.Run("Should hand full batches to the handler", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(1, "product.created"))
+
+		var got batches
+		cleanup := runBatchConsumer(t, cfg, "product.created", func(_ context.Context, msgs []mq.Message) error {
+			got.add(msgs)
+			return nil
+		}, mq.BatchOptions{MaxSize: 3, MaxWait: time.Minute})
+		defer cleanup()
+
+		produceN(t, cfg, "product.created", 6)
+
+		require.Eventually(t, func() bool { return len(got.get()) == 2 }, 10*time.Second, 10*time.Millisecond)
+		assert.Equal(t, [][]string{{"1", "2", "3"}, {"4", "5", "6"}}, got.get())
+	})
+
+	t.Run("Should hand a partial batch once the max wait elapsed", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(1, "product.created"))
+
+		var got batches
+		cleanup := runBatchConsumer(t, cfg, "product.created", func(_ context.Context, msgs []mq.Message) error {
+			got.add(msgs)
+			return nil
+		}, mq.BatchOptions{MaxSize: 10, MaxWait: 100 * time.Millisecond})
+		defer cleanup()
+
+		produceN(t, cfg, "product.created", 2)
+
+		require.Eventually(t, func() bool { return len(got.get()) == 1 }, 10*time.Second, 10*time.Millisecond)
+		assert.Equal(t, [][]string{{"1", "2"}}, got.get())
+	})
+
+	t.Run("Should redeliver a failed batch and commit it only once handled", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(1, "product.created"))
+		cfg.ConsumerMaxAttempts = 1
+
+		var calls atomic.Int32
+		var got batches
+		cleanup := runBatchConsumer(t, cfg, "product.created", func(_ context.Context, msgs []mq.Message) error {
+			if calls.Add(1) == 1 {
+				return errors.New("store unavailable")
+			}
+			got.add(msgs)
+			return nil
+		}, mq.BatchOptions{MaxSize: 2, MaxWait: time.Minute})
+
+		produceN(t, cfg, "product.created", 2)
+
+		require.Eventually(t, func() bool { return len(got.get()) == 1 }, 10*time.Second, 10*time.Millisecond)
+		assert.Equal(t, [][]string{{"1", "2"}}, got.get())
+		cleanup()
+
+		// a new member of the group resumes after the handled batch
+		var next batches
+		cleanup = runBatchConsumer(t, cfg, "product.created", func(_ context.Context, msgs []mq.Message) error {
+			next.add(msgs)
+			return nil
+		}, mq.BatchOptions{MaxSize: 1, MaxWait: time.Minute})
+		defer cleanup()
+
+		kafkatest.Produce(t, cfg, &kgo.Record{Topic: "product.created", Value: []byte(`3`)})
+
+		require.Eventually(t, func() bool { return len(next.get()) == 1 }, 10*time.Second, 10*time.Millisecond)
+		assert.Equal(t, [][]string{{"3"}}, next.get())
+	})
+
+	t.Run("Should republish a failed batch to the dead-letter topic", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(1, "product.created"))
+		cfg.ConsumerMaxAttempts = 2
+		cfg.ConsumerDeadLetter = true
+
+		var calls atomic.Int32
+		cleanup := runBatchConsumer(t, cfg, "product.created", func(context.Context, []mq.Message) error {
+			calls.Add(1)
+			return errors.New("invalid batch")
+		}, mq.BatchOptions{MaxSize: 2, MaxWait: time.Minute})
+		defer cleanup()
+
+		produceN(t, cfg, "product.created", 2)
+
+		records := kafkatest.Consume(t, cfg, mq.DeadLetterTopic("product.created"), 2, 10*time.Second)
+		var payloads []string
+		for _, rec := range records {
+			payloads = append(payloads, string(rec.Value))
+			for _, h := range rec.Headers {
+				if h.Key == mq.HeaderError {
+					assert.Contains(t, string(h.Value), "invalid batch")
+				}
+			}
+		}
+		assert.Equal(t, []string{"1", "2"}, payloads)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("Should reject a batch handler for a topic that already has a handler", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t)
+
+		c, err := mq.NewKafkaConsumer(context.Background(), cfg, discardLogger)
+		require.NoError(t, err)
+		defer c.Close()
+
+		require.NoError(t, c.RegisterHandler("product.created", func(context.Context, mq.Message) error { return nil }))
+		assert.Error(t, c.RegisterBatchHandler("product.created", func(context.Context, []mq.Message) error { return nil },
+			mq.BatchOptions{MaxSize: 1, MaxWait: time.Second}))
+	})
+
+	t.Run("Should hand buffered records to the handler on shutdown", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(1, "product.created"))
+		cfg.ConsumerDrainTimeout = 10 * time.Second
+
+		var got batches
+		cleanup := runBatchConsumer(t, cfg, "product.created", func(_ context.Context, msgs []mq.Message) error {
+			got.add(msgs)
+			return nil
+		}, mq.BatchOptions{MaxSize: 10, MaxWait: time.Minute})
+
+		produceN(t, cfg, "product.created", 2)
+		// let the records be fetched and buffered
+		time.Sleep(500 * time.Millisecond)
+		cleanup()
+
+		assert.Equal(t, [][]string{{"1", "2"}}, got.get())
+	})
+}
@@ -0,0 +1,17 @@
+package mq
+
+import "encoding/json"
+
+// Codec decodes message payloads.
+type Codec interface {
+	Unmarshal(data []byte, v any) error
+}
+
+var _ Codec = JSONCodec{}
+
+// JSONCodec decodes JSON payloads.
+type JSONCodec struct{}
+
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
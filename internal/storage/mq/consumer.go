@@ -2,46 +2,138 @@ package mq
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"log/slog"
-	"runtime/debug"
+	"regexp"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/twmb/franz-go/pkg/kgo"
-	"github.com/twmb/franz-go/plugin/kotel"
-	"go.opentelemetry.io/otel/codes"
-	"go.opentelemetry.io/otel/trace"
 
 	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
-	"github.com/tuanvumaihuynh/outbox-pattern/pkg/outbox"
 )
 
-type HandlerFunc func(ctx context.Context, topic string, payload []byte) error
+type HandlerFunc func(ctx context.Context, msg Message) error
 
 type CleanupFunc func()
 
 type Consumer interface {
+	// Use appends middlewares wrapping every registered handler.
+	Use(middlewares ...Middleware)
 	RegisterHandler(topic string, handler HandlerFunc) error
+	// RegisterEventHandler registers a handler for the messages of the topic with the event type,
+	// so several event types can share a topic, see Router.RegisterEventType.
+	RegisterEventHandler(topic, eventType string, handler HandlerFunc) error
+	// Pause stops handling messages of the topic until it is resumed, e.g. while a dependency
+	// of its handler is down. Messages being handled are not interrupted.
+	Pause(topic string)
+	// Resume handles messages of a paused topic again, from where it was paused.
+	Resume(topic string)
 	Run(ctx context.Context) (CleanupFunc, error)
 }
 
+// PartitionsFunc is called with the partitions of each topic assigned to or revoked from a consumer.
+type PartitionsFunc func(ctx context.Context, partitions map[string][]int32)
+
 var _ Consumer = (*KafkaConsumer)(nil)
 
+const (
+	defaultFetchMaxWait = 5 * time.Second
+	minFetchMaxWait     = 10 * time.Millisecond
+	// regexMetadataMaxAge bounds how long new topics matching a pattern take to be consumed
+	regexMetadataMaxAge = 30 * time.Second
+	// defaultKeyWorkers is how many workers handle the keys of a partition when not configured
+	defaultKeyWorkers = 16
+)
+
+// KafkaConsumer consumes records with at-least-once semantics.
+//
+// A failing record is retried in-process with exponential backoff. Once the attempts are exhausted,
+// it is republished to the next retry topic, handled again there after a delay, and finally
+// republished to the dead-letter topic. Without retry or dead-letter topics configured, its partition
+// is rewound to it instead, so it and the records after it are fetched again. A record failing with
+// a NonRetryable error goes straight to the dead-letter topic, or is skipped without one.
+//
+// Records are handled by the poll loop, one poll after another. With partition or key concurrency they
+// are instead handed to a long-lived worker per assigned partition, and handlers must be safe for
+// concurrent use. A partition is not fetched while its worker is busy with its records, so a slow
+// partition does not hold up the others. Only the offsets of handled or republished records are
+// committed, periodically, after each poll and when partitions are revoked.
+//
+// Topics can instead have a batch handler, see RegisterBatchHandler.
+//
+// Stopping the consumer stops polling, lets the records being handled and the buffered batches finish
+// within the drain timeout, commits and leaves the group. Partitions revoked by a rebalance are
+// likewise flushed and committed before they are given up.
+//
+// Tracing and correlation IDs are left to the middlewares installed with Use. A panicking handler
+// fails its record like an error, middleware.Recoverer logs and traces the panic.
 type KafkaConsumer struct {
-	cl       *kgo.Client
-	kTracer  *kotel.Tracer
-	handlers map[string]HandlerFunc
-	logger   *slog.Logger
+	cfg    config.Kafka
+	router *Router
+	logger *slog.Logger
+
+	clMu sync.RWMutex
+	// cl is replaced by Run with regex consumption, it is read with client
+	cl *kgo.Client
+
+	mu sync.RWMutex
+	// opts and regexTopics create the client consuming with regexes in Run, as franz-go only takes
+	// regexes when the client is created
+	opts        []kgo.Opt
+	regexTopics []string
+
+	batchMu sync.Mutex
+	batches map[string]*batch
+
+	workersMu sync.Mutex
+	workers   map[topicPartition]*partitionWorker
+	// handleCtx is the context records are handled with, set by Run
+	handleCtx context.Context
+
+	onAssigned PartitionsFunc
+	onRevoked  PartitionsFunc
 }
 
 func NewKafkaConsumer(ctx context.Context, cfg config.Kafka, logger *slog.Logger) (*KafkaConsumer, error) {
-	cl, err := kgo.NewClient(
-		kgo.SeedBrokers(cfg.Addresses...),
-		kgo.ConsumerGroup(cfg.Group),
-		kgo.AllowAutoTopicCreation(),
-		kgo.WithContext(ctx),
-		kgo.WithHooks(kTracer),
+	c := &KafkaConsumer{
+		cfg:    cfg,
+		router: NewRouter(),
+		logger: logger,
+
+		batches: make(map[string]*batch),
+		workers: make(map[topicPartition]*partitionWorker),
+	}
+
+	opts, err := clientOpts(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	groupOpts, err := consumerOpts(cfg)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, groupOpts...)
+	opts = append(opts,
+		kgo.OnPartitionsAssigned(c.partitionsAssigned),
+		kgo.OnPartitionsRevoked(c.partitionsRevoked),
+		kgo.OnPartitionsLost(c.partitionsLost),
 	)
+	if len(cfg.ConsumerRetryTopicDelays) > 0 {
+		// a delayed partition is resumed on the next fetch, which must not wait much longer than the delay
+		opts = append(opts, kgo.FetchMaxWait(max(min(slices.Min(cfg.ConsumerRetryTopicDelays), defaultFetchMaxWait), minFetchMaxWait)))
+	}
+
+	if cfg.ConsumerRegex {
+		opts = append(opts, kgo.ConsumeRegex(), kgo.MetadataMaxAge(regexMetadataMaxAge))
+	}
+
+	c.opts = opts
+
+	cl, err := kgo.NewClient(opts...)
 	if err != nil {
 		return nil, fmt.Errorf("create kafka client: %w", err)
 	}
@@ -53,111 +145,682 @@ func NewKafkaConsumer(ctx context.Context, cfg config.Kafka, logger *slog.Logger
 		return nil, fmt.Errorf("ping kafka: %w", err)
 	}
 
-	return &KafkaConsumer{
-		cl:       cl,
-		kTracer:  kTracer,
-		handlers: make(map[string]HandlerFunc),
-		logger:   logger,
-	}, nil
+	c.cl = cl
+	return c, nil
+}
+
+func (c *KafkaConsumer) Use(middlewares ...Middleware) {
+	c.router.Use(middlewares...)
+}
+
+// OnPartitionsAssigned sets a function called when partitions are assigned to the consumer,
+// before their records are fetched. It must be set before Run.
+func (c *KafkaConsumer) OnPartitionsAssigned(fn PartitionsFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.onAssigned = fn
+}
+
+// OnPartitionsRevoked sets a function called when partitions are revoked from or lost by the consumer,
+// after the work done on them is committed. It must be set before Run.
+func (c *KafkaConsumer) OnPartitionsRevoked(fn PartitionsFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.onRevoked = fn
+}
+
+// client returns the client records are consumed with.
+func (c *KafkaConsumer) client() *kgo.Client {
+	c.clMu.RLock()
+	defer c.clMu.RUnlock()
+
+	return c.cl
+}
+
+func (c *KafkaConsumer) Pause(topic string) {
+	// the lock is held so that a client replacing this one keeps the topic paused
+	c.clMu.RLock()
+	defer c.clMu.RUnlock()
+
+	c.cl.PauseFetchTopics(c.topicsOf(topic)...)
+}
+
+func (c *KafkaConsumer) Resume(topic string) {
+	c.clMu.RLock()
+	defer c.clMu.RUnlock()
+
+	c.cl.ResumeFetchTopics(c.topicsOf(topic)...)
 }
 
 func (c *KafkaConsumer) RegisterHandler(topic string, handler HandlerFunc) error {
-	if _, exists := c.handlers[topic]; exists {
+	if c.isBatchTopic(topic) {
+		return fmt.Errorf("handler for topic %s already registered", topic)
+	}
+
+	subscribed := c.router.Registered(topic)
+	if err := c.router.Register(topic, handler); err != nil {
+		return err
+	}
+
+	if !subscribed {
+		c.subscribe(c.topicsOf(topic)...)
+	}
+	return nil
+}
+
+func (c *KafkaConsumer) RegisterEventHandler(topic, eventType string, handler HandlerFunc) error {
+	if c.isBatchTopic(topic) {
 		return fmt.Errorf("handler for topic %s already registered", topic)
 	}
 
-	c.cl.AddConsumeTopics(topic)
-	c.handlers[topic] = handler
+	subscribed := c.router.Registered(topic)
+	if err := c.router.RegisterEventType(topic, eventType, handler); err != nil {
+		return err
+	}
+
+	if !subscribed {
+		c.subscribe(c.topicsOf(topic)...)
+	}
+	return nil
+}
+
+// subscribe consumes the topics, which are exact names even with regex consumption.
+func (c *KafkaConsumer) subscribe(topics ...string) {
+	if !c.cfg.ConsumerRegex {
+		c.client().AddConsumeTopics(topics...)
+		return
+	}
+
+	patterns := make([]string, len(topics))
+	for i, topic := range topics {
+		patterns[i] = anchorPattern(regexp.QuoteMeta(topic))
+	}
+	c.subscribeRegex(patterns...)
+}
+
+// subscribeRegex consumes the topics matching the regexes once the consumer runs.
+func (c *KafkaConsumer) subscribeRegex(patterns ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.regexTopics = append(c.regexTopics, patterns...)
+}
+
+// newRegexClient replaces the client with one consuming the subscribed regexes, keeping the paused topics.
+func (c *KafkaConsumer) newRegexClient() error {
+	c.mu.RLock()
+	opts := append(slices.Clone(c.opts), kgo.ConsumeTopics(c.regexTopics...))
+	c.mu.RUnlock()
+
+	cl, err := kgo.NewClient(opts...)
+	if err != nil {
+		return fmt.Errorf("create kafka client: %w", err)
+	}
+
+	c.clMu.Lock()
+	old := c.cl
+	cl.PauseFetchTopics(old.PauseFetchTopics()...)
+	c.cl = cl
+	c.clMu.Unlock()
+
+	old.Close()
 	return nil
 }
 
+// RegisterPatternHandler registers the handler for the topics fully matching the regular expression,
+// e.g. `product\..*`, along with their retry topics. It requires regex consumption to be enabled,
+// and with it every handler must be registered before Run.
+//
+// A record is routed to the handler of its exact topic, then to the most specific matching pattern,
+// then to the fallback handler. Records of dead-letter topics are only handled by exact handlers.
+func (c *KafkaConsumer) RegisterPatternHandler(pattern string, handler HandlerFunc) error {
+	if !c.cfg.ConsumerRegex {
+		return fmt.Errorf("handler for topic pattern %s: regex consumption is not enabled", pattern)
+	}
+
+	if err := c.router.RegisterPattern(pattern, handler); err != nil {
+		return err
+	}
+
+	patterns := []string{anchorPattern(pattern)}
+	if len(c.cfg.ConsumerRetryTopicDelays) > 0 {
+		patterns = append(patterns, anchorPattern(`(?:`+pattern+`)\.retry\.[0-9]+`))
+	}
+
+	c.subscribeRegex(patterns...)
+	return nil
+}
+
+// SetFallbackHandler sets the handler for the records no other handler is routed to, which are
+// otherwise logged and skipped.
+func (c *KafkaConsumer) SetFallbackHandler(handler HandlerFunc) {
+	c.router.SetFallback(handler)
+}
+
 func (c *KafkaConsumer) Run(ctx context.Context) (CleanupFunc, error) {
-	ctx, cancel := context.WithCancel(ctx)
+	if c.cfg.ConsumerRegex {
+		if err := c.newRegexClient(); err != nil {
+			return nil, err
+		}
+	}
+
+	// stopping the consumer stops polling first, the records being handled are cancelled
+	// only if they are not done within the drain timeout
+	ctx, cancelHandling := context.WithCancel(ctx)
+	pollCtx, stopPolling := context.WithCancel(ctx)
 	doneChan := make(chan struct{})
-	defer close(doneChan)
+
+	c.workersMu.Lock()
+	c.handleCtx = ctx
+	c.workersMu.Unlock()
 
 	go func() {
+		defer close(doneChan)
+
 		for {
-			select {
-			case <-ctx.Done():
-				return
-			default:
-				fetches := c.cl.PollFetches(ctx)
-				if errs := fetches.Errors(); len(errs) > 0 {
-					if errs[0].Err == context.Canceled {
-						// context cancelled, likely due to shutdown
-						continue
-					}
-
-					c.logger.ErrorContext(ctx, "error fetching messages",
-						slog.Any("error", errs),
-					)
-					continue
-				}
+			fetches := c.pollFetches(pollCtx)
+			if pollCtx.Err() != nil {
+				// context cancelled, likely due to shutdown
+				break
+			}
 
-				fetches.EachRecord(func(rec *kgo.Record) {
-					defer func() {
-						if rvr := recover(); rvr != nil {
-							span := trace.SpanFromContext(ctx)
-							span.RecordError(fmt.Errorf("panic: %v", rvr))
-							span.SetStatus(codes.Error, "panic in handler")
-
-							c.logger.ErrorContext(ctx, "panic in message handler",
-								slog.String("topic", rec.Topic),
-								slog.Any("recover", rvr),
-								slog.String("stack", string(debug.Stack())),
-							)
-						}
-					}()
-
-					ctx, span := c.kTracer.WithProcessSpan(rec)
-					defer span.End()
-
-					// inject correlation ID from record headers into context
-					ctx = outbox.InjectCorrelationIDFromRecord(ctx, rec)
-
-					fn, exists := c.handlers[rec.Topic]
-					if !exists {
-						span.RecordError(fmt.Errorf("no handler for topic %s", rec.Topic))
-						span.SetStatus(codes.Error, "no handler registered for topic")
-						c.logger.ErrorContext(ctx, "no handler registered for topic",
-							slog.String("topic", rec.Topic),
-						)
-						return
-					}
-
-					if err := fn(ctx, rec.Topic, rec.Value); err != nil {
-						span.RecordError(err)
-						span.SetStatus(codes.Error, "error in consumer handler")
-						c.logger.ErrorContext(ctx, "error handling message",
-							slog.String("topic", rec.Topic),
-							slog.String("key", string(rec.Key)),
-							slog.Any("error", err),
-						)
-						return
-					}
-
-					span.SetStatus(codes.Ok, "")
-				})
-
-				if err := c.cl.CommitUncommittedOffsets(ctx); err != nil {
-					c.logger.ErrorContext(ctx, "error committing offsets",
-						slog.Any("error", err),
-					)
-				}
+			if errs := fetches.Errors(); len(errs) > 0 {
+				// partitions with an error have no records, the others are still handled
+				c.logger.ErrorContext(ctx, "error fetching messages",
+					slog.Any("error", errs),
+				)
+			}
+
+			c.handleFetches(ctx, fetches)
+			c.handleBatches(ctx, false)
+
+			if err := c.client().CommitMarkedOffsets(ctx); err != nil && ctx.Err() == nil {
+				c.logger.ErrorContext(ctx, "error committing offsets",
+					slog.Any("error", err),
+				)
 			}
+
+			c.client().AllowRebalance()
 		}
+
+		// the records handed to workers and the buffered batches are handled before leaving the group,
+		// what is left once the handlers are cancelled is fetched again by the next owner of the partitions
+		c.stopWorkers(nil, false)
+		c.handleBatches(ctx, true)
+		c.clearBatches()
 	}()
 
 	cleanup := func() {
-		cancel()
-		c.cl.Close()
+		stopPolling()
+
+		select {
+		case <-doneChan:
+		case <-time.After(c.cfg.ConsumerDrainTimeout):
+			c.logger.WarnContext(ctx, "consumer drain timed out, cancelling handlers")
+		}
+		cancelHandling()
 		<-doneChan
+
+		commitCtx, commitCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer commitCancel()
+		if err := c.client().CommitMarkedOffsets(commitCtx); err != nil {
+			c.logger.ErrorContext(commitCtx, "error committing offsets on shutdown",
+				slog.Any("error", err),
+			)
+		}
+
+		c.client().CloseAllowingRebalance()
 	}
 
 	return cleanup, nil
 }
 
 func (c *KafkaConsumer) Close() {
-	c.cl.Close()
+	c.client().CloseAllowingRebalance()
+}
+
+// pollFetches polls records, returning early without any once a buffered batch is due.
+func (c *KafkaConsumer) pollFetches(ctx context.Context) kgo.Fetches {
+	deadline, ok := c.nextBatchDeadline()
+	if !ok {
+		return c.client().PollFetches(ctx)
+	}
+
+	pollCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	fetches := c.client().PollFetches(pollCtx)
+	if errors.Is(fetches.Err0(), context.DeadlineExceeded) && ctx.Err() == nil {
+		// nothing was fetched before the deadline
+		return nil
+	}
+
+	return fetches
+}
+
+// handleFetches handles the records of a poll, or hands them to the workers of their partitions with
+// partition or key concurrency. Records of topics with a batch handler are buffered instead.
+func (c *KafkaConsumer) handleFetches(ctx context.Context, fetches kgo.Fetches) {
+	fetches.EachPartition(func(p kgo.FetchTopicPartition) {
+		switch {
+		case c.isBatchTopic(p.Topic):
+			c.bufferBatch(p.Topic, p.Records)
+		case c.concurrent():
+			c.dispatch(p)
+		default:
+			c.handlePartition(ctx, p.Records)
+		}
+	})
+}
+
+// handlePartition handles the records of a fetched partition and marks the ones done for commit,
+// only up to the first record that is not done so the committed offset never skips one.
+//
+// Records of a retry topic that are not due yet are left for later by delaying the partition,
+// handlePartition then returns true. Otherwise the partition is rewound to the first record that
+// is not done.
+func (c *KafkaConsumer) handlePartition(ctx context.Context, records []*kgo.Record) bool {
+	var pending *kgo.Record
+	for i, rec := range records {
+		if c.retryWait(rec) > 0 {
+			// records of a retry topic are ordered by due time, nothing after this one is due either
+			pending, records = rec, records[:i]
+			break
+		}
+	}
+
+	var done int
+	if c.cfg.ConsumerConcurrency == config.ConsumerConcurrencyKey {
+		done = c.handleByKey(ctx, records)
+	} else {
+		done = c.handleInOrder(ctx, records)
+	}
+
+	c.client().MarkCommitRecords(records[:done]...)
+	if ctx.Err() != nil {
+		return false
+	}
+
+	switch {
+	case done < len(records):
+		c.rewind(ctx, records[done])
+	case pending != nil:
+		return c.delayPartition(ctx, pending, c.retryWait(pending))
+	}
+
+	return false
+}
+
+// handleInOrder handles the records one after another until one is not done and returns how many are.
+func (c *KafkaConsumer) handleInOrder(ctx context.Context, records []*kgo.Record) int {
+	for i, rec := range records {
+		if !c.processRecord(ctx, rec) {
+			return i
+		}
+	}
+
+	return len(records)
+}
+
+// handleByKey handles the records in order within a key, spreading the keys across a bounded number
+// of workers by hash, and returns how many records are done from the first one without a gap. A record
+// that is not done only stops its own key, records of other keys after it are handled again once the
+// partition is rewound.
+func (c *KafkaConsumer) handleByKey(ctx context.Context, records []*kgo.Record) int {
+	workers := c.cfg.ConsumerKeyWorkers
+	if workers == 0 {
+		workers = defaultKeyWorkers
+	}
+	lanes := make([][]int, workers)
+	for i, rec := range records {
+		h := fnv.New32a()
+		h.Write(rec.Key)
+		lane := h.Sum32() % workers
+		lanes[lane] = append(lanes[lane], i)
+	}
+
+	done := make([]bool, len(records))
+	var wg sync.WaitGroup
+	for _, indexes := range lanes {
+		if len(indexes) == 0 {
+			continue
+		}
+
+		wg.Go(func() {
+			stopped := make(map[string]struct{})
+			for _, i := range indexes {
+				key := string(records[i].Key)
+				if _, ok := stopped[key]; ok {
+					continue
+				}
+
+				if !c.processRecord(ctx, records[i]) {
+					stopped[key] = struct{}{}
+					continue
+				}
+				done[i] = true
+			}
+		})
+	}
+	wg.Wait()
+
+	n := 0
+	for n < len(done) && done[n] {
+		n++
+	}
+
+	return n
+}
+
+// processRecord handles the record, republishing it to the next retry or dead-letter topic if it
+// fails. It returns whether the record is done with.
+func (c *KafkaConsumer) processRecord(ctx context.Context, rec *kgo.Record) bool {
+	err := c.handleRecord(ctx, rec)
+	if err == nil {
+		return true
+	}
+
+	if ctx.Err() != nil {
+		return false
+	}
+
+	topic, attempt, ok := c.nextTopic(rec.Topic, err)
+	if !ok && IsNonRetryable(err) {
+		c.logger.ErrorContext(ctx, "skipping message that cannot be handled",
+			slog.String("topic", rec.Topic),
+			slog.Int("partition", int(rec.Partition)),
+			slog.Int64("offset", rec.Offset),
+			slog.Any("error", err),
+		)
+		return true
+	}
+
+	if ok {
+		if err := c.client().ProduceSync(ctx, buildRetryRecord(rec, topic, attempt, err)).FirstErr(); err != nil {
+			c.logger.ErrorContext(ctx, "error republishing message",
+				slog.String("topic", topic),
+				slog.Any("error", err),
+			)
+		} else {
+			c.logger.WarnContext(ctx, "republished failed message",
+				slog.String("topic", rec.Topic),
+				slog.Int64("offset", rec.Offset),
+				slog.String("to", topic),
+				slog.Int("attempt", attempt),
+			)
+			return true
+		}
+	}
+
+	c.logger.ErrorContext(ctx, "giving up handling message, rewinding partition",
+		slog.String("topic", rec.Topic),
+		slog.Int("partition", int(rec.Partition)),
+		slog.Int64("offset", rec.Offset),
+		slog.Any("error", err),
+	)
+	return false
+}
+
+// rewind sets the partitions of the records back to them so they are fetched again,
+// there must be at most one record per partition.
+func (c *KafkaConsumer) rewind(ctx context.Context, recs ...*kgo.Record) bool {
+	// rewinding resets the client's view of what is committed, so the records handled
+	// before these ones must be committed first
+	if err := c.client().CommitMarkedOffsets(ctx); err != nil {
+		c.logger.ErrorContext(ctx, "error committing offsets",
+			slog.Any("error", err),
+		)
+		return false
+	}
+
+	offsets := make(map[string]map[int32]kgo.EpochOffset)
+	for _, rec := range recs {
+		if offsets[rec.Topic] == nil {
+			offsets[rec.Topic] = make(map[int32]kgo.EpochOffset)
+		}
+		offsets[rec.Topic][rec.Partition] = kgo.EpochOffset{Epoch: rec.LeaderEpoch, Offset: rec.Offset}
+	}
+
+	c.client().SetOffsets(offsets)
+	return true
+}
+
+// delayPartition stops fetching the partition of the record until the record is due, and returns
+// whether it did.
+func (c *KafkaConsumer) delayPartition(ctx context.Context, rec *kgo.Record, wait time.Duration) bool {
+	partitions := map[string][]int32{rec.Topic: {rec.Partition}}
+	c.client().PauseFetchPartitions(partitions)
+
+	if !c.rewind(ctx, rec) {
+		c.client().ResumeFetchPartitions(partitions)
+		return false
+	}
+
+	time.AfterFunc(wait, func() {
+		c.client().ResumeFetchPartitions(partitions)
+	})
+	return true
+}
+
+// partitionsAssigned is called by the client when partitions are assigned.
+func (c *KafkaConsumer) partitionsAssigned(ctx context.Context, _ *kgo.Client, assigned map[string][]int32) {
+	c.logger.InfoContext(ctx, "partitions assigned", slog.Any("partitions", assigned))
+
+	if c.concurrent() {
+		c.startWorkers(assigned)
+	}
+
+	c.mu.RLock()
+	fn := c.onAssigned
+	c.mu.RUnlock()
+
+	if fn != nil {
+		fn(ctx, assigned)
+	}
+}
+
+// partitionsRevoked is called by the client when partitions are revoked, in between polls as
+// rebalances are blocked while records of a poll are handled or handed to workers. The workers of
+// the partitions finish their records, the buffered batches are handled and everything done is
+// committed, so the next owner starts right after it.
+func (c *KafkaConsumer) partitionsRevoked(ctx context.Context, cl *kgo.Client, revoked map[string][]int32) {
+	c.logger.InfoContext(ctx, "partitions revoked", slog.Any("partitions", revoked))
+
+	c.stopWorkers(revoked, false)
+	c.flushBatched(ctx, revoked)
+	if err := cl.CommitMarkedOffsets(ctx); err != nil {
+		c.logger.ErrorContext(ctx, "error committing offsets on revoke",
+			slog.Any("error", err),
+		)
+	}
+
+	c.mu.RLock()
+	fn := c.onRevoked
+	c.mu.RUnlock()
+
+	if fn != nil {
+		fn(ctx, revoked)
+	}
+}
+
+// partitionsLost is called by the client when partitions are lost, they can no longer be committed.
+func (c *KafkaConsumer) partitionsLost(ctx context.Context, _ *kgo.Client, lost map[string][]int32) {
+	c.logger.WarnContext(ctx, "partitions lost", slog.Any("partitions", lost))
+
+	c.stopWorkers(lost, true)
+	c.dropBatched(lost)
+
+	c.mu.RLock()
+	fn := c.onRevoked
+	c.mu.RUnlock()
+
+	if fn != nil {
+		fn(ctx, lost)
+	}
+}
+
+// topicsOf returns the topic and its retry topics.
+func (c *KafkaConsumer) topicsOf(topic string) []string {
+	topics := []string{topic}
+	for n := 1; n <= len(c.cfg.ConsumerRetryTopicDelays); n++ {
+		topics = append(topics, RetryTopic(topic, n))
+	}
+
+	return topics
+}
+
+// origin returns the topic the handler of a topic is registered for, and which retry the topic is for,
+// zero if it is not a retry topic.
+func (c *KafkaConsumer) origin(topic string) (string, int) {
+	if origin, n, ok := parseRetryTopic(topic); ok && n <= len(c.cfg.ConsumerRetryTopicDelays) {
+		return origin, n
+	}
+	return topic, 0
+}
+
+// retryWait returns how long until a record of a retry topic is due.
+func (c *KafkaConsumer) retryWait(rec *kgo.Record) time.Duration {
+	_, n := c.origin(rec.Topic)
+	if n == 0 {
+		return 0
+	}
+
+	return time.Until(rec.Timestamp.Add(c.cfg.ConsumerRetryTopicDelays[n-1]))
+}
+
+// nextTopic returns the topic a record of topic that failed with err is republished to with its
+// attempt count, false if there is none. Non-retryable failures skip the retry topics.
+func (c *KafkaConsumer) nextTopic(topic string, err error) (string, int, bool) {
+	origin, n := c.origin(topic)
+	attempt := n + 1
+
+	switch {
+	case attempt <= len(c.cfg.ConsumerRetryTopicDelays) && !IsNonRetryable(err):
+		return RetryTopic(origin, attempt), attempt, true
+	case c.cfg.ConsumerDeadLetter:
+		return DeadLetterTopic(origin), attempt, true
+	default:
+		return "", 0, false
+	}
+}
+
+// handleRecord handles the record, retrying with exponential backoff until it succeeds
+// or the attempts are exhausted.
+func (c *KafkaConsumer) handleRecord(ctx context.Context, rec *kgo.Record) error {
+	if IsDeadLetterTopic(rec.Topic) && !c.router.Registered(rec.Topic) {
+		// matched by a pattern, dead-lettered records are not handled again
+		return nil
+	}
+
+	topic, _ := c.origin(rec.Topic)
+	fn, exists := c.router.Handler(topic)
+	if !exists {
+		// nothing can handle the record, retrying would block the partition forever
+		c.logger.ErrorContext(ctx, "no handler registered for topic",
+			slog.String("topic", rec.Topic),
+		)
+		return nil
+	}
+
+	msg := messageFromRecord(topic, rec)
+	return withRetries(ctx, c.retryPolicy(), func() error {
+		err := callHandler(ctx, fn, msg)
+		return skipUnhandledEvent(ctx, c.logger, c.cfg.ConsumerSkipUnhandledEvents, msg, err)
+	}, func(attempt uint32, err error) {
+		c.logger.WarnContext(ctx, "error handling message",
+			slog.String("topic", rec.Topic),
+			slog.String("key", string(rec.Key)),
+			slog.Int("attempt", int(attempt)),
+			slog.Any("error", err),
+		)
+	})
+}
+
+// callHandler calls the handler, turning a panic into an error as a last resort for the handlers
+// not wrapped by middleware.Recoverer.
+func callHandler(ctx context.Context, fn HandlerFunc, msg Message) (err error) {
+	defer func() {
+		if rvr := recover(); rvr != nil {
+			err = fmt.Errorf("panic: %v", rvr)
+		}
+	}()
+
+	return fn(ctx, msg)
+}
+
+// nonRetryableError is an error retrying cannot fix.
+type nonRetryableError struct {
+	err error
+}
+
+func (e nonRetryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e nonRetryableError) Unwrap() error {
+	return e.err
+}
+
+// NonRetryable marks err as failing the same way however many times the message is handled, e.g. a
+// payload that cannot be decoded. The consumers do not retry such messages, they dead-letter them if
+// enabled and skip them otherwise.
+func NonRetryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return nonRetryableError{err: err}
+}
+
+// IsNonRetryable returns whether err was marked with NonRetryable.
+func IsNonRetryable(err error) bool {
+	return errors.As(err, new(nonRetryableError))
+}
+
+// retryPolicy bounds how a failing handler is retried in-process.
+type retryPolicy struct {
+	maxAttempts uint32
+	backoff     time.Duration
+	maxBackoff  time.Duration
+}
+
+// retryPolicy returns the in-process retry policy of the consumer.
+func (c *KafkaConsumer) retryPolicy() retryPolicy {
+	return retryPolicy{
+		maxAttempts: c.cfg.ConsumerMaxAttempts,
+		backoff:     c.cfg.ConsumerRetryBackoff,
+		maxBackoff:  c.cfg.ConsumerMaxRetryBackoff,
+	}
+}
+
+// withRetries calls handle with exponential backoff until it succeeds, fails with a non-retryable error
+// or the attempts are exhausted, calling onFail after each failed attempt.
+func withRetries(ctx context.Context, policy retryPolicy, handle func() error, onFail func(attempt uint32, err error)) error {
+	maxAttempts := max(policy.maxAttempts, 1)
+	backoff := policy.backoff
+
+	var err error
+	for attempt := uint32(1); attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return errors.Join(err, ctx.Err())
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, policy.maxBackoff)
+		}
+
+		err = handle()
+		if err == nil {
+			return nil
+		}
+
+		onFail(attempt, err)
+		if IsNonRetryable(err) {
+			return err
+		}
+	}
+
+	return err
 }
@@ -0,0 +1,814 @@
+package mq_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq/middleware"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/correlationid"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/outbox"
+	"github.com/tuanvumaihuynh/outbox-pattern/test/kafkatest"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// countingCodec decodes JSON, counting the payloads it is given.
+type countingCodec struct {
+	count *atomic.Int32
+}
+
+func (c countingCodec) Unmarshal(data []byte, v any) error {
+	c.count.Add(1)
+	return mq.JSONCodec{}.Unmarshal(data, v)
+}
+
+// received collects what handlers received, safe for concurrent use.
+type received struct {
+	mu       sync.Mutex
+	payloads []string
+}
+
+func (r *received) add(payload string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.payloads = append(r.payloads, payload)
+}
+
+func (r *received) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.payloads...)
+}
+
+func (r *received) count() int {
+	return len(r.get())
+}
+
+func runConsumer(t *testing.T, cfg config.Kafka, topic string, fn mq.HandlerFunc) mq.CleanupFunc {
+	t.Helper()
+
+	c, err := mq.NewKafkaConsumer(context.Background(), cfg, discardLogger)
+	require.NoError(t, err)
+
+	require.NoError(t, c.RegisterHandler(topic, fn))
+
+	cleanup, err := c.Run(context.Background())
+	require.NoError(t, err)
+
+	return cleanup
+}
+
+func TestKafkaConsumer(t *testing.T) {
+	t.Run("Should handle records with their correlation id and outbox message id headers through middlewares", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(1, "product.created"))
+
+		var got received
+		var gotCorrelationID, gotMessageID string
+		cleanup := runConsumer(t, cfg, "product.created", mq.Chain(func(ctx context.Context, msg mq.Message) error {
+			gotCorrelationID, _ = correlationid.FromContext(ctx)
+			gotMessageID = msg.Headers[outbox.MessageIDHeader]
+			got.add(msg.Topic + ":" + string(msg.Payload))
+			return nil
+		}, middleware.CorrelationID()))
+		defer cleanup()
+
+		kafkatest.Produce(t, cfg, &kgo.Record{
+			Topic: "product.created",
+			Value: []byte(`{"product_id":"1"}`),
+			Headers: []kgo.RecordHeader{
+				{Key: correlationid.Header, Value: []byte("correlation-id")},
+				{Key: outbox.MessageIDHeader, Value: []byte("message-id")},
+			},
+		})
+
+		require.Eventually(t, func() bool { return got.count() == 1 }, 10*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{`product.created:{"product_id":"1"}`}, got.get())
+		assert.Equal(t, "correlation-id", gotCorrelationID)
+		assert.Equal(t, "message-id", gotMessageID)
+	})
+
+	t.Run("Should resume from committed offsets", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(1, "product.created"))
+
+		var first received
+		cleanup := runConsumer(t, cfg, "product.created", func(_ context.Context, msg mq.Message) error {
+			first.add(string(msg.Payload))
+			return nil
+		})
+
+		kafkatest.Produce(t, cfg,
+			&kgo.Record{Topic: "product.created", Value: []byte(`1`)},
+			&kgo.Record{Topic: "product.created", Value: []byte(`2`)},
+		)
+		require.Eventually(t, func() bool { return first.count() == 2 }, 10*time.Second, 10*time.Millisecond)
+		cleanup()
+
+		kafkatest.Produce(t, cfg, &kgo.Record{Topic: "product.created", Value: []byte(`3`)})
+
+		var second received
+		cleanup = runConsumer(t, cfg, "product.created", func(_ context.Context, msg mq.Message) error {
+			second.add(string(msg.Payload))
+			return nil
+		})
+		defer cleanup()
+
+		require.Eventually(t, func() bool { return second.count() == 1 }, 10*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{`3`}, second.get())
+	})
+
+	t.Run("Should share partitions between members of a group", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(4, "product.created"))
+
+		var got received
+		handler := func(_ context.Context, msg mq.Message) error {
+			got.add(string(msg.Payload))
+			return nil
+		}
+
+		cleanupA := runConsumer(t, cfg, "product.created", handler)
+		defer cleanupA()
+		cleanupB := runConsumer(t, cfg, "product.created", handler)
+		defer cleanupB()
+
+		const count = 40
+		records := make([]*kgo.Record, 0, count)
+		for i := range count {
+			records = append(records, &kgo.Record{
+				Topic: "product.created",
+				Key:   fmt.Appendf(nil, "product-%d", i%8),
+				Value: fmt.Appendf(nil, "%d", i),
+			})
+		}
+		kafkatest.Produce(t, cfg, records...)
+
+		// a rebalance may redeliver records, every record must be handled at least once
+		require.Eventually(t, func() bool {
+			seen := map[string]struct{}{}
+			for _, payload := range got.get() {
+				seen[payload] = struct{}{}
+			}
+			return len(seen) == count
+		}, 20*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Should retry a failing record before handling the next one", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(1, "product.created"))
+		cfg.ConsumerMaxAttempts = 3
+		cfg.ConsumerRetryBackoff = time.Millisecond
+
+		var attempts atomic.Int32
+		var got received
+		cleanup := runConsumer(t, cfg, "product.created", func(_ context.Context, msg mq.Message) error {
+			if string(msg.Payload) == `1` && attempts.Add(1) < 3 {
+				return errors.New("temporary failure")
+			}
+			got.add(string(msg.Payload))
+			return nil
+		})
+		defer cleanup()
+
+		kafkatest.Produce(t, cfg,
+			&kgo.Record{Topic: "product.created", Value: []byte(`1`)},
+			&kgo.Record{Topic: "product.created", Value: []byte(`2`)},
+		)
+
+		require.Eventually(t, func() bool { return got.count() == 2 }, 10*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{`1`, `2`}, got.get())
+		assert.Equal(t, int32(3), attempts.Load())
+	})
+
+	t.Run("Should redeliver a record after its attempts are exhausted", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(1, "product.created"))
+		cfg.ConsumerMaxAttempts = 2
+		cfg.ConsumerRetryBackoff = time.Millisecond
+
+		var attempts atomic.Int32
+		var got received
+		cleanup := runConsumer(t, cfg, "product.created", func(_ context.Context, msg mq.Message) error {
+			if string(msg.Payload) == `1` && attempts.Add(1) <= 4 {
+				return errors.New("temporary failure")
+			}
+			got.add(string(msg.Payload))
+			return nil
+		})
+		defer cleanup()
+
+		kafkatest.Produce(t, cfg,
+			&kgo.Record{Topic: "product.created", Value: []byte(`1`)},
+			&kgo.Record{Topic: "product.created", Value: []byte(`2`)},
+		)
+
+		require.Eventually(t, func() bool { return got.count() == 2 }, 10*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{`1`, `2`}, got.get())
+		assert.Equal(t, int32(5), attempts.Load())
+	})
+
+	t.Run("Should not commit past a record that was not handled", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(1, "product.created"))
+		cfg.ConsumerMaxAttempts = 1
+
+		var attempts atomic.Int32
+		var first received
+		cleanup := runConsumer(t, cfg, "product.created", func(_ context.Context, msg mq.Message) error {
+			if string(msg.Payload) == `2` {
+				attempts.Add(1)
+				return errors.New("permanent failure")
+			}
+			first.add(string(msg.Payload))
+			return nil
+		})
+
+		kafkatest.Produce(t, cfg,
+			&kgo.Record{Topic: "product.created", Value: []byte(`1`)},
+			&kgo.Record{Topic: "product.created", Value: []byte(`2`)},
+			&kgo.Record{Topic: "product.created", Value: []byte(`3`)},
+		)
+		require.Eventually(t, func() bool { return attempts.Load() >= 2 }, 10*time.Second, 10*time.Millisecond)
+		cleanup()
+		assert.Equal(t, []string{`1`}, first.get())
+
+		var second received
+		cleanup = runConsumer(t, cfg, "product.created", func(_ context.Context, msg mq.Message) error {
+			second.add(string(msg.Payload))
+			return nil
+		})
+		defer cleanup()
+
+		require.Eventually(t, func() bool { return second.count() == 2 }, 10*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{`2`, `3`}, second.get())
+	})
+
+	t.Run("Should treat a handler panic recovered by middleware as a failed attempt", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(1, "product.created"))
+		cfg.ConsumerMaxAttempts = 2
+
+		var attempts atomic.Int32
+		var got received
+		cleanup := runConsumer(t, cfg, "product.created", mq.Chain(func(_ context.Context, msg mq.Message) error {
+			if attempts.Add(1) == 1 {
+				panic("boom")
+			}
+			got.add(string(msg.Payload))
+			return nil
+		}, middleware.Recoverer(discardLogger)))
+		defer cleanup()
+
+		kafkatest.Produce(t, cfg, &kgo.Record{Topic: "product.created", Value: []byte(`1`)})
+
+		require.Eventually(t, func() bool { return got.count() == 1 }, 10*time.Second, 10*time.Millisecond)
+		assert.Equal(t, int32(2), attempts.Load())
+	})
+
+	t.Run("Should treat a handler panic without middleware as a failed attempt", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(1, "product.created"))
+		cfg.ConsumerMaxAttempts = 2
+
+		var attempts atomic.Int32
+		var got received
+		cleanup := runConsumer(t, cfg, "product.created", func(_ context.Context, msg mq.Message) error {
+			if attempts.Add(1) == 1 {
+				panic("boom")
+			}
+			got.add(string(msg.Payload))
+			return nil
+		})
+		defer cleanup()
+
+		kafkatest.Produce(t, cfg, &kgo.Record{Topic: "product.created", Value: []byte(`1`)})
+
+		require.Eventually(t, func() bool { return got.count() == 1 }, 10*time.Second, 10*time.Millisecond)
+		assert.Equal(t, int32(2), attempts.Load())
+	})
+
+	t.Run("Should republish a failing record to retry topics then the dead-letter topic", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(1, "product.created"))
+		cfg.ConsumerMaxAttempts = 1
+		cfg.ConsumerRetryTopicDelays = []time.Duration{50 * time.Millisecond, 100 * time.Millisecond}
+		cfg.ConsumerDeadLetter = true
+
+		var mu sync.Mutex
+		var attempts []time.Time
+		var got received
+		cleanup := runConsumer(t, cfg, "product.created", func(_ context.Context, msg mq.Message) error {
+			if string(msg.Payload) == `1` {
+				mu.Lock()
+				defer mu.Unlock()
+				attempts = append(attempts, time.Now())
+				return errors.New("permanent failure")
+			}
+			got.add(msg.Topic + ":" + string(msg.Payload))
+			return nil
+		})
+		defer cleanup()
+
+		kafkatest.Produce(t, cfg,
+			&kgo.Record{
+				Topic:   "product.created",
+				Key:     []byte("product-1"),
+				Value:   []byte(`1`),
+				Headers: []kgo.RecordHeader{{Key: correlationid.Header, Value: []byte("correlation-id")}},
+			},
+			&kgo.Record{Topic: "product.created", Value: []byte(`2`)},
+		)
+
+		// the next record is not blocked by the failing one
+		require.Eventually(t, func() bool { return got.count() == 1 }, 10*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{`product.created:2`}, got.get())
+
+		records := kafkatest.Consume(t, cfg, mq.DeadLetterTopic("product.created"), 1, 10*time.Second)
+		require.Len(t, records, 1)
+		assert.Equal(t, "product-1", string(records[0].Key))
+		assert.Equal(t, `1`, string(records[0].Value))
+
+		headers := map[string]string{}
+		for _, h := range records[0].Headers {
+			headers[h.Key] = string(h.Value)
+		}
+		assert.Equal(t, map[string]string{
+			correlationid.Header:       "correlation-id",
+			mq.HeaderOriginalTopic:     "product.created",
+			mq.HeaderOriginalPartition: "0",
+			mq.HeaderOriginalOffset:    "0",
+			mq.HeaderError:             "permanent failure",
+			mq.HeaderAttempt:           "3",
+		}, headers)
+
+		mu.Lock()
+		defer mu.Unlock()
+		require.Len(t, attempts, 3)
+		assert.GreaterOrEqual(t, attempts[1].Sub(attempts[0]), 50*time.Millisecond)
+		assert.GreaterOrEqual(t, attempts[2].Sub(attempts[1]), 100*time.Millisecond)
+	})
+
+	t.Run("Should dead-letter records with an unhandled event type unless they are skipped", func(t *testing.T) {
+		for _, skip := range []bool{false, true} {
+			_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(1, "product"))
+			cfg.ConsumerMaxAttempts = 1
+			cfg.ConsumerDeadLetter = true
+			cfg.ConsumerSkipUnhandledEvents = skip
+
+			c, err := mq.NewKafkaConsumer(context.Background(), cfg, discardLogger)
+			require.NoError(t, err)
+
+			var got received
+			require.NoError(t, c.RegisterEventHandler("product", "product.created", func(_ context.Context, msg mq.Message) error {
+				got.add(string(msg.Payload))
+				return nil
+			}))
+			require.NoError(t, c.RegisterHandler(mq.DeadLetterTopic("product"), func(_ context.Context, msg mq.Message) error {
+				got.add("dlq:" + string(msg.Payload))
+				return nil
+			}))
+
+			cleanup, err := c.Run(context.Background())
+			require.NoError(t, err)
+
+			kafkatest.Produce(t, cfg,
+				&kgo.Record{Topic: "product", Value: []byte(`{"type":"product.deleted"}`)},
+				&kgo.Record{Topic: "product", Value: []byte(`{"type":"product.created"}`)},
+			)
+
+			if skip {
+				require.Eventually(t, func() bool { return got.count() == 1 }, 10*time.Second, 10*time.Millisecond)
+				require.Never(t, func() bool { return got.count() > 1 }, 300*time.Millisecond, 10*time.Millisecond)
+				assert.Equal(t, []string{`{"type":"product.created"}`}, got.get())
+			} else {
+				require.Eventually(t, func() bool { return got.count() == 2 }, 10*time.Second, 10*time.Millisecond)
+				assert.ElementsMatch(t, []string{`dlq:{"type":"product.deleted"}`, `{"type":"product.created"}`}, got.get())
+			}
+			cleanup()
+		}
+	})
+
+	t.Run("Should dead-letter a non-retryable failure without retrying it", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(1, "product.created"))
+		cfg.ConsumerMaxAttempts = 3
+		cfg.ConsumerRetryBackoff = time.Millisecond
+		cfg.ConsumerRetryTopicDelays = []time.Duration{50 * time.Millisecond}
+		cfg.ConsumerDeadLetter = true
+
+		var attempts atomic.Int32
+		var got received
+		cleanup := runConsumer(t, cfg, "product.created", mq.Typed(func(_ context.Context, v map[string]any, _ mq.Metadata) error {
+			got.add(fmt.Sprint(v["id"]))
+			return nil
+		}, mq.WithCodec(countingCodec{&attempts})))
+		defer cleanup()
+
+		kafkatest.Produce(t, cfg,
+			&kgo.Record{Topic: "product.created", Value: []byte(`not json`)},
+			&kgo.Record{Topic: "product.created", Value: []byte(`{"id":2}`)},
+		)
+
+		require.Eventually(t, func() bool { return got.count() == 1 }, 10*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{`2`}, got.get())
+
+		records := kafkatest.Consume(t, cfg, mq.DeadLetterTopic("product.created"), 1, 10*time.Second)
+		require.Len(t, records, 1)
+		assert.Equal(t, `not json`, string(records[0].Value))
+		assert.Equal(t, int32(2), attempts.Load())
+	})
+
+	t.Run("Should skip a non-retryable failure without a dead-letter topic", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(1, "product.created"))
+		cfg.ConsumerMaxAttempts = 3
+		cfg.ConsumerRetryBackoff = time.Millisecond
+
+		var got received
+		cleanup := runConsumer(t, cfg, "product.created", mq.Typed(func(_ context.Context, v map[string]any, _ mq.Metadata) error {
+			got.add(fmt.Sprint(v["id"]))
+			return nil
+		}))
+
+		kafkatest.Produce(t, cfg,
+			&kgo.Record{Topic: "product.created", Value: []byte(`not json`)},
+			&kgo.Record{Topic: "product.created", Value: []byte(`{"id":2}`)},
+		)
+
+		require.Eventually(t, func() bool { return got.count() == 1 }, 10*time.Second, 10*time.Millisecond)
+		cleanup()
+
+		// the skipped record is committed along with the next one
+		var again received
+		cleanup = runConsumer(t, cfg, "product.created", func(_ context.Context, msg mq.Message) error {
+			again.add(string(msg.Payload))
+			return nil
+		})
+		defer cleanup()
+
+		kafkatest.Produce(t, cfg, &kgo.Record{Topic: "product.created", Value: []byte(`{"id":3}`)})
+		require.Eventually(t, func() bool { return again.count() == 1 }, 10*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{`{"id":3}`}, again.get())
+	})
+
+	t.Run("Should handle a retried record once it succeeds", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(1, "product.created"))
+		cfg.ConsumerMaxAttempts = 1
+		cfg.ConsumerRetryTopicDelays = []time.Duration{10 * time.Millisecond}
+		cfg.ConsumerDeadLetter = true
+
+		var attempts atomic.Int32
+		var got received
+		cleanup := runConsumer(t, cfg, "product.created", func(_ context.Context, msg mq.Message) error {
+			if attempts.Add(1) == 1 {
+				return errors.New("temporary failure")
+			}
+			got.add(msg.Topic + ":" + string(msg.Payload))
+			return nil
+		})
+		defer cleanup()
+
+		kafkatest.Produce(t, cfg, &kgo.Record{Topic: "product.created", Value: []byte(`1`)})
+
+		require.Eventually(t, func() bool { return got.count() == 1 }, 10*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{`product.created:1`}, got.get())
+	})
+
+	t.Run("Should handle partitions concurrently", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(2, "product.created"))
+		cfg.ConsumerConcurrency = config.ConsumerConcurrencyPartition
+
+		release := make(chan struct{})
+		var got received
+		cleanup := runConsumer(t, cfg, "product.created", func(_ context.Context, msg mq.Message) error {
+			if string(msg.Payload) == `blocked` {
+				<-release
+			}
+			got.add(string(msg.Payload))
+			return nil
+		})
+		defer cleanup()
+
+		cl := kafkatest.NewClient(t, cfg, kgo.RecordPartitioner(kgo.ManualPartitioner()))
+		require.NoError(t, cl.ProduceSync(context.Background(),
+			&kgo.Record{Topic: "product.created", Partition: 0, Value: []byte(`blocked`)},
+			&kgo.Record{Topic: "product.created", Partition: 1, Value: []byte(`1`)},
+		).FirstErr())
+
+		require.Eventually(t, func() bool { return got.count() == 1 }, 10*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{`1`}, got.get())
+
+		close(release)
+		require.Eventually(t, func() bool { return got.count() == 2 }, 10*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Should keep handling other partitions while one is slow", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(2, "product.created"))
+		cfg.ConsumerConcurrency = config.ConsumerConcurrencyPartition
+
+		release := make(chan struct{})
+		var got received
+		cleanup := runConsumer(t, cfg, "product.created", func(_ context.Context, msg mq.Message) error {
+			if string(msg.Payload) == `blocked` {
+				<-release
+			}
+			got.add(string(msg.Payload))
+			return nil
+		})
+		defer cleanup()
+
+		cl := kafkatest.NewClient(t, cfg, kgo.RecordPartitioner(kgo.ManualPartitioner()))
+		require.NoError(t, cl.ProduceSync(context.Background(),
+			&kgo.Record{Topic: "product.created", Partition: 0, Value: []byte(`blocked`)},
+			&kgo.Record{Topic: "product.created", Partition: 1, Value: []byte(`1`)},
+		).FirstErr())
+		require.Eventually(t, func() bool { return got.count() == 1 }, 10*time.Second, 10*time.Millisecond)
+
+		// fetched by a later poll, while partition 0 is still busy
+		require.NoError(t, cl.ProduceSync(context.Background(),
+			&kgo.Record{Topic: "product.created", Partition: 1, Value: []byte(`2`)},
+		).FirstErr())
+		require.Eventually(t, func() bool { return got.count() == 2 }, 10*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{`1`, `2`}, got.get())
+
+		close(release)
+		require.Eventually(t, func() bool { return got.count() == 3 }, 10*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Should handle keys concurrently preserving their order", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(1, "product.created"))
+		cfg.ConsumerConcurrency = config.ConsumerConcurrencyKey
+
+		release := make(chan struct{})
+		var got received
+		cleanup := runConsumer(t, cfg, "product.created", func(_ context.Context, msg mq.Message) error {
+			if string(msg.Payload) == `a1` {
+				<-release
+			}
+			got.add(string(msg.Payload))
+			return nil
+		})
+		defer cleanup()
+
+		kafkatest.Produce(t, cfg,
+			&kgo.Record{Topic: "product.created", Key: []byte("a"), Value: []byte(`a1`)},
+			&kgo.Record{Topic: "product.created", Key: []byte("b"), Value: []byte(`b1`)},
+			&kgo.Record{Topic: "product.created", Key: []byte("a"), Value: []byte(`a2`)},
+			&kgo.Record{Topic: "product.created", Key: []byte("b"), Value: []byte(`b2`)},
+		)
+
+		require.Eventually(t, func() bool { return got.count() == 2 }, 10*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{`b1`, `b2`}, got.get())
+
+		close(release)
+		require.Eventually(t, func() bool { return got.count() == 4 }, 10*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{`b1`, `b2`, `a1`, `a2`}, got.get())
+	})
+
+	t.Run("Should handle keys with a bounded number of workers", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(1, "product.created"))
+		cfg.ConsumerConcurrency = config.ConsumerConcurrencyKey
+		cfg.ConsumerKeyWorkers = 2
+
+		var inFlight, maxInFlight atomic.Int32
+		var got received
+		cleanup := runConsumer(t, cfg, "product.created", func(_ context.Context, msg mq.Message) error {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				m := maxInFlight.Load()
+				if n <= m || maxInFlight.CompareAndSwap(m, n) {
+					break
+				}
+			}
+
+			time.Sleep(20 * time.Millisecond)
+			got.add(string(msg.Payload))
+			return nil
+		})
+		defer cleanup()
+
+		records := make([]*kgo.Record, 10)
+		for i := range records {
+			records[i] = &kgo.Record{Topic: "product.created", Key: fmt.Appendf(nil, "key-%d", i), Value: []byte(`1`)}
+		}
+		kafkatest.Produce(t, cfg, records...)
+
+		require.Eventually(t, func() bool { return got.count() == len(records) }, 10*time.Second, 10*time.Millisecond)
+		assert.LessOrEqual(t, maxInFlight.Load(), int32(2))
+	})
+
+	t.Run("Should commit only contiguous done offsets when handling keys concurrently", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(1, "product.created"))
+		cfg.ConsumerConcurrency = config.ConsumerConcurrencyKey
+		cfg.ConsumerMaxAttempts = 1
+
+		var first received
+		cleanup := runConsumer(t, cfg, "product.created", func(_ context.Context, msg mq.Message) error {
+			if string(msg.Payload) == `a2` {
+				return errors.New("permanent failure")
+			}
+			first.add(string(msg.Payload))
+			return nil
+		})
+
+		kafkatest.Produce(t, cfg,
+			&kgo.Record{Topic: "product.created", Key: []byte("a"), Value: []byte(`a1`)},
+			&kgo.Record{Topic: "product.created", Key: []byte("a"), Value: []byte(`a2`)},
+			&kgo.Record{Topic: "product.created", Key: []byte("b"), Value: []byte(`b1`)},
+		)
+		require.Eventually(t, func() bool {
+			payloads := first.get()
+			return slices.Contains(payloads, `a1`) && slices.Contains(payloads, `b1`)
+		}, 10*time.Second, 10*time.Millisecond)
+		cleanup()
+
+		var second received
+		cleanup = runConsumer(t, cfg, "product.created", func(_ context.Context, msg mq.Message) error {
+			second.add(string(msg.Payload))
+			return nil
+		})
+		defer cleanup()
+
+		// b1 was handled after the failed a2, it is redelivered with it
+		require.Eventually(t, func() bool { return second.count() == 2 }, 10*time.Second, 10*time.Millisecond)
+		assert.ElementsMatch(t, []string{`a2`, `b1`}, second.get())
+	})
+
+	t.Run("Should not handle records of a paused topic until it is resumed", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(1, "product.created"))
+
+		c, err := mq.NewKafkaConsumer(context.Background(), cfg, discardLogger)
+		require.NoError(t, err)
+
+		var got received
+		require.NoError(t, c.RegisterHandler("product.created", func(_ context.Context, msg mq.Message) error {
+			got.add(string(msg.Payload))
+			return nil
+		}))
+
+		cleanup, err := c.Run(context.Background())
+		require.NoError(t, err)
+		defer cleanup()
+
+		kafkatest.Produce(t, cfg, &kgo.Record{Topic: "product.created", Value: []byte(`1`)})
+		require.Eventually(t, func() bool { return got.count() == 1 }, 10*time.Second, 10*time.Millisecond)
+
+		c.Pause("product.created")
+		kafkatest.Produce(t, cfg, &kgo.Record{Topic: "product.created", Value: []byte(`2`)})
+		assert.Never(t, func() bool { return got.count() > 1 }, 500*time.Millisecond, 10*time.Millisecond)
+
+		c.Resume("product.created")
+		require.Eventually(t, func() bool { return got.count() == 2 }, 10*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{`1`, `2`}, got.get())
+	})
+
+	t.Run("Should let records being handled finish and commit them on shutdown", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(1, "product.created"))
+		cfg.ConsumerDrainTimeout = 10 * time.Second
+
+		started := make(chan struct{})
+		var handleErr atomic.Value
+		cleanup := runConsumer(t, cfg, "product.created", func(ctx context.Context, _ mq.Message) error {
+			close(started)
+			time.Sleep(200 * time.Millisecond)
+			handleErr.Store(fmt.Sprint(ctx.Err()))
+			return ctx.Err()
+		})
+
+		kafkatest.Produce(t, cfg, &kgo.Record{Topic: "product.created", Value: []byte(`1`)})
+		<-started
+		cleanup()
+		assert.Equal(t, "<nil>", handleErr.Load())
+
+		kafkatest.Produce(t, cfg, &kgo.Record{Topic: "product.created", Value: []byte(`2`)})
+
+		var next received
+		cleanup = runConsumer(t, cfg, "product.created", func(_ context.Context, msg mq.Message) error {
+			next.add(string(msg.Payload))
+			return nil
+		})
+		defer cleanup()
+
+		require.Eventually(t, func() bool { return next.count() == 1 }, 10*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{`2`}, next.get())
+	})
+
+	t.Run("Should call the partition callbacks on assignment and revocation", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(2, "product.created"))
+
+		c, err := mq.NewKafkaConsumer(context.Background(), cfg, discardLogger)
+		require.NoError(t, err)
+
+		var mu sync.Mutex
+		var assigned, revoked map[string][]int32
+		c.OnPartitionsAssigned(func(_ context.Context, partitions map[string][]int32) {
+			mu.Lock()
+			defer mu.Unlock()
+			assigned = partitions
+		})
+		c.OnPartitionsRevoked(func(_ context.Context, partitions map[string][]int32) {
+			mu.Lock()
+			defer mu.Unlock()
+			revoked = partitions
+		})
+		require.NoError(t, c.RegisterHandler("product.created", func(context.Context, mq.Message) error { return nil }))
+
+		cleanup, err := c.Run(context.Background())
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return assigned != nil
+		}, 10*time.Second, 10*time.Millisecond)
+
+		cleanup()
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.ElementsMatch(t, []int32{0, 1}, assigned["product.created"])
+		assert.ElementsMatch(t, []int32{0, 1}, revoked["product.created"])
+	})
+
+	t.Run("Should consume topics matching a pattern with regex consumption", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(1, "product.created", "product.deleted", "product.created.dlq", "order.created"))
+		cfg.ConsumerRegex = true
+
+		c, err := mq.NewKafkaConsumer(context.Background(), cfg, discardLogger)
+		require.NoError(t, err)
+
+		var got received
+		handler := func(name string) mq.HandlerFunc {
+			return func(_ context.Context, msg mq.Message) error {
+				got.add(name + ":" + msg.Topic)
+				return nil
+			}
+		}
+		require.NoError(t, c.RegisterPatternHandler(`product\..*`, handler("pattern")))
+		require.NoError(t, c.RegisterHandler("product.deleted", handler("exact")))
+		c.SetFallbackHandler(handler("fallback"))
+
+		cleanup, err := c.Run(context.Background())
+		require.NoError(t, err)
+		defer cleanup()
+
+		kafkatest.Produce(t, cfg,
+			&kgo.Record{Topic: "product.created", Value: []byte(`1`)},
+			&kgo.Record{Topic: "product.deleted", Value: []byte(`2`)},
+			&kgo.Record{Topic: "product.created.dlq", Value: []byte(`3`)},
+			&kgo.Record{Topic: "order.created", Value: []byte(`4`)},
+		)
+
+		require.Eventually(t, func() bool { return got.count() == 2 }, 10*time.Second, 10*time.Millisecond)
+		assert.Never(t, func() bool { return got.count() > 2 }, 300*time.Millisecond, 10*time.Millisecond)
+		assert.ElementsMatch(t, []string{"pattern:product.created", "exact:product.deleted"}, got.get())
+	})
+
+	t.Run("Should keep a topic paused while Run replaces the regex client", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(2, "product.created"))
+		cfg.ConsumerRegex = true
+		cfg.ConsumerConcurrency = config.ConsumerConcurrencyPartition
+
+		c, err := mq.NewKafkaConsumer(context.Background(), cfg, discardLogger)
+		require.NoError(t, err)
+
+		var got received
+		require.NoError(t, c.RegisterPatternHandler(`product\..*`, func(_ context.Context, msg mq.Message) error {
+			got.add(string(msg.Payload))
+			return nil
+		}))
+
+		paused := make(chan struct{})
+		go func() {
+			defer close(paused)
+			c.Pause("product.created")
+		}()
+
+		cleanup, err := c.Run(context.Background())
+		require.NoError(t, err)
+		defer cleanup()
+		<-paused
+
+		kafkatest.Produce(t, cfg, &kgo.Record{Topic: "product.created", Value: []byte(`1`)})
+		assert.Never(t, func() bool { return got.count() > 0 }, 300*time.Millisecond, 10*time.Millisecond)
+
+		c.Resume("product.created")
+		require.Eventually(t, func() bool { return got.count() == 1 }, 10*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Should reject pattern handlers without regex consumption", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t)
+
+		c, err := mq.NewKafkaConsumer(context.Background(), cfg, discardLogger)
+		require.NoError(t, err)
+		defer c.Close()
+
+		assert.Error(t, c.RegisterPatternHandler(`product\..*`, func(context.Context, mq.Message) error { return nil }))
+	})
+}
@@ -0,0 +1,65 @@
+package mq
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/outbox"
+)
+
+// EventTypeField is the field of the JSON envelope holding the event type of messages without
+// an event type header.
+const EventTypeField = "type"
+
+// EventVersionField is the field of the JSON envelope holding the event version of messages without
+// an event version header.
+const EventVersionField = "version"
+
+// DefaultEventVersion is the version of events published before they were versioned.
+const DefaultEventVersion = 1
+
+// EventType returns the event type of the message, from its event type header or else from the
+// type field of its JSON envelope.
+func EventType(msg Message) (string, bool) {
+	if eventType, ok := msg.Headers[outbox.EventTypeHeader]; ok && eventType != "" {
+		return eventType, true
+	}
+
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(msg.Payload, &envelope); err != nil {
+		return "", false
+	}
+
+	var eventType string
+	if err := json.Unmarshal(envelope[EventTypeField], &eventType); err != nil || eventType == "" {
+		return "", false
+	}
+
+	return eventType, true
+}
+
+// EventVersion returns the event version of the message, from its event version header or else from
+// the version field of its JSON envelope, DefaultEventVersion if it has none.
+// It fails with a NonRetryable error if the version is not a positive integer.
+func EventVersion(msg Message) (int, error) {
+	if v, ok := msg.Headers[outbox.EventVersionHeader]; ok && v != "" {
+		version, err := strconv.Atoi(v)
+		if err != nil || version < 1 {
+			return 0, NonRetryable(fmt.Errorf("invalid event version header %q", v))
+		}
+		return version, nil
+	}
+
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(msg.Payload, &envelope); err != nil || envelope[EventVersionField] == nil {
+		return DefaultEventVersion, nil
+	}
+
+	var version int
+	if err := json.Unmarshal(envelope[EventVersionField], &version); err != nil || version < 1 {
+		return 0, NonRetryable(fmt.Errorf("invalid event version field %s", envelope[EventVersionField]))
+	}
+
+	return version, nil
+}
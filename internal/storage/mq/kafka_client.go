@@ -0,0 +1,102 @@
+package mq
+
+import (
+	"context"
+	"errors"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
+)
+
+// clientOpts returns the client options shared by the producer and consumer clients.
+func clientOpts(ctx context.Context, cfg config.Kafka) ([]kgo.Opt, error) {
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(cfg.Addresses...),
+		kgo.WithContext(ctx),
+		kgo.WithHooks(kTracer),
+	}
+	if cfg.TopicsAutoCreate {
+		opts = append(opts, kgo.AllowAutoTopicCreation())
+	}
+
+	securityOpts, err := securityOpts(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(opts, securityOpts...), nil
+}
+
+// producerOpts returns the client options tuning how records are produced.
+// Acks, compression, partitioner and linger are always set from the config, their zero
+// values being ALL acks, no compression, the murmur2 partitioner and no linger. The max
+// buffered records, batch max bytes and timeout keep the franz-go defaults when zero.
+func producerOpts(cfg config.Kafka) ([]kgo.Opt, error) {
+	var opts []kgo.Opt
+
+	switch cfg.ProducerAcks {
+	case config.ProducerAcksAll:
+		opts = append(opts, kgo.RequiredAcks(kgo.AllISRAcks()))
+	case config.ProducerAcksLeader:
+		opts = append(opts, kgo.RequiredAcks(kgo.LeaderAck()))
+	case config.ProducerAcksNone:
+		opts = append(opts, kgo.RequiredAcks(kgo.NoAck()))
+	}
+	if cfg.ProducerDisableIdempotence {
+		opts = append(opts, kgo.DisableIdempotentWrite())
+	} else if cfg.ProducerAcks != config.ProducerAcksAll {
+		return nil, errors.New("idempotent writes require ALL producer acks, disable idempotence to use other acks")
+	}
+
+	switch cfg.ProducerCompression {
+	case config.ProducerCompressionNone:
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.NoCompression()))
+	case config.ProducerCompressionGzip:
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.GzipCompression()))
+	case config.ProducerCompressionSnappy:
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.SnappyCompression()))
+	case config.ProducerCompressionLz4:
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.Lz4Compression()))
+	case config.ProducerCompressionZstd:
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.ZstdCompression()))
+	}
+
+	switch cfg.ProducerPartitioner {
+	case config.ProducerPartitionerMurmur2:
+		// a nil hasher hashes keys with murmur2 the way the Java client does
+		opts = append(opts, kgo.RecordPartitioner(kgo.StickyKeyPartitioner(nil)))
+	case config.ProducerPartitionerSticky:
+		opts = append(opts, kgo.RecordPartitioner(kgo.StickyPartitioner()))
+	case config.ProducerPartitionerRoundRobin:
+		opts = append(opts, kgo.RecordPartitioner(kgo.RoundRobinPartitioner()))
+	}
+
+	opts = append(opts, kgo.ProducerLinger(cfg.ProducerLinger))
+	if cfg.ProducerMaxBufferedRecords > 0 {
+		opts = append(opts, kgo.MaxBufferedRecords(cfg.ProducerMaxBufferedRecords))
+	}
+	if cfg.ProducerBatchMaxBytes > 0 {
+		opts = append(opts, kgo.ProducerBatchMaxBytes(cfg.ProducerBatchMaxBytes))
+	}
+	if cfg.ProducerTimeout > 0 {
+		opts = append(opts, kgo.RecordDeliveryTimeout(cfg.ProducerTimeout))
+	}
+
+	return opts, nil
+}
+
+// consumerOpts returns the client options of a consumer group member.
+func consumerOpts(cfg config.Kafka) ([]kgo.Opt, error) {
+	if cfg.Group == "" {
+		return nil, errors.New("kafka consumer: group is required")
+	}
+
+	return []kgo.Opt{
+		kgo.ConsumerGroup(cfg.Group),
+		// only offsets of handled records are committed
+		kgo.AutoCommitMarks(),
+		// partitions cannot be revoked while records of a poll are being handled
+		kgo.BlockRebalanceOnPoll(),
+	}, nil
+}
@@ -0,0 +1,88 @@
+package mq
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
+)
+
+// securityOpts returns the client options connecting to the brokers with the TLS and SASL settings
+// of the config, shared by the producer and consumer clients.
+func securityOpts(cfg config.Kafka) ([]kgo.Opt, error) {
+	var opts []kgo.Opt
+
+	if cfg.TLSEnabled {
+		tlsCfg, err := newTLSConfig(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("tls config: %w", err)
+		}
+		opts = append(opts, kgo.DialTLSConfig(tlsCfg))
+	}
+
+	if cfg.SASLMechanism != config.SASLMechanismNone {
+		mechanism, err := newSASLMechanism(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("sasl mechanism: %w", err)
+		}
+		opts = append(opts, kgo.SASL(mechanism))
+	}
+
+	return opts, nil
+}
+
+func newTLSConfig(cfg config.Kafka) (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		//nolint:gosec
+		InsecureSkipVerify: cfg.TLSInsecureSkipVerify,
+	}
+
+	if cfg.TLSCAFile != "" {
+		ca, err := os.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificate found in ca file %s", cfg.TLSCAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if cfg.TLSCertFile != "" || cfg.TLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsCfg, nil
+}
+
+func newSASLMechanism(cfg config.Kafka) (sasl.Mechanism, error) {
+	if cfg.SASLUsername == "" || cfg.SASLPassword == "" {
+		return nil, errors.New("username and password are required")
+	}
+
+	switch cfg.SASLMechanism {
+	case config.SASLMechanismPlain:
+		return plain.Auth{User: cfg.SASLUsername, Pass: cfg.SASLPassword}.AsMechanism(), nil
+	case config.SASLMechanismScramSHA256:
+		return scram.Auth{User: cfg.SASLUsername, Pass: cfg.SASLPassword}.AsSha256Mechanism(), nil
+	case config.SASLMechanismScramSHA512:
+		return scram.Auth{User: cfg.SASLUsername, Pass: cfg.SASLPassword}.AsSha512Mechanism(), nil
+	default:
+		return nil, fmt.Errorf("unsupported sasl mechanism: %s", cfg.SASLMechanism)
+	}
+}
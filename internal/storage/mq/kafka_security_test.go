@@ -0,0 +1,119 @@
+package mq_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kfake"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+	"github.com/tuanvumaihuynh/outbox-pattern/test/kafkatest"
+)
+
+// newServerCert creates a self-signed certificate for localhost and writes it to a PEM file.
+func newServerCert(t *testing.T) (tls.Certificate, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:              []string{"localhost"},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, caFile
+}
+
+func TestKafkaSecurity(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should connect with SASL mechanisms", func(t *testing.T) {
+		for _, mechanism := range []config.SASLMechanism{
+			config.SASLMechanismPlain,
+			config.SASLMechanismScramSHA256,
+			config.SASLMechanismScramSHA512,
+		} {
+			t.Run(mechanism.String(), func(t *testing.T) {
+				_, cfg := kafkatest.NewCluster(t, kfake.EnableSASL(), kfake.Superuser(mechanism.String(), "user", "secret"))
+				cfg.SASLMechanism = mechanism
+				cfg.SASLUsername = "user"
+				cfg.SASLPassword = "secret"
+
+				producer, err := mq.NewKafkaProducer(ctx, cfg)
+				require.NoError(t, err)
+				producer.Close()
+
+				consumer, err := mq.NewKafkaConsumer(ctx, cfg, discardLogger)
+				require.NoError(t, err)
+				consumer.Close()
+			})
+		}
+	})
+
+	t.Run("Should fail with wrong SASL credentials", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.EnableSASL(), kfake.Superuser("SCRAM-SHA-256", "user", "secret"))
+		cfg.SASLMechanism = config.SASLMechanismScramSHA256
+		cfg.SASLUsername = "user"
+		cfg.SASLPassword = "wrong"
+
+		_, err := mq.NewKafkaProducer(ctx, cfg)
+		assert.Error(t, err)
+	})
+
+	t.Run("Should require SASL credentials", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t)
+		cfg.SASLMechanism = config.SASLMechanismPlain
+
+		_, err := mq.NewKafkaConsumer(ctx, cfg, discardLogger)
+		assert.Error(t, err)
+	})
+
+	t.Run("Should connect over TLS verifying the brokers with the CA file", func(t *testing.T) {
+		cert, caFile := newServerCert(t)
+		_, cfg := kafkatest.NewCluster(t, kfake.TLS(&tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}))
+		cfg.TLSEnabled = true
+		cfg.TLSCAFile = caFile
+
+		producer, err := mq.NewKafkaProducer(ctx, cfg)
+		require.NoError(t, err)
+		producer.Close()
+	})
+
+	t.Run("Should fail with an unreadable CA file", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t)
+		cfg.TLSEnabled = true
+		cfg.TLSCAFile = filepath.Join(t.TempDir(), "missing.pem")
+
+		_, err := mq.NewKafkaProducer(ctx, cfg)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,44 @@
+package mq
+
+import (
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Metadata describes a consumed message.
+type Metadata struct {
+	// Topic is the topic the handler is registered for, the original topic for a retried message.
+	Topic     string
+	Key       []byte
+	Headers   map[string]string
+	Partition int32
+	Offset    int64
+	Timestamp time.Time
+}
+
+// Message is a consumed message.
+type Message struct {
+	Metadata
+	Payload []byte
+}
+
+// messageFromRecord converts a Kafka record to a message of topic.
+func messageFromRecord(topic string, rec *kgo.Record) Message {
+	headers := make(map[string]string, len(rec.Headers))
+	for _, h := range rec.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+
+	return Message{
+		Metadata: Metadata{
+			Topic:     topic,
+			Key:       rec.Key,
+			Headers:   headers,
+			Partition: rec.Partition,
+			Offset:    rec.Offset,
+			Timestamp: rec.Timestamp,
+		},
+		Payload: rec.Value,
+	}
+}
@@ -0,0 +1,12 @@
+package mq
+
+// Middleware wraps a handler to add behavior around it, like HTTP middlewares do.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Chain wraps the handler with the middlewares, the first one being the outermost.
+func Chain(handler HandlerFunc, middlewares ...Middleware) HandlerFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
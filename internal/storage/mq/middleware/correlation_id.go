@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/correlationid"
+)
+
+// CorrelationID injects the correlation ID carried in the message headers into the context.
+func CorrelationID() mq.Middleware {
+	return func(next mq.HandlerFunc) mq.HandlerFunc {
+		return func(ctx context.Context, msg mq.Message) error {
+			if correlationID, ok := msg.Headers[correlationid.Header]; ok {
+				ctx = correlationid.NewContext(ctx, correlationID)
+			}
+
+			return next(ctx, msg)
+		}
+	}
+}
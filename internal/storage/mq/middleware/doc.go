@@ -0,0 +1,6 @@
+// Package middleware provides mq.Middleware implementations for consumer handlers, mirroring
+// the HTTP middleware package. They are installed for every handler with mq.Consumer.Use, or
+// for a single handler with mq.Chain.
+//
+// Inbox deduplication is provided by inbox.Inbox.Middleware.
+package middleware
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+)
+
+// Logging logs each handled message with its latency, as an error if the handler failed, or as a warning
+// with its event type if nothing handles it.
+func Logging(log *slog.Logger) mq.Middleware {
+	return func(next mq.HandlerFunc) mq.HandlerFunc {
+		return func(ctx context.Context, msg mq.Message) error {
+			t1 := time.Now()
+
+			err := next(ctx, msg)
+
+			attrs := []slog.Attr{
+				slog.Duration("latency", time.Since(t1)),
+				slog.String("topic", msg.Topic),
+				slog.Int("partition", int(msg.Partition)),
+				slog.Int64("offset", msg.Offset),
+				slog.String("key", string(msg.Key)),
+			}
+
+			if errors.Is(err, mq.ErrUnhandledEventType) {
+				eventType, _ := mq.EventType(msg)
+				attrs = append(attrs, slog.String("event_type", eventType), slog.Any("error", err))
+				log.LogAttrs(ctx, slog.LevelWarn, "mq message", attrs...)
+				return err
+			}
+
+			if err != nil {
+				attrs = append(attrs, slog.Any("error", err))
+				log.LogAttrs(ctx, slog.LevelError, "mq message", attrs...)
+				return err
+			}
+
+			log.LogAttrs(ctx, slog.LevelInfo, "mq message", attrs...)
+			return nil
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+)
+
+// Metrics records the number of handled messages and the handling duration, by topic and status.
+// Messages nothing handles have the unhandled status and their event type.
+func Metrics(meter metric.Meter) (mq.Middleware, error) {
+	handled, err := meter.Int64Counter("mq.consumer.messages",
+		metric.WithDescription("Number of messages handled by the consumer."),
+		metric.WithUnit("{message}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create messages counter: %w", err)
+	}
+
+	duration, err := meter.Float64Histogram("mq.consumer.duration",
+		metric.WithDescription("Duration of message handling."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create duration histogram: %w", err)
+	}
+
+	return func(next mq.HandlerFunc) mq.HandlerFunc {
+		return func(ctx context.Context, msg mq.Message) error {
+			t1 := time.Now()
+
+			err := next(ctx, msg)
+
+			kvs := []attribute.KeyValue{attribute.String("topic", msg.Topic)}
+			switch {
+			case errors.Is(err, mq.ErrUnhandledEventType):
+				eventType, _ := mq.EventType(msg)
+				kvs = append(kvs, attribute.String("status", "unhandled"), attribute.String("event_type", eventType))
+			case err != nil:
+				kvs = append(kvs, attribute.String("status", "error"))
+			default:
+				kvs = append(kvs, attribute.String("status", "ok"))
+			}
+
+			attrs := metric.WithAttributes(kvs...)
+			handled.Add(ctx, 1, attrs)
+			duration.Record(ctx, time.Since(t1).Seconds(), attrs)
+
+			return err
+		}
+	}, nil
+}
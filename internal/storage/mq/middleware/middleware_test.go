@@ -0,0 +1,232 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/noop"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq/middleware"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq/schemaregistry"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/correlationid"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/outbox"
+	"github.com/tuanvumaihuynh/outbox-pattern/test/fake"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+var msg = mq.Message{
+	Metadata: mq.Metadata{
+		Topic: "product.created",
+		Headers: map[string]string{
+			correlationid.Header:   "correlation-id",
+			outbox.MessageIDHeader: "message-id",
+		},
+	},
+	Payload: []byte(`{}`),
+}
+
+func TestChain(t *testing.T) {
+	t.Run("Should run middlewares with the first one outermost", func(t *testing.T) {
+		var calls []string
+		mw := func(name string) mq.Middleware {
+			return func(next mq.HandlerFunc) mq.HandlerFunc {
+				return func(ctx context.Context, msg mq.Message) error {
+					calls = append(calls, name)
+					return next(ctx, msg)
+				}
+			}
+		}
+
+		handler := mq.Chain(func(context.Context, mq.Message) error {
+			calls = append(calls, "handler")
+			return nil
+		}, mw("a"), mw("b"))
+
+		require.NoError(t, handler(context.Background(), msg))
+		assert.Equal(t, []string{"a", "b", "handler"}, calls)
+	})
+}
+
+func TestRecoverer(t *testing.T) {
+	t.Run("Should turn a panic into an error", func(t *testing.T) {
+		handler := mq.Chain(func(context.Context, mq.Message) error {
+			panic("boom")
+		}, middleware.Recoverer(discardLogger))
+
+		assert.EqualError(t, handler(context.Background(), msg), "panic: boom")
+	})
+}
+
+func TestCorrelationID(t *testing.T) {
+	t.Run("Should inject correlation id from headers", func(t *testing.T) {
+		var gotCorrelationID string
+		handler := mq.Chain(func(ctx context.Context, _ mq.Message) error {
+			gotCorrelationID, _ = correlationid.FromContext(ctx)
+			return nil
+		}, middleware.CorrelationID())
+
+		require.NoError(t, handler(context.Background(), msg))
+		assert.Equal(t, "correlation-id", gotCorrelationID)
+	})
+}
+
+func TestTimeout(t *testing.T) {
+	t.Run("Should cancel the handler context after the timeout", func(t *testing.T) {
+		handler := mq.Chain(func(ctx context.Context, _ mq.Message) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, middleware.Timeout(10*time.Millisecond))
+
+		assert.ErrorIs(t, handler(context.Background(), msg), context.DeadlineExceeded)
+	})
+}
+
+func TestTraceLoggingMetrics(t *testing.T) {
+	t.Run("Should pass the handler error through", func(t *testing.T) {
+		metrics, err := middleware.Metrics(noop.NewMeterProvider().Meter("test"))
+		require.NoError(t, err)
+
+		handleErr := errors.New("handler failed")
+		handler := mq.Chain(func(context.Context, mq.Message) error {
+			return handleErr
+		},
+			middleware.Trace(tracenoop.NewTracerProvider().Tracer("test")),
+			middleware.Logging(discardLogger),
+			metrics,
+		)
+
+		assert.ErrorIs(t, handler(context.Background(), msg), handleErr)
+	})
+
+	t.Run("Should count messages with an unhandled event type with their event type", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		metrics, err := middleware.Metrics(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test"))
+		require.NoError(t, err)
+
+		r := mq.NewRouter()
+		require.NoError(t, r.RegisterEventType("product", "product.created", func(context.Context, mq.Message) error {
+			return nil
+		}))
+		r.Use(middleware.Logging(discardLogger), metrics)
+		handler, ok := r.Handler("product")
+		require.True(t, ok)
+
+		unhandled := mq.Message{
+			Metadata: mq.Metadata{Topic: "product", Headers: map[string]string{outbox.EventTypeHeader: "product.deleted"}},
+		}
+		assert.ErrorIs(t, handler(context.Background(), unhandled), mq.ErrUnhandledEventType)
+
+		var rm metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(context.Background(), &rm))
+		require.Len(t, rm.ScopeMetrics, 1)
+
+		var points []metricdata.DataPoint[int64]
+		for _, m := range rm.ScopeMetrics[0].Metrics {
+			if m.Name == "mq.consumer.messages" {
+				points = m.Data.(metricdata.Sum[int64]).DataPoints
+			}
+		}
+		require.Len(t, points, 1)
+		assert.Equal(t, int64(1), points[0].Value)
+		assert.Equal(t, attribute.NewSet(
+			attribute.String("topic", "product"),
+			attribute.String("status", "unhandled"),
+			attribute.String("event_type", "product.deleted"),
+		), points[0].Attributes)
+	})
+}
+
+func TestSchemaRegistry(t *testing.T) {
+	t.Run("Should unframe payloads published with a registered schema", func(t *testing.T) {
+		registry := fake.NewSchemaRegistry()
+		id, err := registry.Register(context.Background(), "product.created-value", schemaregistry.Schema{
+			Type:   schemaregistry.SchemaTypeJSON,
+			Schema: `{"type":"object","required":["product_id"]}`,
+		})
+		require.NoError(t, err)
+
+		var payloads []string
+		handler := mq.Chain(func(_ context.Context, msg mq.Message) error {
+			payloads = append(payloads, string(msg.Payload))
+			return nil
+		}, middleware.SchemaRegistry(schemaregistry.NewDeserializer(registry)))
+
+		framed := msg
+		framed.Payload = schemaregistry.Encode(id, []byte(`{"product_id":"1"}`))
+		require.NoError(t, handler(context.Background(), framed))
+
+		plain := msg
+		plain.Payload = []byte(`{"product_id":"2"}`)
+		require.NoError(t, handler(context.Background(), plain))
+
+		invalid := msg
+		invalid.Payload = schemaregistry.Encode(id, []byte(`{}`))
+		require.Error(t, handler(context.Background(), invalid))
+
+		assert.Equal(t, []string{`{"product_id":"1"}`, `{"product_id":"2"}`}, payloads)
+	})
+}
+
+func TestUpcast(t *testing.T) {
+	t.Run("Should upcast older event versions before the handler", func(t *testing.T) {
+		upcasters := mq.NewUpcasters()
+		require.NoError(t, upcasters.Register("product.created", 1, func(payload json.RawMessage) (json.RawMessage, error) {
+			return json.RawMessage(`{"price":"9.5"}`), nil
+		}))
+
+		var got []mq.Message
+		handler := mq.Chain(func(_ context.Context, msg mq.Message) error {
+			got = append(got, msg)
+			return nil
+		}, middleware.Upcast(upcasters))
+
+		old := mq.Message{
+			Metadata: mq.Metadata{Topic: "product.created", Headers: map[string]string{outbox.EventTypeHeader: "product.created"}},
+			Payload:  []byte(`{"price":9.5}`),
+		}
+		require.NoError(t, handler(context.Background(), old))
+
+		current := old
+		current.Headers = map[string]string{outbox.EventTypeHeader: "product.created", outbox.EventVersionHeader: "2"}
+		current.Payload = []byte(`{"price":"10"}`)
+		require.NoError(t, handler(context.Background(), current))
+
+		require.Len(t, got, 2)
+		assert.JSONEq(t, `{"price":"9.5"}`, string(got[0].Payload))
+		assert.Equal(t, "2", got[0].Headers[outbox.EventVersionHeader])
+		assert.NotContains(t, old.Headers, outbox.EventVersionHeader, "the original headers are left untouched")
+		assert.JSONEq(t, `{"price":"10"}`, string(got[1].Payload))
+	})
+	t.Run("Should fail newer event versions as non-retryable without calling the handler", func(t *testing.T) {
+		called := false
+		handler := mq.Chain(func(context.Context, mq.Message) error {
+			called = true
+			return nil
+		}, middleware.Upcast(mq.NewUpcasters()))
+
+		newer := mq.Message{
+			Metadata: mq.Metadata{
+				Topic:   "product.created",
+				Headers: map[string]string{outbox.EventTypeHeader: "product.created", outbox.EventVersionHeader: "2"},
+			},
+			Payload: []byte(`{"price":"10"}`),
+		}
+		err := handler(context.Background(), newer)
+		require.Error(t, err)
+		assert.True(t, mq.IsNonRetryable(err))
+		assert.False(t, called)
+	})
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+)
+
+// Recoverer is a middleware that recovers from panics, logs the panic (and a
+// backtrace), and returns it as an error so the message is handled like a failed one.
+func Recoverer(log *slog.Logger) mq.Middleware {
+	return func(next mq.HandlerFunc) mq.HandlerFunc {
+		return func(ctx context.Context, msg mq.Message) (err error) {
+			defer func() {
+				if rvr := recover(); rvr != nil {
+					err = fmt.Errorf("panic: %v", rvr)
+
+					log.ErrorContext(ctx, "panic in message handler",
+						slog.String("topic", msg.Topic),
+						slog.Any("recover", rvr),
+						slog.String("stack", string(debug.Stack())),
+					)
+				}
+			}()
+
+			return next(ctx, msg)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq/schemaregistry"
+)
+
+// SchemaRegistry unframes payloads published with a registered schema and validates them against it,
+// so the handler receives the bare payload. Payloads that are not framed are passed through.
+func SchemaRegistry(deserializer *schemaregistry.Deserializer) mq.Middleware {
+	return func(next mq.HandlerFunc) mq.HandlerFunc {
+		return func(ctx context.Context, msg mq.Message) error {
+			payload, err := deserializer.Deserialize(ctx, msg.Payload)
+			if err != nil {
+				return fmt.Errorf("deserialize %s message: %w", msg.Topic, err)
+			}
+			msg.Payload = payload
+
+			return next(ctx, msg)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+)
+
+// Timeout cancels the context passed to the handler after d. Handlers must respect the
+// context for the timeout to take effect.
+func Timeout(d time.Duration) mq.Middleware {
+	return func(next mq.HandlerFunc) mq.HandlerFunc {
+		return func(ctx context.Context, msg mq.Message) error {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+
+			return next(ctx, msg)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+)
+
+// Trace starts a consumer span for each message, continuing the trace propagated in its headers.
+func Trace(tracer trace.Tracer) mq.Middleware {
+	return func(next mq.HandlerFunc) mq.HandlerFunc {
+		return func(ctx context.Context, msg mq.Message) error {
+			ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(msg.Headers))
+
+			ctx, span := tracer.Start(ctx, msg.Topic+" process",
+				trace.WithSpanKind(trace.SpanKindConsumer),
+				trace.WithAttributes(
+					attribute.String("messaging.destination.name", msg.Topic),
+					attribute.Int("messaging.destination.partition.id", int(msg.Partition)),
+					attribute.Int64("messaging.message.offset", msg.Offset),
+				),
+			)
+			defer span.End()
+
+			if err := next(ctx, msg); err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, "error in consumer handler")
+				return err
+			}
+
+			span.SetStatus(codes.Ok, "")
+			return nil
+		}
+	}
+}
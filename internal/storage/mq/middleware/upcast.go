@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strconv"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/outbox"
+)
+
+// Upcast transforms payloads of older event versions into the current version of their event type
+// before the handler decodes them, and sets the event version header to the current version.
+// Messages without an event type are passed through, messages of a newer version fail as non-retryable.
+func Upcast(upcasters *mq.Upcasters) mq.Middleware {
+	return func(next mq.HandlerFunc) mq.HandlerFunc {
+		return func(ctx context.Context, msg mq.Message) error {
+			eventType, ok := mq.EventType(msg)
+			if !ok {
+				return next(ctx, msg)
+			}
+
+			version, err := mq.EventVersion(msg)
+			if err != nil {
+				return fmt.Errorf("%s message: %w", msg.Topic, err)
+			}
+
+			payload, current, err := upcasters.Upcast(eventType, version, msg.Payload)
+			if err != nil {
+				return fmt.Errorf("%s message: %w", msg.Topic, err)
+			}
+			if current == version {
+				return next(ctx, msg)
+			}
+
+			msg.Payload = payload
+			msg.Headers = maps.Clone(msg.Headers)
+			if msg.Headers == nil {
+				msg.Headers = make(map[string]string)
+			}
+			msg.Headers[outbox.EventVersionHeader] = strconv.Itoa(current)
+
+			return next(ctx, msg)
+		}
+	}
+}
@@ -0,0 +1,421 @@
+package mq
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db/sqlc"
+)
+
+// postgresNotifyChannel is the channel notified by the mq_messages insert trigger.
+const postgresNotifyChannel = "mq_messages"
+
+var _ Consumer = (*PostgresConsumer)(nil)
+
+// PostgresConsumer consumes messages from the mq_messages table.
+//
+// Each (group, topic) pair has an offset row in mq_consumer_offsets. A group member claims a topic
+// by locking its offset row with SKIP LOCKED, so a topic is consumed by one member at a time and
+// in order. New messages are picked up through LISTEN/NOTIFY, with polling as a fallback.
+//
+// A failing message is retried in-process with exponential backoff. Once the attempts are exhausted,
+// it is published to the dead-letter topic if enabled and the offset moves past it. Otherwise the
+// offset stops before it, so it is handled again on the next poll and blocks the messages after it.
+// A message failing with a NonRetryable error is dead-lettered right away, or skipped without
+// dead-lettering.
+//
+// Tracing and correlation IDs are left to the middlewares installed with Use. A panicking handler
+// fails its message like an error, middleware.Recoverer logs and traces the panic.
+type PostgresConsumer struct {
+	cfg     config.PostgresMQ
+	pool    *pgxpool.Pool
+	db      db.DB
+	queries sqlc.Queries
+	router  *Router
+	logger  *slog.Logger
+
+	mu       sync.RWMutex
+	paused   map[string]struct{}
+	wakeChan chan struct{}
+}
+
+func NewPostgresConsumer(
+	cfg config.PostgresMQ,
+	pool *pgxpool.Pool,
+	db db.DB,
+	queries sqlc.Queries,
+	logger *slog.Logger,
+) *PostgresConsumer {
+	return &PostgresConsumer{
+		cfg:     cfg,
+		pool:    pool,
+		db:      db,
+		queries: queries,
+		router:  NewRouter(),
+		logger:  logger,
+
+		paused:   make(map[string]struct{}),
+		wakeChan: make(chan struct{}, 1),
+	}
+}
+
+func (c *PostgresConsumer) Use(middlewares ...Middleware) {
+	c.router.Use(middlewares...)
+}
+
+func (c *PostgresConsumer) RegisterEventHandler(topic, eventType string, handler HandlerFunc) error {
+	return c.router.RegisterEventType(topic, eventType, handler)
+}
+
+func (c *PostgresConsumer) Pause(topic string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.paused[topic] = struct{}{}
+}
+
+func (c *PostgresConsumer) Resume(topic string) {
+	c.mu.Lock()
+	delete(c.paused, topic)
+	c.mu.Unlock()
+
+	c.wake()
+}
+
+func (c *PostgresConsumer) RegisterHandler(topic string, handler HandlerFunc) error {
+	return c.router.Register(topic, handler)
+}
+
+func (c *PostgresConsumer) Run(ctx context.Context) (CleanupFunc, error) {
+	for _, topic := range c.router.Topics() {
+		if err := c.queries.MqConsumerOffsetInit(ctx, c.db, sqlc.MqConsumerOffsetInitParams{
+			ConsumerGroup: c.cfg.Group,
+			Topic:         topic,
+		}); err != nil {
+			return nil, fmt.Errorf("mq consumer offset init for topic %s: %w", topic, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		c.listen(ctx)
+	})
+	wg.Go(func() {
+		c.consume(ctx)
+	})
+
+	cleanup := func() {
+		cancel()
+		wg.Wait()
+	}
+
+	return cleanup, nil
+}
+
+// listen wakes the consumer whenever a message is inserted, reconnecting on failures.
+func (c *PostgresConsumer) listen(ctx context.Context) {
+	for {
+		err := c.waitForNotifications(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		c.logger.ErrorContext(ctx, "error listening for mq notifications",
+			slog.Any("error", err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.cfg.PollInterval):
+		}
+	}
+}
+
+func (c *PostgresConsumer) waitForNotifications(ctx context.Context) error {
+	conn, err := c.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer func() {
+		//nolint:errcheck
+		conn.Exec(context.Background(), "UNLISTEN *")
+		conn.Release()
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+postgresNotifyChannel); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	for {
+		if _, err := conn.Conn().WaitForNotification(ctx); err != nil {
+			return fmt.Errorf("wait for notification: %w", err)
+		}
+
+		c.wake()
+	}
+}
+
+// wake makes the consumer look for new messages without waiting for the poll interval.
+func (c *PostgresConsumer) wake() {
+	select {
+	case c.wakeChan <- struct{}{}:
+	default:
+	}
+}
+
+func (c *PostgresConsumer) isPaused(topic string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	_, paused := c.paused[topic]
+	return paused
+}
+
+func (c *PostgresConsumer) consume(ctx context.Context) {
+	for {
+		hasMore := false
+		for _, topic := range c.router.Topics() {
+			if c.isPaused(topic) {
+				continue
+			}
+
+			count, err := c.consumeTopic(ctx, topic)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+
+				c.logger.ErrorContext(ctx, "error consuming topic",
+					slog.String("topic", topic),
+					slog.Any("error", err),
+				)
+				continue
+			}
+
+			if count == int(c.cfg.BatchSize) {
+				hasMore = true
+			}
+		}
+
+		if hasMore && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.wakeChan:
+		case <-time.After(c.cfg.PollInterval):
+		}
+	}
+}
+
+// consumeTopic handles the next batch of messages of the topic and returns how many are done with.
+// It stops at the first message that is not, so it is redelivered on the next poll.
+func (c *PostgresConsumer) consumeTopic(ctx context.Context, topic string) (int, error) {
+	done := 0
+
+	err := c.db.WithTx(ctx, func(tx db.DB) error {
+		lastID, err := c.queries.MqConsumerOffsetClaim(ctx, tx, sqlc.MqConsumerOffsetClaimParams{
+			ConsumerGroup: c.cfg.Group,
+			Topic:         topic,
+		})
+		if err != nil {
+			if db.IsNoRowsError(err) {
+				// the topic is being consumed by another member of the group
+				return nil
+			}
+			return fmt.Errorf("mq consumer offset claim: %w", err)
+		}
+
+		msgs, err := c.queries.MqMessageListAfter(ctx, tx, sqlc.MqMessageListAfterParams{
+			Topic:   topic,
+			AfterID: lastID,
+			//nolint:gosec
+			BatchSize: int32(c.cfg.BatchSize),
+		})
+		if err != nil {
+			return fmt.Errorf("mq message list after: %w", err)
+		}
+
+		for _, msg := range msgs {
+			if !c.process(ctx, tx, msg) {
+				break
+			}
+			lastID = msg.ID
+			done++
+		}
+
+		if done == 0 {
+			return nil
+		}
+
+		if err := c.queries.MqConsumerOffsetUpdate(ctx, tx, sqlc.MqConsumerOffsetUpdateParams{
+			LastID:        lastID,
+			ConsumerGroup: c.cfg.Group,
+			Topic:         topic,
+		}); err != nil {
+			return fmt.Errorf("mq consumer offset update: %w", err)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// process handles the message, publishing it to the dead-letter topic in tx if it fails.
+// It returns whether the message is done with.
+func (c *PostgresConsumer) process(ctx context.Context, tx db.DB, msg sqlc.MqMessage) bool {
+	err := c.handle(ctx, msg)
+	if err == nil {
+		return true
+	}
+
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if c.cfg.DeadLetter {
+		if err := c.deadLetter(ctx, tx, msg, err); err != nil {
+			c.logger.ErrorContext(ctx, "error dead-lettering message",
+				slog.String("topic", DeadLetterTopic(msg.Topic)),
+				slog.Any("error", err),
+			)
+		} else {
+			c.logger.WarnContext(ctx, "dead-lettered failed message",
+				slog.String("topic", msg.Topic),
+				slog.Int64("message_id", msg.ID),
+				slog.String("to", DeadLetterTopic(msg.Topic)),
+			)
+			return true
+		}
+	} else if IsNonRetryable(err) {
+		c.logger.ErrorContext(ctx, "skipping message that cannot be handled",
+			slog.String("topic", msg.Topic),
+			slog.Int64("message_id", msg.ID),
+			slog.Any("error", err),
+		)
+		return true
+	}
+
+	c.logger.ErrorContext(ctx, "giving up handling message, retrying on next poll",
+		slog.String("topic", msg.Topic),
+		slog.Int64("message_id", msg.ID),
+		slog.Any("error", err),
+	)
+	return false
+}
+
+// deadLetter publishes the message that failed with handleErr to the dead-letter topic of its topic,
+// with the headers a Kafka dead-lettered record carries.
+func (c *PostgresConsumer) deadLetter(ctx context.Context, tx db.DB, msg sqlc.MqMessage, handleErr error) error {
+	// invalid headers were logged when the message was handled
+	headers, _ := decodeHeaders(msg.Headers)
+	headers[HeaderOriginalTopic] = msg.Topic
+	headers[HeaderOriginalOffset] = strconv.FormatInt(msg.ID, 10)
+	headers[HeaderError] = handleErr.Error()
+	headers[HeaderAttempt] = "1"
+
+	headersBytes, err := json.Marshal(headers)
+	if err != nil {
+		return fmt.Errorf("marshal headers: %w", err)
+	}
+	headersJSON := json.RawMessage(headersBytes)
+
+	topic := DeadLetterTopic(msg.Topic)
+	// inserts are serialized per topic, see PostgresProducer
+	if err := c.queries.MqTopicLock(ctx, tx, topic); err != nil {
+		return fmt.Errorf("mq topic lock: %w", err)
+	}
+
+	if err := c.queries.MqMessageCreate(ctx, tx, sqlc.MqMessageCreateParams{
+		Topic:        topic,
+		PartitionKey: msg.PartitionKey,
+		Headers:      &headersJSON,
+		Payload:      msg.Payload,
+		CreatedAt:    time.Now(),
+	}); err != nil {
+		return fmt.Errorf("mq message create: %w", err)
+	}
+
+	return nil
+}
+
+// handle handles the message, retrying with exponential backoff until it succeeds or the attempts
+// are exhausted. Messages nothing is routed to are skipped, retrying would block the topic forever.
+func (c *PostgresConsumer) handle(ctx context.Context, msg sqlc.MqMessage) error {
+	fn, exists := c.router.Handler(msg.Topic)
+	if !exists {
+		c.logger.ErrorContext(ctx, "no handler registered for topic",
+			slog.String("topic", msg.Topic),
+		)
+		return nil
+	}
+
+	headers, err := decodeHeaders(msg.Headers)
+	if err != nil {
+		c.logger.WarnContext(ctx, "error unmarshaling message headers",
+			slog.Int64("message_id", msg.ID),
+			slog.Any("error", err),
+		)
+	}
+
+	var key []byte
+	if msg.PartitionKey != nil {
+		key = []byte(*msg.PartitionKey)
+	}
+
+	m := Message{
+		Metadata: Metadata{
+			Topic:     msg.Topic,
+			Key:       key,
+			Headers:   headers,
+			Offset:    msg.ID,
+			Timestamp: msg.CreatedAt,
+		},
+		Payload: msg.Payload,
+	}
+
+	return withRetries(ctx, retryPolicy{
+		maxAttempts: c.cfg.MaxAttempts,
+		backoff:     c.cfg.RetryBackoff,
+		maxBackoff:  c.cfg.MaxRetryBackoff,
+	}, func() error {
+		err := callHandler(ctx, fn, m)
+		return skipUnhandledEvent(ctx, c.logger, c.cfg.SkipUnhandledEvents, m, err)
+	}, func(attempt uint32, err error) {
+		c.logger.WarnContext(ctx, "error handling message",
+			slog.String("topic", msg.Topic),
+			slog.Int64("message_id", msg.ID),
+			slog.Int("attempt", int(attempt)),
+			slog.Any("error", err),
+		)
+	})
+}
+
+// decodeHeaders returns the headers of a message, empty if they are missing or invalid.
+func decodeHeaders(raw *json.RawMessage) (map[string]string, error) {
+	headers := map[string]string{}
+	if raw == nil {
+		return headers, nil
+	}
+
+	if err := json.Unmarshal(*raw, &headers); err != nil {
+		return map[string]string{}, fmt.Errorf("unmarshal headers: %w", err)
+	}
+
+	return headers, nil
+}
@@ -0,0 +1,245 @@
+package mq_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db/sqlc"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+	"github.com/tuanvumaihuynh/outbox-pattern/test/pgtest"
+)
+
+func postgresMQConfig() config.PostgresMQ {
+	return config.PostgresMQ{
+		Group:           "test",
+		BatchSize:       100,
+		PollInterval:    100 * time.Millisecond,
+		MaxAttempts:     2,
+		RetryBackoff:    time.Millisecond,
+		MaxRetryBackoff: time.Millisecond,
+	}
+}
+
+func runPostgresConsumer(
+	t *testing.T,
+	pool *pgxpool.Pool,
+	cfg config.PostgresMQ,
+	handlers map[string]mq.HandlerFunc,
+) mq.CleanupFunc {
+	t.Helper()
+
+	c := mq.NewPostgresConsumer(cfg, pool, db.NewClient(pool), *sqlc.New(), discardLogger)
+	for topic, fn := range handlers {
+		require.NoError(t, c.RegisterHandler(topic, fn))
+	}
+
+	cleanup, err := c.Run(context.Background())
+	require.NoError(t, err)
+
+	return cleanup
+}
+
+func producePostgres(t *testing.T, pool *pgxpool.Pool, topic string, payloads ...string) {
+	t.Helper()
+
+	p := mq.NewPostgresProducer(db.NewClient(pool), *sqlc.New())
+	for _, payload := range payloads {
+		require.NoError(t, p.Produce(context.Background(), mq.ProduceMsg{
+			Topic:   topic,
+			Headers: map[string]string{"source": "test"},
+			Payload: []byte(payload),
+		}))
+	}
+}
+
+func TestPostgresConsumer(t *testing.T) {
+	t.Run("Should handle messages in order and not redeliver them after a restart", func(t *testing.T) {
+		pool := pgtest.NewPool(t)
+		cfg := postgresMQConfig()
+		cfg.BatchSize = 2
+
+		producePostgres(t, pool, "product.created", "1", "2", "3")
+
+		var got received
+		handlers := map[string]mq.HandlerFunc{
+			"product.created": func(_ context.Context, msg mq.Message) error {
+				assert.Equal(t, "test", msg.Headers["source"])
+				got.add(string(msg.Payload))
+				return nil
+			},
+		}
+
+		cleanup := runPostgresConsumer(t, pool, cfg, handlers)
+		require.Eventually(t, func() bool { return got.count() == 3 }, 5*time.Second, 10*time.Millisecond)
+		cleanup()
+
+		producePostgres(t, pool, "product.created", "4")
+
+		cleanup = runPostgresConsumer(t, pool, cfg, handlers)
+		defer cleanup()
+
+		require.Eventually(t, func() bool { return got.count() == 4 }, 5*time.Second, 10*time.Millisecond)
+		require.Never(t, func() bool { return got.count() > 4 }, 300*time.Millisecond, 10*time.Millisecond)
+		assert.Equal(t, []string{"1", "2", "3", "4"}, got.get())
+	})
+
+	t.Run("Should block the topic on a failing message and retry it on the next poll", func(t *testing.T) {
+		pool := pgtest.NewPool(t)
+
+		producePostgres(t, pool, "product.created", "bad", "good")
+
+		var badCalls atomic.Int32
+		var got received
+		cleanup := runPostgresConsumer(t, pool, postgresMQConfig(), map[string]mq.HandlerFunc{
+			"product.created": func(_ context.Context, msg mq.Message) error {
+				if string(msg.Payload) == "bad" && badCalls.Add(1) <= 4 {
+					return errors.New("boom")
+				}
+				got.add(string(msg.Payload))
+				return nil
+			},
+		})
+		defer cleanup()
+
+		// two polls of two attempts each fail before the message succeeds
+		require.Eventually(t, func() bool { return got.count() == 2 }, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{"bad", "good"}, got.get())
+		assert.Equal(t, int32(5), badCalls.Load())
+	})
+
+	t.Run("Should dead-letter a message once its attempts are exhausted and move past it", func(t *testing.T) {
+		pool := pgtest.NewPool(t)
+		cfg := postgresMQConfig()
+		cfg.DeadLetter = true
+
+		producePostgres(t, pool, "product.created", "bad", "good")
+
+		var badCalls atomic.Int32
+		var got, dead received
+		var deadHeaders atomic.Value
+		cleanup := runPostgresConsumer(t, pool, cfg, map[string]mq.HandlerFunc{
+			"product.created": func(_ context.Context, msg mq.Message) error {
+				if string(msg.Payload) == "bad" {
+					badCalls.Add(1)
+					return errors.New("boom")
+				}
+				got.add(string(msg.Payload))
+				return nil
+			},
+			mq.DeadLetterTopic("product.created"): func(_ context.Context, msg mq.Message) error {
+				deadHeaders.Store(msg.Headers)
+				dead.add(string(msg.Payload))
+				return nil
+			},
+		})
+		defer cleanup()
+
+		require.Eventually(t, func() bool { return got.count() == 1 && dead.count() == 1 }, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{"good"}, got.get())
+		assert.Equal(t, []string{"bad"}, dead.get())
+		assert.Equal(t, int32(2), badCalls.Load())
+
+		headers, _ := deadHeaders.Load().(map[string]string)
+		assert.Equal(t, "product.created", headers[mq.HeaderOriginalTopic])
+		assert.Equal(t, "boom", headers[mq.HeaderError])
+		assert.Equal(t, "test", headers["source"])
+	})
+
+	t.Run("Should skip a non-retryable failure without dead-lettering", func(t *testing.T) {
+		pool := pgtest.NewPool(t)
+		cfg := postgresMQConfig()
+		cfg.MaxAttempts = 5
+
+		producePostgres(t, pool, "product.created", "bad", "good")
+
+		var badCalls atomic.Int32
+		var got received
+		cleanup := runPostgresConsumer(t, pool, cfg, map[string]mq.HandlerFunc{
+			"product.created": func(_ context.Context, msg mq.Message) error {
+				if string(msg.Payload) == "bad" {
+					badCalls.Add(1)
+					return mq.NonRetryable(errors.New("malformed"))
+				}
+				got.add(string(msg.Payload))
+				return nil
+			},
+		})
+		defer cleanup()
+
+		require.Eventually(t, func() bool { return got.count() == 1 }, 5*time.Second, 10*time.Millisecond)
+		require.Never(t, func() bool { return badCalls.Load() > 1 }, 300*time.Millisecond, 10*time.Millisecond)
+		assert.Equal(t, []string{"good"}, got.get())
+	})
+
+	t.Run("Should pick up new messages on notification without waiting for the poll interval", func(t *testing.T) {
+		pool := pgtest.NewPool(t)
+		cfg := postgresMQConfig()
+		cfg.PollInterval = time.Minute
+
+		var got received
+		cleanup := runPostgresConsumer(t, pool, cfg, map[string]mq.HandlerFunc{
+			"product.created": func(_ context.Context, msg mq.Message) error {
+				got.add(string(msg.Payload))
+				return nil
+			},
+		})
+		defer cleanup()
+
+		// let the consumer go idle and start listening
+		time.Sleep(200 * time.Millisecond)
+
+		producePostgres(t, pool, "product.created", "1")
+
+		require.Eventually(t, func() bool { return got.count() == 1 }, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Should let only one member of the group consume a topic at a time", func(t *testing.T) {
+		pool := pgtest.NewPool(t)
+
+		const count = 20
+		payloads := make([]string, count)
+		for i := range payloads {
+			payloads[i] = fmt.Sprint(i)
+		}
+		producePostgres(t, pool, "product.created", payloads...)
+
+		var inFlight, maxInFlight atomic.Int32
+		var got received
+		handler := func(_ context.Context, msg mq.Message) error {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				m := maxInFlight.Load()
+				if n <= m || maxInFlight.CompareAndSwap(m, n) {
+					break
+				}
+			}
+
+			time.Sleep(5 * time.Millisecond)
+			got.add(string(msg.Payload))
+			return nil
+		}
+
+		cfg := postgresMQConfig()
+		cfg.BatchSize = 3
+		for range 3 {
+			cleanup := runPostgresConsumer(t, pool, cfg, map[string]mq.HandlerFunc{"product.created": handler})
+			defer cleanup()
+		}
+
+		require.Eventually(t, func() bool { return got.count() == count }, 10*time.Second, 10*time.Millisecond)
+		require.Never(t, func() bool { return got.count() > count }, 300*time.Millisecond, 10*time.Millisecond)
+		assert.Equal(t, payloads, got.get())
+		assert.Equal(t, int32(1), maxInFlight.Load())
+	})
+}
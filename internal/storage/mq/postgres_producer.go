@@ -0,0 +1,76 @@
+package mq
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db/sqlc"
+)
+
+var _ Producer = (*PostgresProducer)(nil)
+
+// PostgresProducer publishes messages to the mq_messages table.
+type PostgresProducer struct {
+	db      db.DB
+	queries sqlc.Queries
+}
+
+func NewPostgresProducer(db db.DB, queries sqlc.Queries) *PostgresProducer {
+	return &PostgresProducer{
+		db:      db,
+		queries: queries,
+	}
+}
+
+func (p *PostgresProducer) Produce(ctx context.Context, msg ProduceMsg) error {
+	ctx, span := tracer.Start(ctx, "PostgresProducer.Produce",
+		trace.WithAttributes(
+			attribute.String("topic", msg.Topic),
+		),
+	)
+	defer span.End()
+
+	if err := p.produce(ctx, msg); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to produce message")
+		return err
+	}
+
+	span.SetStatus(codes.Ok, "")
+	return nil
+}
+
+func (p *PostgresProducer) produce(ctx context.Context, msg ProduceMsg) error {
+	headersBytes, err := json.Marshal(msg.Headers)
+	if err != nil {
+		return fmt.Errorf("marshal headers: %w", err)
+	}
+	headers := json.RawMessage(headersBytes)
+
+	return p.db.WithTx(ctx, func(db db.DB) error {
+		// serialize inserts per topic so ids are committed in increasing order,
+		// otherwise a consumer could move its offset past a message not yet visible
+		if err := p.queries.MqTopicLock(ctx, db, msg.Topic); err != nil {
+			return fmt.Errorf("mq topic lock: %w", err)
+		}
+
+		if err := p.queries.MqMessageCreate(ctx, db, sqlc.MqMessageCreateParams{
+			Topic:        msg.Topic,
+			PartitionKey: msg.PartitionKey,
+			Headers:      &headers,
+			Payload:      msg.Payload,
+			CreatedAt:    time.Now(),
+		}); err != nil {
+			return fmt.Errorf("mq message create: %w", err)
+		}
+
+		return nil
+	})
+}
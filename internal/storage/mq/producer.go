@@ -33,13 +33,16 @@ type KafkaProducer struct {
 }
 
 func NewKafkaProducer(ctx context.Context, cfg config.Kafka) (*KafkaProducer, error) {
-	cl, err := kgo.NewClient(
-		kgo.SeedBrokers(cfg.Addresses...),
-		kgo.ConsumerGroup(cfg.Group),
-		kgo.AllowAutoTopicCreation(),
-		kgo.WithContext(ctx),
-		kgo.WithHooks(kTracer),
-	)
+	opts, err := clientOpts(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	tuningOpts, err := producerOpts(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("kafka producer: %w", err)
+	}
+
+	cl, err := kgo.NewClient(append(opts, tuningOpts...)...)
 	if err != nil {
 		return nil, fmt.Errorf("create kafka client: %w", err)
 	}
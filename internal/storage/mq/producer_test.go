@@ -0,0 +1,188 @@
+package mq_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kfake"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/ptr"
+	"github.com/tuanvumaihuynh/outbox-pattern/test/kafkatest"
+)
+
+func TestKafkaProducer(t *testing.T) {
+	t.Run("Should produce message with headers and partition key", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t)
+
+		p, err := mq.NewKafkaProducer(context.Background(), cfg)
+		require.NoError(t, err)
+		defer p.Close()
+
+		err = p.Produce(context.Background(), mq.ProduceMsg{
+			Topic: "product.created",
+			Headers: map[string]string{
+				"X-Correlation-Id": "correlation-id",
+			},
+			Payload:      []byte(`{"product_id":"1"}`),
+			PartitionKey: ptr.New("1"),
+		})
+		require.NoError(t, err)
+
+		records := kafkatest.Consume(t, cfg, "product.created", 1, 10*time.Second)
+		require.Len(t, records, 1)
+		assert.JSONEq(t, `{"product_id":"1"}`, string(records[0].Value))
+		assert.Equal(t, "1", string(records[0].Key))
+
+		headers := map[string]string{}
+		for _, h := range records[0].Headers {
+			headers[h.Key] = string(h.Value)
+		}
+		assert.Equal(t, "correlation-id", headers["X-Correlation-Id"])
+	})
+
+	t.Run("Should not create missing topics unless auto-creation is enabled", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t)
+		cfg.TopicsAutoCreate = false
+
+		p, err := mq.NewKafkaProducer(context.Background(), cfg)
+		require.NoError(t, err)
+		defer p.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		err = p.Produce(ctx, mq.ProduceMsg{Topic: "product.created", Payload: []byte(`{}`)})
+		require.Error(t, err)
+
+		topics, err := kadm.NewClient(kafkatest.NewClient(t, cfg)).ListTopics(context.Background(), "product.created")
+		require.NoError(t, err)
+		assert.False(t, topics.Has("product.created"))
+	})
+
+	t.Run("Should produce messages sharing a partition key to the same partition", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(3, "product.created"))
+
+		p, err := mq.NewKafkaProducer(context.Background(), cfg)
+		require.NoError(t, err)
+		defer p.Close()
+
+		const count = 30
+		for i := range count {
+			err := p.Produce(context.Background(), mq.ProduceMsg{
+				Topic:        "product.created",
+				Payload:      fmt.Appendf(nil, `{"seq":%d}`, i),
+				PartitionKey: ptr.New(fmt.Sprintf("product-%d", i%3)),
+			})
+			require.NoError(t, err)
+		}
+
+		records := kafkatest.Consume(t, cfg, "product.created", count, 10*time.Second)
+
+		partitionsByKey := map[string]map[int32]struct{}{}
+		for _, rec := range records {
+			key := string(rec.Key)
+			if partitionsByKey[key] == nil {
+				partitionsByKey[key] = map[int32]struct{}{}
+			}
+			partitionsByKey[key][rec.Partition] = struct{}{}
+		}
+
+		require.Len(t, partitionsByKey, 3)
+		for key, partitions := range partitionsByKey {
+			assert.Len(t, partitions, 1, "key %s spread across partitions", key)
+		}
+	})
+
+	t.Run("Should spread messages sharing a partition key with the round-robin partitioner", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(3, "product.created"))
+		cfg.ProducerPartitioner = config.ProducerPartitionerRoundRobin
+
+		p, err := mq.NewKafkaProducer(context.Background(), cfg)
+		require.NoError(t, err)
+		defer p.Close()
+
+		const count = 9
+		for i := range count {
+			err := p.Produce(context.Background(), mq.ProduceMsg{
+				Topic:        "product.created",
+				Payload:      fmt.Appendf(nil, `{"seq":%d}`, i),
+				PartitionKey: ptr.New("product-1"),
+			})
+			require.NoError(t, err)
+		}
+
+		records := kafkatest.Consume(t, cfg, "product.created", count, 10*time.Second)
+
+		partitions := map[int32]struct{}{}
+		for _, rec := range records {
+			partitions[rec.Partition] = struct{}{}
+		}
+		assert.Len(t, partitions, 3)
+	})
+
+	t.Run("Should produce with leader acks, gzip compression and no idempotence", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t)
+		cfg.ProducerAcks = config.ProducerAcksLeader
+		cfg.ProducerDisableIdempotence = true
+		cfg.ProducerCompression = config.ProducerCompressionGzip
+
+		p, err := mq.NewKafkaProducer(context.Background(), cfg)
+		require.NoError(t, err)
+		defer p.Close()
+
+		err = p.Produce(context.Background(), mq.ProduceMsg{
+			Topic:   "product.created",
+			Payload: []byte(`{"product_id":"1"}`),
+		})
+		require.NoError(t, err)
+
+		records := kafkatest.Consume(t, cfg, "product.created", 1, 10*time.Second)
+		require.Len(t, records, 1)
+		assert.JSONEq(t, `{"product_id":"1"}`, string(records[0].Value))
+	})
+
+	t.Run("Should reject acks other than ALL with idempotent writes", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t)
+		cfg.ProducerAcks = config.ProducerAcksLeader
+
+		_, err := mq.NewKafkaProducer(context.Background(), cfg)
+		require.ErrorContains(t, err, "idempotent writes require ALL producer acks")
+	})
+
+	t.Run("Should not require a consumer group", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t)
+		cfg.Group = ""
+
+		p, err := mq.NewKafkaProducer(context.Background(), cfg)
+		require.NoError(t, err)
+		p.Close()
+
+		_, err = mq.NewKafkaConsumer(context.Background(), cfg, slog.Default())
+		require.ErrorContains(t, err, "group is required")
+	})
+
+	t.Run("Should fail when the context is cancelled", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t)
+
+		p, err := mq.NewKafkaProducer(context.Background(), cfg)
+		require.NoError(t, err)
+		defer p.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err = p.Produce(ctx, mq.ProduceMsg{
+			Topic:   "product.created",
+			Payload: []byte(`{}`),
+		})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
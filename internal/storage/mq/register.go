@@ -0,0 +1,114 @@
+package mq
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/validator"
+)
+
+// TypedHandlerFunc handles a message decoded into a T.
+type TypedHandlerFunc[T any] func(ctx context.Context, v T, md Metadata) error
+
+type decodeOptions struct {
+	codec     Codec
+	validator validator.Validator
+	validate  bool
+}
+
+// DecodeOption configures how a message payload is decoded.
+type DecodeOption func(*decodeOptions)
+
+// WithCodec decodes payloads with codec instead of JSON.
+func WithCodec(codec Codec) DecodeOption {
+	return func(o *decodeOptions) {
+		o.codec = codec
+	}
+}
+
+// WithValidator validates decoded structs with v instead of the default validator.
+func WithValidator(v validator.Validator) DecodeOption {
+	return func(o *decodeOptions) {
+		o.validator = v
+	}
+}
+
+// WithoutValidation skips validating decoded structs.
+func WithoutValidation() DecodeOption {
+	return func(o *decodeOptions) {
+		o.validate = false
+	}
+}
+
+var defaultValidator = sync.OnceValues(func() (validator.Validator, error) {
+	return validator.NewDefaultValidator()
+})
+
+// Register registers fn for topic on the consumer, decoding each message into a T before calling it.
+func Register[T any](c Consumer, topic string, fn TypedHandlerFunc[T], opts ...DecodeOption) error {
+	return c.RegisterHandler(topic, Typed(fn, opts...))
+}
+
+// RegisterEvent registers fn for the event type of topic on the consumer, decoding each message
+// into a T before calling it.
+func RegisterEvent[T any](c Consumer, topic, eventType string, fn TypedHandlerFunc[T], opts ...DecodeOption) error {
+	return c.RegisterEventHandler(topic, eventType, Typed(fn, opts...))
+}
+
+// Typed adapts fn into a HandlerFunc decoding each message into a T before calling it.
+func Typed[T any](fn TypedHandlerFunc[T], opts ...DecodeOption) HandlerFunc {
+	return func(ctx context.Context, msg Message) error {
+		v, err := Decode[T](msg, opts...)
+		if err != nil {
+			return err
+		}
+
+		return fn(ctx, v, msg.Metadata)
+	}
+}
+
+// Decode decodes the message payload into a T, JSON by default, and validates it
+// with pkg/validator if it is a struct. Payloads that do not decode or validate fail with
+// a NonRetryable error.
+func Decode[T any](msg Message, opts ...DecodeOption) (T, error) {
+	o := decodeOptions{
+		codec:    JSONCodec{},
+		validate: true,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var v T
+	if err := o.codec.Unmarshal(msg.Payload, &v); err != nil {
+		return v, NonRetryable(fmt.Errorf("decode %s message: %w", msg.Topic, err))
+	}
+
+	if !o.validate || !isStruct(v) {
+		return v, nil
+	}
+
+	if o.validator == nil {
+		dv, err := defaultValidator()
+		if err != nil {
+			return v, fmt.Errorf("create validator: %w", err)
+		}
+		o.validator = dv
+	}
+
+	if err := o.validator.Validate(v); err != nil {
+		return v, NonRetryable(fmt.Errorf("validate %s message: %w", msg.Topic, err))
+	}
+
+	return v, nil
+}
+
+func isStruct(v any) bool {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t != nil && t.Kind() == reflect.Struct
+}
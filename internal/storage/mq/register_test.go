@@ -0,0 +1,115 @@
+package mq_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/validator"
+)
+
+type productCreated struct {
+	ProductID string `json:"product_id" validate:"required"`
+	Name      string `json:"name"`
+}
+
+// upperCodec decodes payloads as upper-cased strings.
+type upperCodec struct{}
+
+func (upperCodec) Unmarshal(data []byte, v any) error {
+	s, ok := v.(*string)
+	if !ok {
+		return errors.New("unsupported type")
+	}
+	*s = strings.ToUpper(string(data))
+	return nil
+}
+
+func newMessage(payload string) mq.Message {
+	return mq.Message{
+		Metadata: mq.Metadata{
+			Topic:     "product.created",
+			Key:       []byte("product-1"),
+			Headers:   map[string]string{"X-Correlation-Id": "correlation-id"},
+			Partition: 2,
+			Offset:    42,
+			Timestamp: time.Date(2025, 11, 20, 0, 0, 0, 0, time.UTC),
+		},
+		Payload: []byte(payload),
+	}
+}
+
+func TestTyped(t *testing.T) {
+	t.Run("Should decode the payload and pass the metadata", func(t *testing.T) {
+		var gotEvent productCreated
+		var gotMetadata mq.Metadata
+		handler := mq.Typed(func(_ context.Context, ev productCreated, md mq.Metadata) error {
+			gotEvent, gotMetadata = ev, md
+			return nil
+		})
+
+		msg := newMessage(`{"product_id":"1","name":"Product 1"}`)
+		require.NoError(t, handler(context.Background(), msg))
+
+		assert.Equal(t, productCreated{ProductID: "1", Name: "Product 1"}, gotEvent)
+		assert.Equal(t, msg.Metadata, gotMetadata)
+	})
+
+	t.Run("Should fail an invalid payload as non-retryable without calling the handler", func(t *testing.T) {
+		called := false
+		handler := mq.Typed(func(context.Context, productCreated, mq.Metadata) error {
+			called = true
+			return nil
+		})
+
+		err := handler(context.Background(), newMessage(`{"name":"Product 1"}`))
+		require.Error(t, err)
+		assert.True(t, mq.IsNonRetryable(err))
+		assert.True(t, validator.IsValidationError(errors.Unwrap(errors.Unwrap(err))))
+
+		err = handler(context.Background(), newMessage(`not json`))
+		require.Error(t, err)
+		assert.True(t, mq.IsNonRetryable(err))
+
+		assert.False(t, called)
+	})
+
+	t.Run("Should skip validation when disabled", func(t *testing.T) {
+		called := false
+		handler := mq.Typed(func(context.Context, productCreated, mq.Metadata) error {
+			called = true
+			return nil
+		}, mq.WithoutValidation())
+
+		require.NoError(t, handler(context.Background(), newMessage(`{"name":"Product 1"}`)))
+		assert.True(t, called)
+	})
+
+	t.Run("Should decode with a custom codec", func(t *testing.T) {
+		var got string
+		handler := mq.Typed(func(_ context.Context, v string, _ mq.Metadata) error {
+			got = v
+			return nil
+		}, mq.WithCodec(upperCodec{}))
+
+		require.NoError(t, handler(context.Background(), newMessage(`product`)))
+		assert.Equal(t, "PRODUCT", got)
+	})
+
+	t.Run("Should return the handler error", func(t *testing.T) {
+		handlerErr := errors.New("handler failed")
+		handler := mq.Typed(func(context.Context, map[string]any, mq.Metadata) error {
+			return handlerErr
+		})
+
+		err := handler(context.Background(), newMessage(`{}`))
+		require.ErrorIs(t, err, handlerErr)
+		assert.False(t, mq.IsNonRetryable(err))
+	})
+}
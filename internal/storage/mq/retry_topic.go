@@ -0,0 +1,89 @@
+package mq
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Headers set on records republished to a retry or dead-letter topic.
+const (
+	HeaderOriginalTopic     = "X-Original-Topic"
+	HeaderOriginalPartition = "X-Original-Partition"
+	HeaderOriginalOffset    = "X-Original-Offset"
+	HeaderError             = "X-Error"
+	// HeaderAttempt is the number of delivery rounds the record has failed, one for the original topic
+	// and one for each retry topic.
+	HeaderAttempt = "X-Attempt"
+)
+
+// RetryTopic returns the topic a record of topic is republished to for its n-th retry, starting at 1.
+func RetryTopic(topic string, n int) string {
+	return fmt.Sprintf("%s.retry.%d", topic, n)
+}
+
+// DeadLetterTopic returns the topic a record of topic is republished to once its retries are exhausted.
+func DeadLetterTopic(topic string) string {
+	return topic + ".dlq"
+}
+
+// IsDeadLetterTopic returns whether the topic is a dead-letter topic.
+func IsDeadLetterTopic(topic string) bool {
+	return strings.HasSuffix(topic, ".dlq")
+}
+
+var retryTopicRe = regexp.MustCompile(`^(.+)\.retry\.([1-9][0-9]*)$`)
+
+// parseRetryTopic returns the topic a retry topic is for and which retry it is for, false if the
+// topic is not a retry topic.
+func parseRetryTopic(topic string) (string, int, bool) {
+	m := retryTopicRe.FindStringSubmatch(topic)
+	if m == nil {
+		return "", 0, false
+	}
+
+	n, err := strconv.Atoi(m[2])
+	if err != nil {
+		return "", 0, false
+	}
+
+	return m[1], n, true
+}
+
+// buildRetryRecord builds the record republishing rec to topic after it failed with handleErr.
+// The original topic, partition and offset are kept from the first failure.
+func buildRetryRecord(rec *kgo.Record, topic string, attempt int, handleErr error) *kgo.Record {
+	headers := map[string]string{
+		HeaderOriginalTopic:     rec.Topic,
+		HeaderOriginalPartition: strconv.Itoa(int(rec.Partition)),
+		HeaderOriginalOffset:    strconv.FormatInt(rec.Offset, 10),
+	}
+
+	out := make([]kgo.RecordHeader, 0, len(rec.Headers)+5)
+	for _, h := range rec.Headers {
+		switch h.Key {
+		case HeaderOriginalTopic, HeaderOriginalPartition, HeaderOriginalOffset:
+			headers[h.Key] = string(h.Value)
+		case HeaderError, HeaderAttempt:
+		default:
+			out = append(out, h)
+		}
+	}
+
+	headers[HeaderError] = handleErr.Error()
+	headers[HeaderAttempt] = strconv.Itoa(attempt)
+
+	for _, k := range []string{HeaderOriginalTopic, HeaderOriginalPartition, HeaderOriginalOffset, HeaderError, HeaderAttempt} {
+		out = append(out, kgo.RecordHeader{Key: k, Value: []byte(headers[k])})
+	}
+
+	return &kgo.Record{
+		Topic:   topic,
+		Key:     rec.Key,
+		Value:   rec.Value,
+		Headers: out,
+	}
+}
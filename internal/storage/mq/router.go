@@ -0,0 +1,242 @@
+package mq
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"regexp"
+	"slices"
+	"sync"
+)
+
+// ErrUnhandledEventType is returned for a message of a topic with event type handlers when none is
+// registered for its event type and the topic has no other handler.
+var ErrUnhandledEventType = errors.New("no handler registered for event type")
+
+// Router maps topics to the handlers registered for them, wrapped with the global middlewares.
+// It is shared by the consumer implementations so they route messages the same way.
+//
+// A topic is routed to the handler registered for its exact name, then to the most specific
+// matching pattern, the one with the longest literal prefix, then to the fallback handler.
+// Messages of a topic with event type handlers are first routed by their event type, see EventType.
+type Router struct {
+	mu            sync.RWMutex
+	handlers      map[string]HandlerFunc
+	eventHandlers map[string]map[string]HandlerFunc
+	patterns      []patternRoute
+	fallback      HandlerFunc
+	middlewares   []Middleware
+	// routes caches the handlers topics are routed to, wrapped with the middlewares, so a chain
+	// is built once per topic. It is reset whenever a handler or middleware is added.
+	routes map[string]HandlerFunc
+}
+
+// patternRoute is a handler registered for the topics matching a pattern.
+type patternRoute struct {
+	pattern string
+	re      *regexp.Regexp
+	handler HandlerFunc
+}
+
+// NewRouter creates an empty router.
+func NewRouter() *Router {
+	return &Router{
+		handlers:      make(map[string]HandlerFunc),
+		eventHandlers: make(map[string]map[string]HandlerFunc),
+		routes:        make(map[string]HandlerFunc),
+	}
+}
+
+// Register registers the handler for the topic.
+// It fails if a handler is already registered for the topic.
+func (r *Router) Register(topic string, handler HandlerFunc) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.handlers[topic]; exists {
+		return fmt.Errorf("handler for topic %s already registered", topic)
+	}
+
+	r.handlers[topic] = handler
+	clear(r.routes)
+	return nil
+}
+
+// RegisterEventType registers the handler for the messages of the topic with the event type.
+// Messages of the topic with another event type go to the handler the topic is otherwise routed to,
+// or fail with ErrUnhandledEventType if there is none.
+// It fails if a handler is already registered for the topic and event type.
+func (r *Router) RegisterEventType(topic, eventType string, handler HandlerFunc) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.eventHandlers[topic][eventType]; exists {
+		return fmt.Errorf("handler for topic %s and event type %s already registered", topic, eventType)
+	}
+
+	// the maps are copied on write since the handlers returned by Handler keep them
+	byType := maps.Clone(r.eventHandlers[topic])
+	if byType == nil {
+		byType = make(map[string]HandlerFunc)
+	}
+	byType[eventType] = handler
+	r.eventHandlers[topic] = byType
+	clear(r.routes)
+	return nil
+}
+
+// RegisterPattern registers the handler for the topics fully matching the regular expression.
+// It fails if the pattern is invalid or a handler is already registered for it.
+func (r *Router) RegisterPattern(pattern string, handler HandlerFunc) error {
+	re, err := regexp.Compile(anchorPattern(pattern))
+	if err != nil {
+		return fmt.Errorf("compile topic pattern %s: %w", pattern, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if slices.ContainsFunc(r.patterns, func(p patternRoute) bool { return p.pattern == pattern }) {
+		return fmt.Errorf("handler for topic pattern %s already registered", pattern)
+	}
+
+	r.patterns = append(r.patterns, patternRoute{pattern: pattern, re: re, handler: handler})
+	slices.SortStableFunc(r.patterns, func(a, b patternRoute) int {
+		prefixA, _ := a.re.LiteralPrefix()
+		prefixB, _ := b.re.LiteralPrefix()
+		return cmp.Compare(len(prefixB), len(prefixA))
+	})
+	clear(r.routes)
+	return nil
+}
+
+// SetFallback sets the handler for the topics no other handler is registered for.
+func (r *Router) SetFallback(handler HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.fallback = handler
+	clear(r.routes)
+}
+
+// Use appends middlewares wrapping every handler, including the ones already registered.
+func (r *Router) Use(middlewares ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.middlewares = append(r.middlewares, middlewares...)
+	clear(r.routes)
+}
+
+// Handler returns the handler the topic is routed to, wrapped with the global middlewares.
+func (r *Router) Handler(topic string) (HandlerFunc, bool) {
+	r.mu.RLock()
+	fn, exists := r.routes[topic]
+	r.mu.RUnlock()
+	if exists {
+		return fn, true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if fn, exists := r.routes[topic]; exists {
+		return fn, true
+	}
+
+	fn, exists = r.handlers[topic]
+	if !exists {
+		fn, exists = r.match(topic)
+	}
+	if byType, ok := r.eventHandlers[topic]; ok {
+		fn, exists = dispatchEventType(byType, fn), true
+	}
+	if !exists {
+		return nil, false
+	}
+
+	fn = Chain(fn, r.middlewares...)
+	r.routes[topic] = fn
+	return fn, true
+}
+
+// dispatchEventType returns a handler passing messages to the handler of their event type, else to
+// fallback, and failing with ErrUnhandledEventType if it is nil.
+func dispatchEventType(byType map[string]HandlerFunc, fallback HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, msg Message) error {
+		eventType, ok := EventType(msg)
+		if ok {
+			if fn, ok := byType[eventType]; ok {
+				return fn(ctx, msg)
+			}
+		}
+
+		if fallback == nil {
+			return fmt.Errorf("%w %q", ErrUnhandledEventType, eventType)
+		}
+		return fallback(ctx, msg)
+	}
+}
+
+// skipUnhandledEvent returns nil instead of err if the message has an unhandled event type and such
+// messages are skipped, logging it.
+func skipUnhandledEvent(ctx context.Context, logger *slog.Logger, skip bool, msg Message, err error) error {
+	if !skip || !errors.Is(err, ErrUnhandledEventType) {
+		return err
+	}
+
+	eventType, _ := EventType(msg)
+	logger.WarnContext(ctx, "skipping message with unhandled event type",
+		slog.String("topic", msg.Topic),
+		slog.String("event_type", eventType),
+	)
+	return nil
+}
+
+// match returns the handler of the most specific pattern matching the topic, or the fallback.
+func (r *Router) match(topic string) (HandlerFunc, bool) {
+	for _, p := range r.patterns {
+		if p.re.MatchString(topic) {
+			return p.handler, true
+		}
+	}
+
+	return r.fallback, r.fallback != nil
+}
+
+// Registered returns whether a handler is registered for the exact topic, or one of its event types.
+func (r *Router) Registered(topic string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, exists := r.handlers[topic]
+	_, typed := r.eventHandlers[topic]
+	return exists || typed
+}
+
+// Topics returns the sorted list of topics with a registered handler, including event type handlers.
+func (r *Router) Topics() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	topics := make([]string, 0, len(r.handlers)+len(r.eventHandlers))
+	for topic := range r.handlers {
+		topics = append(topics, topic)
+	}
+	for topic := range r.eventHandlers {
+		if _, exists := r.handlers[topic]; !exists {
+			topics = append(topics, topic)
+		}
+	}
+	slices.Sort(topics)
+
+	return topics
+}
+
+// anchorPattern returns the regular expression matching the whole topic name with pattern.
+func anchorPattern(pattern string) string {
+	return "^(?:" + pattern + ")$"
+}
@@ -0,0 +1,157 @@
+package mq_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/outbox"
+)
+
+// named returns a handler returning an error carrying its name, to tell handlers apart.
+func named(name string) mq.HandlerFunc {
+	return func(context.Context, mq.Message) error {
+		return namedErr(name)
+	}
+}
+
+type namedErr string
+
+func (e namedErr) Error() string { return string(e) }
+
+func route(t *testing.T, r *mq.Router, topic string) string {
+	t.Helper()
+
+	fn, ok := r.Handler(topic)
+	if !ok {
+		return ""
+	}
+	return fn(context.Background(), mq.Message{}).Error()
+}
+
+func TestRouter(t *testing.T) {
+	t.Run("Should route to the exact topic before any pattern", func(t *testing.T) {
+		r := mq.NewRouter()
+		require.NoError(t, r.RegisterPattern(`product\..*`, named("pattern")))
+		require.NoError(t, r.Register("product.created", named("exact")))
+
+		assert.Equal(t, "exact", route(t, r, "product.created"))
+		assert.Equal(t, "pattern", route(t, r, "product.updated"))
+	})
+
+	t.Run("Should route to the most specific matching pattern", func(t *testing.T) {
+		r := mq.NewRouter()
+		require.NoError(t, r.RegisterPattern(`.*`, named("any")))
+		require.NoError(t, r.RegisterPattern(`product\.created\..*`, named("created")))
+		require.NoError(t, r.RegisterPattern(`product\..*`, named("product")))
+
+		assert.Equal(t, "created", route(t, r, "product.created.v2"))
+		assert.Equal(t, "product", route(t, r, "product.deleted"))
+		assert.Equal(t, "any", route(t, r, "order.created"))
+	})
+
+	t.Run("Should match patterns against the whole topic", func(t *testing.T) {
+		r := mq.NewRouter()
+		require.NoError(t, r.RegisterPattern(`product\.[a-z]+`, named("pattern")))
+
+		assert.Equal(t, "pattern", route(t, r, "product.created"))
+		assert.Empty(t, route(t, r, "legacy.product.created"))
+		assert.Empty(t, route(t, r, "product.created.v2"))
+	})
+
+	t.Run("Should route unmatched topics to the fallback", func(t *testing.T) {
+		r := mq.NewRouter()
+		require.NoError(t, r.RegisterPattern(`product\..*`, named("pattern")))
+
+		assert.Empty(t, route(t, r, "order.created"))
+
+		r.SetFallback(named("fallback"))
+		assert.Equal(t, "fallback", route(t, r, "order.created"))
+	})
+
+	t.Run("Should reject invalid and duplicate patterns", func(t *testing.T) {
+		r := mq.NewRouter()
+		require.NoError(t, r.RegisterPattern(`product\..*`, named("pattern")))
+
+		assert.Error(t, r.RegisterPattern(`product\..*`, named("again")))
+		assert.Error(t, r.RegisterPattern(`product(`, named("invalid")))
+	})
+
+	t.Run("Should build the middleware chain once per topic until a middleware is added", func(t *testing.T) {
+		r := mq.NewRouter()
+		require.NoError(t, r.Register("product.created", named("exact")))
+
+		built := 0
+		counting := func(next mq.HandlerFunc) mq.HandlerFunc {
+			built++
+			return next
+		}
+		r.Use(counting)
+
+		assert.Equal(t, "exact", route(t, r, "product.created"))
+		assert.Equal(t, "exact", route(t, r, "product.created"))
+		assert.Equal(t, 1, built)
+
+		r.Use(counting)
+		assert.Equal(t, "exact", route(t, r, "product.created"))
+		assert.Equal(t, 3, built)
+	})
+}
+
+func TestRouterEventType(t *testing.T) {
+	withType := func(eventType string) mq.Message {
+		return mq.Message{Metadata: mq.Metadata{Headers: map[string]string{outbox.EventTypeHeader: eventType}}}
+	}
+	dispatch := func(t *testing.T, r *mq.Router, msg mq.Message) string {
+		t.Helper()
+
+		fn, ok := r.Handler("product")
+		require.True(t, ok)
+		if err := fn(context.Background(), msg); err != nil {
+			return err.Error()
+		}
+		return ""
+	}
+
+	t.Run("Should route messages by the event type header", func(t *testing.T) {
+		r := mq.NewRouter()
+		require.NoError(t, r.RegisterEventType("product", "product.created", named("created")))
+		require.NoError(t, r.RegisterEventType("product", "product.deleted", named("deleted")))
+
+		assert.Equal(t, "created", dispatch(t, r, withType("product.created")))
+		assert.Equal(t, "deleted", dispatch(t, r, withType("product.deleted")))
+	})
+
+	t.Run("Should route messages by the envelope type field without a header", func(t *testing.T) {
+		r := mq.NewRouter()
+		require.NoError(t, r.RegisterEventType("product", "product.created", named("created")))
+
+		assert.Equal(t, "created", dispatch(t, r, mq.Message{Payload: []byte(`{"type":"product.created","data":{}}`)}))
+	})
+
+	t.Run("Should route other event types to the topic handler or fail them as unhandled", func(t *testing.T) {
+		r := mq.NewRouter()
+		require.NoError(t, r.RegisterEventType("product", "product.created", named("created")))
+
+		fn, ok := r.Handler("product")
+		require.True(t, ok)
+		assert.ErrorIs(t, fn(context.Background(), withType("product.updated")), mq.ErrUnhandledEventType)
+		assert.ErrorIs(t, fn(context.Background(), mq.Message{Payload: []byte(`not json`)}), mq.ErrUnhandledEventType)
+
+		require.NoError(t, r.Register("product", named("topic")))
+		assert.Equal(t, "topic", dispatch(t, r, withType("product.updated")))
+		assert.Equal(t, "created", dispatch(t, r, withType("product.created")))
+	})
+
+	t.Run("Should list topics with event type handlers and reject duplicates", func(t *testing.T) {
+		r := mq.NewRouter()
+		require.NoError(t, r.RegisterEventType("product", "product.created", named("created")))
+		require.NoError(t, r.Register("order.created", named("order")))
+
+		assert.Error(t, r.RegisterEventType("product", "product.created", named("again")))
+		assert.Equal(t, []string{"order.created", "product"}, r.Topics())
+	})
+}
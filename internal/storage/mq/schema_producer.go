@@ -0,0 +1,33 @@
+package mq
+
+import (
+	"context"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq/schemaregistry"
+)
+
+var _ Producer = (*SchemaProducer)(nil)
+
+// SchemaProducer validates payloads against the registered schema of their topic and frames them
+// with its id before handing them to the next producer.
+type SchemaProducer struct {
+	next       Producer
+	serializer *schemaregistry.Serializer
+}
+
+func NewSchemaProducer(next Producer, serializer *schemaregistry.Serializer) *SchemaProducer {
+	return &SchemaProducer{
+		next:       next,
+		serializer: serializer,
+	}
+}
+
+func (p *SchemaProducer) Produce(ctx context.Context, msg ProduceMsg) error {
+	payload, err := p.serializer.Serialize(ctx, msg.Topic, msg.Payload)
+	if err != nil {
+		return err
+	}
+	msg.Payload = payload
+
+	return p.next.Produce(ctx, msg)
+}
@@ -0,0 +1,182 @@
+package schemaregistry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
+)
+
+// contentType is the media type of the registry API.
+const contentType = "application/vnd.schemaregistry.v1+json"
+
+// Error codes of the registry API.
+const (
+	ErrorCodeSubjectNotFound = 40401
+	ErrorCodeVersionNotFound = 40402
+	ErrorCodeSchemaNotFound  = 40403
+)
+
+// APIError is an error answered by the registry.
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Code       int    `json:"error_code"`
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("schema registry responded with status %d, error code %d: %s", e.StatusCode, e.Code, e.Message)
+}
+
+var _ Registry = (*Client)(nil)
+
+// Client is a Registry talking to a Confluent compatible schema registry over its REST API.
+// Schemas looked up by id are cached, since they never change.
+type Client struct {
+	cfg    config.SchemaRegistry
+	client *http.Client
+
+	mu   sync.RWMutex
+	byID map[int]Schema
+}
+
+func NewClient(cfg config.SchemaRegistry) *Client {
+	return &Client{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		byID:   make(map[int]Schema),
+	}
+}
+
+// schemaPayload is a schema as sent to and answered by the registry, where an empty schema type
+// means Avro.
+type schemaPayload struct {
+	Schema     string `json:"schema"`
+	SchemaType string `json:"schemaType,omitempty"`
+}
+
+func newSchemaPayload(schema Schema) schemaPayload {
+	p := schemaPayload{Schema: schema.Schema}
+	if schema.Type != SchemaTypeAvro {
+		p.SchemaType = string(schema.Type)
+	}
+	return p
+}
+
+func (p schemaPayload) schema() Schema {
+	t := SchemaType(p.SchemaType)
+	if t == "" {
+		t = SchemaTypeAvro
+	}
+	return Schema{Type: t, Schema: p.Schema}
+}
+
+func (c *Client) Register(ctx context.Context, subject string, schema Schema) (int, error) {
+	var resp struct {
+		ID int `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/subjects/"+url.PathEscape(subject)+"/versions", newSchemaPayload(schema), &resp); err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	c.byID[resp.ID] = schema
+	c.mu.Unlock()
+
+	return resp.ID, nil
+}
+
+func (c *Client) SchemaByID(ctx context.Context, id int) (Schema, error) {
+	c.mu.RLock()
+	schema, ok := c.byID[id]
+	c.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	var resp schemaPayload
+	if err := c.do(ctx, http.MethodGet, "/schemas/ids/"+strconv.Itoa(id), nil, &resp); err != nil {
+		return Schema{}, err
+	}
+
+	schema = resp.schema()
+
+	c.mu.Lock()
+	c.byID[id] = schema
+	c.mu.Unlock()
+
+	return schema, nil
+}
+
+func (c *Client) Compatible(ctx context.Context, subject string, schema Schema) (bool, error) {
+	var resp struct {
+		IsCompatible bool `json:"is_compatible"`
+	}
+	err := c.do(ctx, http.MethodPost, "/compatibility/subjects/"+url.PathEscape(subject)+"/versions/latest", newSchemaPayload(schema), &resp)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && (apiErr.Code == ErrorCodeSubjectNotFound || apiErr.Code == ErrorCodeVersionNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return resp.IsCompatible, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.cfg.URL, "/")+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", contentType)
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.cfg.Username != "" {
+		req.SetBasicAuth(c.cfg.Username, c.cfg.Password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		if err := json.Unmarshal(respBody, apiErr); err != nil {
+			apiErr.Message = string(respBody)
+		}
+		return apiErr
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	return nil
+}
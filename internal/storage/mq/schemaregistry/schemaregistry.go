@@ -0,0 +1,53 @@
+// Package schemaregistry serializes message payloads with schemas registered in a Confluent
+// compatible schema registry.
+//
+// Serialized payloads use the Confluent wire format: a zero magic byte, the 4-byte big-endian id of
+// the schema, then the payload. JSON Schema is supported, Avro and Protobuf schemas can be registered
+// but not serialized yet.
+package schemaregistry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// SchemaType is the format of a schema, named as in the registry API.
+type SchemaType string
+
+const (
+	SchemaTypeAvro     SchemaType = "AVRO"
+	SchemaTypeJSON     SchemaType = "JSON"
+	SchemaTypeProtobuf SchemaType = "PROTOBUF"
+)
+
+// Schema is a schema definition and its format.
+type Schema struct {
+	Type   SchemaType
+	Schema string
+}
+
+// ErrIncompatibleSchema is returned when a schema is not compatible with the latest version of its subject.
+var ErrIncompatibleSchema = errors.New("schema is incompatible with the latest version of its subject")
+
+// Registry registers schemas under subjects and looks them up by id.
+type Registry interface {
+	// Register registers the schema under the subject and returns its id. Registering a schema
+	// that is already registered returns its existing id.
+	Register(ctx context.Context, subject string, schema Schema) (int, error)
+	// SchemaByID returns the schema with the id.
+	SchemaByID(ctx context.Context, id int) (Schema, error)
+	// Compatible returns whether the schema is compatible with the latest version of the subject,
+	// true if the subject has no version yet.
+	Compatible(ctx context.Context, subject string, schema Schema) (bool, error)
+}
+
+// ValueSubject returns the subject of the payload schemas of the topic, following the topic name strategy.
+func ValueSubject(topic string) string {
+	return topic + "-value"
+}
+
+// unsupportedTypeError is returned for schemas that cannot be serialized yet.
+func unsupportedTypeError(t SchemaType) error {
+	return fmt.Errorf("schema type %s is not supported yet", t)
+}
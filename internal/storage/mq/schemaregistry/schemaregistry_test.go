@@ -0,0 +1,249 @@
+package schemaregistry_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq/schemaregistry"
+	"github.com/tuanvumaihuynh/outbox-pattern/test/fake"
+)
+
+const productSchema = `{
+	"type": "object",
+	"required": ["product_id", "price"],
+	"properties": {
+		"product_id": {"type": "string", "format": "uuid"},
+		"price": {"type": "number"}
+	}
+}`
+
+func schemas(topic string) (schemaregistry.Schema, bool) {
+	if topic != "product.created" {
+		return schemaregistry.Schema{}, false
+	}
+	return schemaregistry.Schema{Type: schemaregistry.SchemaTypeJSON, Schema: productSchema}, true
+}
+
+func TestWireFormat(t *testing.T) {
+	t.Run("Should prefix the payload with the magic byte and the schema id", func(t *testing.T) {
+		data := schemaregistry.Encode(258, []byte(`{}`))
+		assert.Equal(t, []byte{0, 0, 0, 1, 2, '{', '}'}, data)
+
+		id, payload, err := schemaregistry.Decode(data)
+		require.NoError(t, err)
+		assert.Equal(t, 258, id)
+		assert.Equal(t, []byte(`{}`), payload)
+	})
+
+	t.Run("Should reject payloads that are not framed", func(t *testing.T) {
+		_, _, err := schemaregistry.Decode([]byte(`{"product_id":"1"}`))
+		require.ErrorIs(t, err, schemaregistry.ErrNotFramed)
+
+		_, _, err = schemaregistry.Decode([]byte{0, 0, 1})
+		require.ErrorIs(t, err, schemaregistry.ErrNotFramed)
+	})
+}
+
+func TestClient(t *testing.T) {
+	newClient := func(t *testing.T, registry *fake.SchemaRegistry) *schemaregistry.Client {
+		srv := httptest.NewServer(registry)
+		t.Cleanup(srv.Close)
+
+		return schemaregistry.NewClient(config.SchemaRegistry{URL: srv.URL, Timeout: 5 * time.Second})
+	}
+	schema, _ := schemas("product.created")
+
+	t.Run("Should register schemas and keep the id of a schema registered twice", func(t *testing.T) {
+		registry := fake.NewSchemaRegistry()
+		client := newClient(t, registry)
+
+		id, err := client.Register(context.Background(), "product.created-value", schema)
+		require.NoError(t, err)
+
+		again, err := client.Register(context.Background(), "product.created-value", schema)
+		require.NoError(t, err)
+		assert.Equal(t, id, again)
+		assert.Equal(t, []int{id}, registry.Versions("product.created-value"))
+
+		avro := schemaregistry.Schema{Type: schemaregistry.SchemaTypeAvro, Schema: `{"type":"string"}`}
+		avroID, err := client.Register(context.Background(), "product.deleted-value", avro)
+		require.NoError(t, err)
+		assert.NotEqual(t, id, avroID)
+
+		got, err := newClient(t, registry).SchemaByID(context.Background(), avroID)
+		require.NoError(t, err)
+		assert.Equal(t, avro, got)
+	})
+
+	t.Run("Should check compatibility against the latest version of the subject", func(t *testing.T) {
+		registry := fake.NewSchemaRegistry()
+		client := newClient(t, registry)
+
+		compatible, err := client.Compatible(context.Background(), "product.created-value", schema)
+		require.NoError(t, err)
+		assert.True(t, compatible, "a subject without versions accepts any schema")
+
+		_, err = client.Register(context.Background(), "product.created-value", schema)
+		require.NoError(t, err)
+		registry.SetIncompatible("product.created-value")
+
+		compatible, err = client.Compatible(context.Background(), "product.created-value", schemaregistry.Schema{
+			Type:   schemaregistry.SchemaTypeJSON,
+			Schema: `{"type":"string"}`,
+		})
+		require.NoError(t, err)
+		assert.False(t, compatible)
+	})
+
+	t.Run("Should return the registry error of an unknown schema id", func(t *testing.T) {
+		client := newClient(t, fake.NewSchemaRegistry())
+
+		_, err := client.SchemaByID(context.Background(), 42)
+
+		var apiErr *schemaregistry.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+		assert.Equal(t, schemaregistry.ErrorCodeSchemaNotFound, apiErr.Code)
+	})
+
+	t.Run("Should authenticate with basic auth", func(t *testing.T) {
+		registry := fake.NewSchemaRegistry()
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			registry.ServeHTTP(w, r)
+		}))
+		defer srv.Close()
+
+		client := schemaregistry.NewClient(config.SchemaRegistry{URL: srv.URL, Username: "user", Password: "secret"})
+		_, err := client.Register(context.Background(), "product.created-value", schema)
+		require.NoError(t, err)
+
+		client = schemaregistry.NewClient(config.SchemaRegistry{URL: srv.URL})
+		_, err = client.Register(context.Background(), "product.created-value", schema)
+		var apiErr *schemaregistry.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	})
+}
+
+// blockingRegistry blocks the compatibility checks of a subject until it is released.
+type blockingRegistry struct {
+	*fake.SchemaRegistry
+	subject string
+	release chan struct{}
+}
+
+func (r blockingRegistry) Compatible(ctx context.Context, subject string, schema schemaregistry.Schema) (bool, error) {
+	if subject == r.subject {
+		<-r.release
+	}
+	return r.SchemaRegistry.Compatible(ctx, subject, schema)
+}
+
+func TestSerde(t *testing.T) {
+	const payload = `{"product_id":"4f1d2a3e-8c53-4d6e-9a1b-2c3d4e5f6a7b","price":9.5}`
+
+	t.Run("Should serialize with the registered schema id and deserialize back", func(t *testing.T) {
+		registry := fake.NewSchemaRegistry()
+		serializer := schemaregistry.NewSerializer(registry, schemas)
+
+		data, err := serializer.Serialize(context.Background(), "product.created", []byte(payload))
+		require.NoError(t, err)
+
+		versions := registry.Versions("product.created-value")
+		require.Len(t, versions, 1)
+		id, _, err := schemaregistry.Decode(data)
+		require.NoError(t, err)
+		assert.Equal(t, versions[0], id)
+
+		got, err := schemaregistry.NewDeserializer(registry).Deserialize(context.Background(), data)
+		require.NoError(t, err)
+		assert.JSONEq(t, payload, string(got))
+	})
+
+	t.Run("Should reject payloads not matching the schema", func(t *testing.T) {
+		serializer := schemaregistry.NewSerializer(fake.NewSchemaRegistry(), schemas)
+
+		_, err := serializer.Serialize(context.Background(), "product.created", []byte(`{"product_id":"not-a-uuid","price":1}`))
+		require.Error(t, err)
+
+		_, err = serializer.Serialize(context.Background(), "product.created", []byte(`{"product_id":"4f1d2a3e-8c53-4d6e-9a1b-2c3d4e5f6a7b"}`))
+		require.Error(t, err)
+	})
+
+	t.Run("Should reject topics without a schema", func(t *testing.T) {
+		serializer := schemaregistry.NewSerializer(fake.NewSchemaRegistry(), schemas)
+
+		_, err := serializer.Serialize(context.Background(), "order.created", []byte(`{}`))
+		require.ErrorContains(t, err, "no schema for topic order.created")
+	})
+
+	t.Run("Should not register a schema incompatible with the latest version", func(t *testing.T) {
+		registry := fake.NewSchemaRegistry()
+		_, err := registry.Register(context.Background(), "product.created-value", schemaregistry.Schema{
+			Type:   schemaregistry.SchemaTypeJSON,
+			Schema: `{"type":"object"}`,
+		})
+		require.NoError(t, err)
+		registry.SetIncompatible("product.created-value")
+
+		serializer := schemaregistry.NewSerializer(registry, schemas)
+		_, err = serializer.Serialize(context.Background(), "product.created", []byte(payload))
+		require.ErrorIs(t, err, schemaregistry.ErrIncompatibleSchema)
+		assert.Len(t, registry.Versions("product.created-value"), 1)
+	})
+
+	t.Run("Should not serialize schema types that are not supported yet", func(t *testing.T) {
+		serializer := schemaregistry.NewSerializer(fake.NewSchemaRegistry(), func(string) (schemaregistry.Schema, bool) {
+			return schemaregistry.Schema{Type: schemaregistry.SchemaTypeProtobuf, Schema: `syntax = "proto3";`}, true
+		})
+
+		_, err := serializer.Serialize(context.Background(), "product.created", []byte(payload))
+		require.ErrorContains(t, err, "schema type PROTOBUF is not supported yet")
+	})
+
+	t.Run("Should pass through payloads that are not framed", func(t *testing.T) {
+		got, err := schemaregistry.NewDeserializer(fake.NewSchemaRegistry()).Deserialize(context.Background(), []byte(payload))
+		require.NoError(t, err)
+		assert.Equal(t, payload, string(got))
+	})
+
+	t.Run("Should fail deserializing an unknown schema id", func(t *testing.T) {
+		_, err := schemaregistry.NewDeserializer(fake.NewSchemaRegistry()).Deserialize(context.Background(),
+			schemaregistry.Encode(7, []byte(payload)))
+		require.ErrorContains(t, err, "get schema 7")
+	})
+
+	t.Run("Should not block other subjects while a subject is being registered", func(t *testing.T) {
+		registry := blockingRegistry{
+			SchemaRegistry: fake.NewSchemaRegistry(),
+			subject:        "product.created-value",
+			release:        make(chan struct{}),
+		}
+		serializer := schemaregistry.NewSerializer(registry, func(string) (schemaregistry.Schema, bool) {
+			return schemaregistry.Schema{Type: schemaregistry.SchemaTypeJSON, Schema: productSchema}, true
+		})
+
+		blocked := make(chan error, 1)
+		go func() {
+			_, err := serializer.Serialize(context.Background(), "product.created", []byte(payload))
+			blocked <- err
+		}()
+
+		_, err := serializer.Serialize(context.Background(), "product.updated", []byte(payload))
+		require.NoError(t, err)
+
+		close(registry.release)
+		require.NoError(t, <-blocked)
+	})
+}
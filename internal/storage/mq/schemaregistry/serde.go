@@ -0,0 +1,202 @@
+package schemaregistry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/eventschema"
+)
+
+// SchemaSource returns the schema of the payloads published to the topic, false if it has none.
+type SchemaSource func(topic string) (Schema, bool)
+
+// JSONSchemas returns a source of the JSON Schemas looked up by topic, such as the ones
+// declared in api-contract.
+func JSONSchemas(lookup func(topic string) ([]byte, bool)) SchemaSource {
+	return func(topic string) (Schema, bool) {
+		b, ok := lookup(topic)
+		if !ok {
+			return Schema{}, false
+		}
+
+		return Schema{Type: SchemaTypeJSON, Schema: string(b)}, true
+	}
+}
+
+// registered is a schema registered for a subject, compiled for validation.
+type registered struct {
+	id  int
+	sch *jsonschema.Schema
+}
+
+// Serializer validates payloads against the schema of their topic and frames them with its id.
+//
+// The schema of a topic is checked for compatibility with the latest version of its subject and
+// registered the first time a payload of the topic is serialized, an incompatible schema fails
+// every publish to the topic until it is fixed.
+type Serializer struct {
+	registry Registry
+	schemas  SchemaSource
+	subjects cache[string, registered]
+}
+
+func NewSerializer(registry Registry, schemas SchemaSource) *Serializer {
+	return &Serializer{
+		registry: registry,
+		schemas:  schemas,
+	}
+}
+
+// Serialize validates the payload against the schema of the topic and returns it in the wire format.
+func (s *Serializer) Serialize(ctx context.Context, topic string, payload []byte) ([]byte, error) {
+	r, err := s.register(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := eventschema.ValidateJSON(r.sch, payload); err != nil {
+		return nil, fmt.Errorf("serialize %s payload: %w", topic, err)
+	}
+
+	return Encode(r.id, payload), nil
+}
+
+func (s *Serializer) register(ctx context.Context, topic string) (registered, error) {
+	subject := ValueSubject(topic)
+	return s.subjects.get(subject, func() (registered, error) {
+		return s.registerSubject(ctx, topic, subject)
+	})
+}
+
+func (s *Serializer) registerSubject(ctx context.Context, topic, subject string) (registered, error) {
+	schema, ok := s.schemas(topic)
+	if !ok {
+		return registered{}, fmt.Errorf("no schema for topic %s", topic)
+	}
+	if schema.Type != SchemaTypeJSON {
+		return registered{}, unsupportedTypeError(schema.Type)
+	}
+
+	sch, err := eventschema.Compile(schema.Schema)
+	if err != nil {
+		return registered{}, fmt.Errorf("schema for topic %s: %w", topic, err)
+	}
+
+	compatible, err := s.registry.Compatible(ctx, subject, schema)
+	if err != nil {
+		return registered{}, fmt.Errorf("check compatibility of subject %s: %w", subject, err)
+	}
+	if !compatible {
+		return registered{}, fmt.Errorf("subject %s: %w", subject, ErrIncompatibleSchema)
+	}
+
+	id, err := s.registry.Register(ctx, subject, schema)
+	if err != nil {
+		return registered{}, fmt.Errorf("register schema of subject %s: %w", subject, err)
+	}
+
+	return registered{id: id, sch: sch}, nil
+}
+
+// Deserializer validates framed payloads against the schema of their id and unframes them.
+type Deserializer struct {
+	registry Registry
+	schemas  cache[int, *jsonschema.Schema]
+}
+
+func NewDeserializer(registry Registry) *Deserializer {
+	return &Deserializer{
+		registry: registry,
+	}
+}
+
+// Deserialize returns the payload of data in the wire format, validated against its schema.
+// Payloads that are not framed are returned as is, so messages published before the registry was
+// enabled are still consumed.
+func (d *Deserializer) Deserialize(ctx context.Context, data []byte) ([]byte, error) {
+	if !IsFramed(data) {
+		return data, nil
+	}
+
+	id, payload, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	sch, err := d.schema(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := eventschema.ValidateJSON(sch, payload); err != nil {
+		return nil, fmt.Errorf("deserialize payload of schema %d: %w", id, err)
+	}
+
+	return payload, nil
+}
+
+func (d *Deserializer) schema(ctx context.Context, id int) (*jsonschema.Schema, error) {
+	return d.schemas.get(id, func() (*jsonschema.Schema, error) {
+		return d.fetchSchema(ctx, id)
+	})
+}
+
+func (d *Deserializer) fetchSchema(ctx context.Context, id int) (*jsonschema.Schema, error) {
+	schema, err := d.registry.SchemaByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get schema %d: %w", id, err)
+	}
+	if schema.Type != SchemaTypeJSON {
+		return nil, unsupportedTypeError(schema.Type)
+	}
+
+	sch, err := eventschema.Compile(schema.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("schema %d: %w", id, err)
+	}
+
+	return sch, nil
+}
+
+// cache holds the values loaded by key. Loading a key only blocks the callers of the same key, so a
+// slow registry call for one subject or id does not hold up the others. Failed loads are not cached.
+type cache[K comparable, V any] struct {
+	mu      sync.Mutex
+	entries map[K]*cacheEntry[V]
+}
+
+type cacheEntry[V any] struct {
+	mu     sync.Mutex
+	v      V
+	loaded bool
+}
+
+func (c *cache[K, V]) get(key K, load func() (V, error)) (V, error) {
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = make(map[K]*cacheEntry[V])
+	}
+	e, ok := c.entries[key]
+	if !ok {
+		e = &cacheEntry[V]{}
+		c.entries[key] = e
+	}
+	c.mu.Unlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.loaded {
+		return e.v, nil
+	}
+
+	v, err := load()
+	if err != nil {
+		return v, err
+	}
+	e.v, e.loaded = v, true
+	return v, nil
+}
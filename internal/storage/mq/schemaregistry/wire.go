@@ -0,0 +1,39 @@
+package schemaregistry
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// magicByte starts every payload in the Confluent wire format.
+const magicByte byte = 0
+
+// headerSize is the size of the magic byte and the schema id.
+const headerSize = 5
+
+// ErrNotFramed is returned when decoding a payload that is not in the Confluent wire format.
+var ErrNotFramed = errors.New("payload is not in the schema registry wire format")
+
+// Encode prefixes the payload with the magic byte and the schema id.
+func Encode(id int, payload []byte) []byte {
+	b := make([]byte, headerSize, headerSize+len(payload))
+	b[0] = magicByte
+	//nolint:gosec
+	binary.BigEndian.PutUint32(b[1:headerSize], uint32(id))
+	return append(b, payload...)
+}
+
+// Decode returns the schema id and the payload of data in the Confluent wire format.
+func Decode(data []byte) (int, []byte, error) {
+	if !IsFramed(data) {
+		return 0, nil, fmt.Errorf("decode: %w", ErrNotFramed)
+	}
+
+	return int(binary.BigEndian.Uint32(data[1:headerSize])), data[headerSize:], nil
+}
+
+// IsFramed returns whether data starts with the magic byte and a schema id.
+func IsFramed(data []byte) bool {
+	return len(data) >= headerSize && data[0] == magicByte
+}
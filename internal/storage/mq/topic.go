@@ -0,0 +1,56 @@
+package mq
+
+import (
+	"fmt"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/topic"
+)
+
+// WithRetryTopics returns the specs along with the specs of the retry and dead-letter topics the
+// consumer republishes failing records of their topics to, as enabled by the config.
+// Those topics are never compacted, since records are republished with their original key.
+func WithRetryTopics(specs []topic.Spec, cfg config.Kafka) []topic.Spec {
+	all := make([]topic.Spec, 0, len(specs)*(len(cfg.ConsumerRetryTopicDelays)+2))
+	for _, spec := range specs {
+		all = append(all, spec)
+
+		retry := spec
+		retry.CleanupPolicy = topic.CleanupPolicyDelete
+		retry.MinCompactionLag = 0
+
+		for n := range cfg.ConsumerRetryTopicDelays {
+			retry.Name = RetryTopic(spec.Name, n+1)
+			all = append(all, retry)
+		}
+		if cfg.ConsumerDeadLetter {
+			retry.Name = DeadLetterTopic(spec.Name)
+			all = append(all, retry)
+		}
+	}
+
+	return all
+}
+
+// TopicDrift is a setting of an existing topic that differs from its spec.
+type TopicDrift struct {
+	Topic   string
+	Setting string
+	Want    string
+	Got     string
+}
+
+func (d TopicDrift) String() string {
+	return fmt.Sprintf("topic %s: %s is %s, want %s", d.Topic, d.Setting, d.Got, d.Want)
+}
+
+// TopicReport is the outcome of checking or provisioning topics against their specs.
+type TopicReport struct {
+	// Missing are the topics that do not exist when checking.
+	Missing []string
+	// Created are the topics created when provisioning.
+	Created []string
+	// Drifts are the settings of existing topics that differ from their specs. They are only
+	// reported, since changing partitions or replication of a live topic needs care.
+	Drifts []TopicDrift
+}
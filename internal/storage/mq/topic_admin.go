@@ -0,0 +1,222 @@
+package mq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/topic"
+)
+
+// TopicAdmin checks and provisions topics against their specs.
+type TopicAdmin struct {
+	cl  *kgo.Client
+	adm *kadm.Client
+}
+
+func NewTopicAdmin(ctx context.Context, cfg config.Kafka) (*TopicAdmin, error) {
+	opts, err := clientOpts(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	cl, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create kafka client: %w", err)
+	}
+
+	pingCtx, pingCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer pingCancel()
+	if err := cl.Ping(pingCtx); err != nil {
+		cl.Close()
+		return nil, fmt.Errorf("ping kafka: %w", err)
+	}
+
+	return &TopicAdmin{cl: cl, adm: kadm.NewClient(cl)}, nil
+}
+
+func (a *TopicAdmin) Close() {
+	a.cl.Close()
+}
+
+// Check reports the topics that are missing and the settings of existing topics that drifted
+// from their specs, without changing anything.
+func (a *TopicAdmin) Check(ctx context.Context, specs []topic.Spec) (TopicReport, error) {
+	return a.reconcile(ctx, specs, false)
+}
+
+// Provision creates the missing topics with their specs and reports the settings of existing
+// topics that drifted from their specs.
+func (a *TopicAdmin) Provision(ctx context.Context, specs []topic.Spec) (TopicReport, error) {
+	return a.reconcile(ctx, specs, true)
+}
+
+func (a *TopicAdmin) reconcile(ctx context.Context, specs []topic.Spec, create bool) (TopicReport, error) {
+	var report TopicReport
+	if len(specs) == 0 {
+		return report, nil
+	}
+
+	names := make([]string, len(specs))
+	for i, spec := range specs {
+		names[i] = spec.Name
+	}
+
+	details, err := a.adm.ListTopics(ctx, names...)
+	if err != nil {
+		return report, fmt.Errorf("list topics: %w", err)
+	}
+
+	var existing []topic.Spec
+	for _, spec := range specs {
+		if details.Has(spec.Name) {
+			if err := details[spec.Name].Err; err != nil {
+				return report, fmt.Errorf("describe topic %s: %w", spec.Name, err)
+			}
+			existing = append(existing, spec)
+			continue
+		}
+
+		if !create {
+			report.Missing = append(report.Missing, spec.Name)
+			continue
+		}
+
+		partitions, replicationFactor := spec.Partitions, spec.ReplicationFactor
+		if partitions == 0 {
+			partitions = -1
+		}
+		if replicationFactor == 0 {
+			replicationFactor = -1
+		}
+		if _, err := a.adm.CreateTopic(ctx, partitions, replicationFactor, spec.Configs(), spec.Name); err != nil {
+			return report, fmt.Errorf("create topic %s: %w", spec.Name, err)
+		}
+		report.Created = append(report.Created, spec.Name)
+	}
+
+	if len(existing) == 0 {
+		return report, nil
+	}
+
+	existingNames := make([]string, len(existing))
+	for i, spec := range existing {
+		existingNames[i] = spec.Name
+	}
+	configs, err := a.adm.DescribeTopicConfigs(ctx, existingNames...)
+	if err != nil {
+		return report, fmt.Errorf("describe topic configs: %w", err)
+	}
+
+	for _, spec := range existing {
+		partitions := details[spec.Name].Partitions
+		if spec.Partitions != 0 && int32(len(partitions)) != spec.Partitions {
+			report.Drifts = append(report.Drifts, TopicDrift{
+				Topic:   spec.Name,
+				Setting: "partitions",
+				Want:    strconv.Itoa(int(spec.Partitions)),
+				Got:     strconv.Itoa(len(partitions)),
+			})
+		}
+		if spec.ReplicationFactor != 0 && partitions.NumReplicas() != int(spec.ReplicationFactor) {
+			report.Drifts = append(report.Drifts, TopicDrift{
+				Topic:   spec.Name,
+				Setting: "replication factor",
+				Want:    strconv.Itoa(int(spec.ReplicationFactor)),
+				Got:     strconv.Itoa(partitions.NumReplicas()),
+			})
+		}
+
+		rc, err := configs.On(spec.Name, nil)
+		if err == nil {
+			err = rc.Err
+		}
+		if err != nil {
+			return report, fmt.Errorf("describe topic %s configs: %w", spec.Name, err)
+		}
+		actual := make(map[string]string, len(rc.Configs))
+		for _, c := range rc.Configs {
+			if c.Value != nil {
+				actual[c.Key] = *c.Value
+			}
+		}
+		wanted := spec.Configs()
+		for _, key := range slices.Sorted(maps.Keys(wanted)) {
+			if got, want := actual[key], *wanted[key]; got != want {
+				report.Drifts = append(report.Drifts, TopicDrift{
+					Topic:   spec.Name,
+					Setting: key,
+					Want:    want,
+					Got:     got,
+				})
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// ErrMissingTopics is returned on startup when declared topics are missing and nothing creates them.
+var ErrMissingTopics = errors.New("declared topics are missing")
+
+// ReconcileTopics checks the topics on startup as enabled by the config, creating the missing ones
+// if cfg.TopicsCreate is set, and logs what is missing or drifted. It fails with ErrMissingTopics if
+// topics are still missing, unless the clients are allowed to auto-create them.
+func ReconcileTopics(ctx context.Context, cfg config.Kafka, specs []topic.Spec, logger *slog.Logger) error {
+	if !cfg.TopicsCheck && !cfg.TopicsCreate {
+		return nil
+	}
+
+	admin, err := NewTopicAdmin(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("create topic admin: %w", err)
+	}
+	defer admin.Close()
+
+	specs = WithRetryTopics(specs, cfg)
+
+	var report TopicReport
+	if cfg.TopicsCreate {
+		report, err = admin.Provision(ctx, specs)
+	} else {
+		report, err = admin.Check(ctx, specs)
+	}
+	if err != nil {
+		return err
+	}
+
+	LogTopicReport(ctx, logger, report)
+	if len(report.Missing) > 0 && !cfg.TopicsAutoCreate {
+		return fmt.Errorf("%w: %s", ErrMissingTopics, strings.Join(report.Missing, ", "))
+	}
+
+	return nil
+}
+
+// LogTopicReport logs the created and missing topics and the drifted settings of the report.
+func LogTopicReport(ctx context.Context, logger *slog.Logger, report TopicReport) {
+	for _, name := range report.Created {
+		logger.InfoContext(ctx, "created topic", slog.String("topic", name))
+	}
+	for _, name := range report.Missing {
+		logger.WarnContext(ctx, "topic is missing", slog.String("topic", name))
+	}
+	for _, drift := range report.Drifts {
+		logger.WarnContext(ctx, "topic setting drifted",
+			slog.String("topic", drift.Topic),
+			slog.String("setting", drift.Setting),
+			slog.String("want", drift.Want),
+			slog.String("got", drift.Got),
+		)
+	}
+}
@@ -0,0 +1,134 @@
+package mq_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kfake"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/topic"
+	"github.com/tuanvumaihuynh/outbox-pattern/test/kafkatest"
+)
+
+func TestTopicAdmin(t *testing.T) {
+	spec := topic.Spec{
+		Name:          "product.created",
+		Partitions:    3,
+		Retention:     24 * time.Hour,
+		CleanupPolicy: topic.CleanupPolicyDelete,
+	}
+
+	t.Run("Should report missing topics without creating them when checking", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t)
+
+		admin, err := mq.NewTopicAdmin(context.Background(), cfg)
+		require.NoError(t, err)
+		defer admin.Close()
+
+		report, err := admin.Check(context.Background(), []topic.Spec{spec})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"product.created"}, report.Missing)
+		assert.Empty(t, report.Created)
+
+		topics, err := kadm.NewClient(kafkatest.NewClient(t, cfg)).ListTopics(context.Background(), spec.Name)
+		require.NoError(t, err)
+		assert.False(t, topics.Has(spec.Name))
+	})
+
+	t.Run("Should create missing topics with their settings", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t)
+
+		admin, err := mq.NewTopicAdmin(context.Background(), cfg)
+		require.NoError(t, err)
+		defer admin.Close()
+
+		report, err := admin.Provision(context.Background(), []topic.Spec{spec})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"product.created"}, report.Created)
+		assert.Empty(t, report.Drifts)
+
+		report, err = admin.Check(context.Background(), []topic.Spec{spec})
+		require.NoError(t, err)
+		assert.Empty(t, report.Missing)
+		assert.Empty(t, report.Drifts)
+
+		adm := kadm.NewClient(kafkatest.NewClient(t, cfg))
+		topics, err := adm.ListTopics(context.Background(), spec.Name)
+		require.NoError(t, err)
+		assert.Len(t, topics[spec.Name].Partitions, 3)
+
+		configs, err := adm.DescribeTopicConfigs(context.Background(), spec.Name)
+		require.NoError(t, err)
+		rc, err := configs.On(spec.Name, nil)
+		require.NoError(t, err)
+		values := map[string]string{}
+		for _, c := range rc.Configs {
+			if c.Value != nil {
+				values[c.Key] = *c.Value
+			}
+		}
+		assert.Equal(t, "86400000", values["retention.ms"])
+		assert.Equal(t, "delete", values["cleanup.policy"])
+	})
+
+	t.Run("Should report drifted settings of existing topics", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t, kfake.SeedTopics(1, "product.created"))
+
+		admin, err := mq.NewTopicAdmin(context.Background(), cfg)
+		require.NoError(t, err)
+		defer admin.Close()
+
+		report, err := admin.Provision(context.Background(), []topic.Spec{spec})
+		require.NoError(t, err)
+		assert.Empty(t, report.Created)
+
+		settings := map[string]mq.TopicDrift{}
+		for _, drift := range report.Drifts {
+			settings[drift.Setting] = drift
+		}
+		assert.Equal(t, mq.TopicDrift{Topic: "product.created", Setting: "partitions", Want: "3", Got: "1"}, settings["partitions"])
+		assert.Equal(t, "86400000", settings["retention.ms"].Want)
+	})
+
+	t.Run("Should fail on startup when declared topics are missing and nothing creates them", func(t *testing.T) {
+		_, cfg := kafkatest.NewCluster(t)
+		cfg.TopicsCheck = true
+		cfg.TopicsAutoCreate = false
+
+		err := mq.ReconcileTopics(context.Background(), cfg, []topic.Spec{spec}, discardLogger)
+		require.ErrorIs(t, err, mq.ErrMissingTopics)
+		assert.ErrorContains(t, err, "product.created")
+
+		cfg.TopicsCreate = true
+		require.NoError(t, mq.ReconcileTopics(context.Background(), cfg, []topic.Spec{spec}, discardLogger))
+
+		cfg.TopicsCreate = false
+		require.NoError(t, mq.ReconcileTopics(context.Background(), cfg, []topic.Spec{spec}, discardLogger))
+	})
+
+	t.Run("Should declare the retry and dead-letter topics enabled by the config", func(t *testing.T) {
+		spec := spec
+		spec.CleanupPolicy = topic.CleanupPolicyCompact
+
+		specs := mq.WithRetryTopics([]topic.Spec{spec}, config.Kafka{
+			ConsumerRetryTopicDelays: []time.Duration{time.Second, time.Minute},
+			ConsumerDeadLetter:       true,
+		})
+
+		require.Len(t, specs, 4)
+		assert.Equal(t, spec, specs[0])
+		assert.Equal(t, mq.RetryTopic("product.created", 1), specs[1].Name)
+		assert.Equal(t, mq.RetryTopic("product.created", 2), specs[2].Name)
+		assert.Equal(t, mq.DeadLetterTopic("product.created"), specs[3].Name)
+		for _, s := range specs[1:] {
+			assert.Equal(t, int32(3), s.Partitions)
+			assert.Equal(t, topic.CleanupPolicyDelete, s.CleanupPolicy)
+		}
+	})
+}
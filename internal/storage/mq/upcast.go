@@ -0,0 +1,99 @@
+package mq
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// UpcastFunc transforms the JSON payload of an event from a version to the next one.
+type UpcastFunc func(payload json.RawMessage) (json.RawMessage, error)
+
+// Upcasters holds, per event type, the chain of upcasters transforming older versions of its
+// payload into the current one, the version following the last upcaster registered for it.
+type Upcasters struct {
+	mu    sync.RWMutex
+	steps map[string]map[int]UpcastFunc
+}
+
+// NewUpcasters creates an empty upcaster registry.
+func NewUpcasters() *Upcasters {
+	return &Upcasters{
+		steps: make(map[string]map[int]UpcastFunc),
+	}
+}
+
+// Register registers fn transforming the payload of the event type from version from to from+1.
+// It fails if an upcaster is already registered for the event type and version.
+func (u *Upcasters) Register(eventType string, from int, fn UpcastFunc) error {
+	if from < 1 {
+		return fmt.Errorf("upcaster for event type %s: version must be positive", eventType)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if _, exists := u.steps[eventType][from]; exists {
+		return fmt.Errorf("upcaster for event type %s from version %d already registered", eventType, from)
+	}
+
+	if u.steps[eventType] == nil {
+		u.steps[eventType] = make(map[int]UpcastFunc)
+	}
+	u.steps[eventType][from] = fn
+	return nil
+}
+
+// Current returns the current version of the event type, DefaultEventVersion if it has no upcaster.
+func (u *Upcasters) Current(eventType string) int {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	return u.current(eventType)
+}
+
+func (u *Upcasters) current(eventType string) int {
+	current := DefaultEventVersion
+	for from := range u.steps[eventType] {
+		current = max(current, from+1)
+	}
+	return current
+}
+
+// Check fails if the current version of the event type is not version, e.g. when the version its
+// producer publishes was bumped without registering the upcaster from the previous one.
+func (u *Upcasters) Check(eventType string, version int) error {
+	if current := u.Current(eventType); current != version {
+		return fmt.Errorf("event type %s is published at version %d but upcast to version %d", eventType, version, current)
+	}
+	return nil
+}
+
+// Upcast transforms the payload of the event type from the version to the current one, applying the
+// upcasters in order, and returns it with the current version. Payloads of the current version are
+// returned as is. It fails if an upcaster of the chain is missing or fails, and with a NonRetryable
+// error for a version newer than the current one, which the handler cannot decode.
+func (u *Upcasters) Upcast(eventType string, version int, payload json.RawMessage) (json.RawMessage, int, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	current := u.current(eventType)
+	if version > current {
+		return nil, 0, NonRetryable(fmt.Errorf("event type %s version %d is newer than the current version %d", eventType, version, current))
+	}
+
+	for ; version < current; version++ {
+		fn, ok := u.steps[eventType][version]
+		if !ok {
+			return nil, 0, fmt.Errorf("no upcaster for event type %s from version %d", eventType, version)
+		}
+
+		upcasted, err := fn(payload)
+		if err != nil {
+			return nil, 0, fmt.Errorf("upcast event type %s from version %d: %w", eventType, version, err)
+		}
+		payload = upcasted
+	}
+
+	return payload, version, nil
+}
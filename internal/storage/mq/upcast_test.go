@@ -0,0 +1,146 @@
+package mq_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/outbox"
+)
+
+// productCreatedUpcasters upcasts product.created from v1, with a float price, to v2, with a decimal
+// string price, then to v3, with the price nested with its currency.
+func productCreatedUpcasters(t *testing.T) *mq.Upcasters {
+	t.Helper()
+
+	u := mq.NewUpcasters()
+	require.NoError(t, u.Register("product.created", 1, func(payload json.RawMessage) (json.RawMessage, error) {
+		var v map[string]any
+		if err := json.Unmarshal(payload, &v); err != nil {
+			return nil, err
+		}
+		price, ok := v["price"].(float64)
+		if !ok {
+			return nil, errors.New("price is not a number")
+		}
+		v["price"] = strconv.FormatFloat(price, 'f', -1, 64)
+		return json.Marshal(v)
+	}))
+	require.NoError(t, u.Register("product.created", 2, func(payload json.RawMessage) (json.RawMessage, error) {
+		var v map[string]any
+		if err := json.Unmarshal(payload, &v); err != nil {
+			return nil, err
+		}
+		v["price"] = map[string]any{"amount": v["price"], "currency": "USD"}
+		return json.Marshal(v)
+	}))
+
+	return u
+}
+
+func TestUpcasters(t *testing.T) {
+	t.Run("Should apply the chain of upcasters from the version to the current one", func(t *testing.T) {
+		u := productCreatedUpcasters(t)
+		assert.Equal(t, 3, u.Current("product.created"))
+
+		payload, version, err := u.Upcast("product.created", 1, json.RawMessage(`{"product_id":"1","price":9.5}`))
+		require.NoError(t, err)
+		assert.Equal(t, 3, version)
+		assert.JSONEq(t, `{"product_id":"1","price":{"amount":"9.5","currency":"USD"}}`, string(payload))
+
+		payload, version, err = u.Upcast("product.created", 2, json.RawMessage(`{"product_id":"1","price":"9.50"}`))
+		require.NoError(t, err)
+		assert.Equal(t, 3, version)
+		assert.JSONEq(t, `{"product_id":"1","price":{"amount":"9.50","currency":"USD"}}`, string(payload))
+	})
+
+	t.Run("Should leave payloads of the current version as is", func(t *testing.T) {
+		u := productCreatedUpcasters(t)
+
+		payload, version, err := u.Upcast("product.created", 3, json.RawMessage(`{"price":{}}`))
+		require.NoError(t, err)
+		assert.Equal(t, 3, version)
+		assert.JSONEq(t, `{"price":{}}`, string(payload))
+
+		payload, version, err = u.Upcast("order.created", 1, json.RawMessage(`{"id":1}`))
+		require.NoError(t, err)
+		assert.Equal(t, 1, version)
+		assert.JSONEq(t, `{"id":1}`, string(payload))
+	})
+
+	t.Run("Should fail payloads of a newer version as non-retryable", func(t *testing.T) {
+		u := productCreatedUpcasters(t)
+
+		_, _, err := u.Upcast("product.created", 4, json.RawMessage(`{"price":{}}`))
+		require.Error(t, err)
+		assert.True(t, mq.IsNonRetryable(err))
+
+		_, _, err = u.Upcast("order.created", 2, json.RawMessage(`{"id":1}`))
+		assert.True(t, mq.IsNonRetryable(err))
+	})
+
+	t.Run("Should check the current version against the published one", func(t *testing.T) {
+		u := productCreatedUpcasters(t)
+
+		require.NoError(t, u.Check("product.created", 3))
+		require.NoError(t, u.Check("order.created", 1))
+		assert.Error(t, u.Check("product.created", 4))
+		assert.Error(t, u.Check("order.created", 2))
+	})
+
+	t.Run("Should fail when the chain has a gap", func(t *testing.T) {
+		u := mq.NewUpcasters()
+		require.NoError(t, u.Register("product.created", 2, func(payload json.RawMessage) (json.RawMessage, error) {
+			return payload, nil
+		}))
+
+		_, _, err := u.Upcast("product.created", 1, json.RawMessage(`{}`))
+		require.ErrorContains(t, err, "no upcaster for event type product.created from version 1")
+	})
+
+	t.Run("Should fail when an upcaster of the chain fails", func(t *testing.T) {
+		u := productCreatedUpcasters(t)
+
+		_, _, err := u.Upcast("product.created", 1, json.RawMessage(`{"price":"9.5"}`))
+		require.ErrorContains(t, err, "upcast event type product.created from version 1: price is not a number")
+	})
+
+	t.Run("Should reject duplicate and invalid registrations", func(t *testing.T) {
+		u := productCreatedUpcasters(t)
+		noop := func(payload json.RawMessage) (json.RawMessage, error) { return payload, nil }
+
+		require.Error(t, u.Register("product.created", 1, noop))
+		require.Error(t, u.Register("product.created", 0, noop))
+	})
+}
+
+func TestEventVersion(t *testing.T) {
+	t.Run("Should read the version from the header, then the envelope, else default to 1", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			msg  mq.Message
+			want int
+		}{
+			"header":   {mq.Message{Metadata: mq.Metadata{Headers: map[string]string{outbox.EventVersionHeader: "2"}}, Payload: []byte(`{"version":3}`)}, 2},
+			"envelope": {mq.Message{Payload: []byte(`{"version":3}`)}, 3},
+			"none":     {mq.Message{Payload: []byte(`{"id":1}`)}, mq.DefaultEventVersion},
+			"not json": {mq.Message{Payload: []byte(`not json`)}, mq.DefaultEventVersion},
+		} {
+			version, err := mq.EventVersion(tc.msg)
+			require.NoError(t, err, name)
+			assert.Equal(t, tc.want, version, name)
+		}
+	})
+
+	t.Run("Should reject versions that are not positive integers", func(t *testing.T) {
+		_, err := mq.EventVersion(mq.Message{Metadata: mq.Metadata{Headers: map[string]string{outbox.EventVersionHeader: "v2"}}})
+		require.Error(t, err)
+
+		_, err = mq.EventVersion(mq.Message{Payload: []byte(`{"version":0}`)})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,161 @@
+package mq
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/correlationid"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/webhook"
+)
+
+var _ Producer = (*WebhookProducer)(nil)
+
+// WebhookStatusError is returned when a subscriber answers with a non-2xx status code.
+// The relay retries the message after RetryAfter when it is set.
+type WebhookStatusError struct {
+	URL        string
+	StatusCode int
+	// RetryAfter is the delay requested by the subscriber through the Retry-After header, zero if absent.
+	RetryAfter time.Duration
+}
+
+func (e *WebhookStatusError) Error() string {
+	return fmt.Sprintf("webhook %s responded with status %d", e.URL, e.StatusCode)
+}
+
+// WebhookProducer delivers messages as signed HTTP POST requests to the subscribers of their topic.
+//
+// Delivery is at least once per subscriber: a message failing for any subscriber is retried as a
+// whole, so the subscribers that already accepted it receive it again and must deduplicate it by
+// its message id header.
+type WebhookProducer struct {
+	cfg    config.Webhook
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewWebhookProducer(cfg config.Webhook) *WebhookProducer {
+	return &WebhookProducer{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		now:    time.Now,
+	}
+}
+
+// Produce delivers the message to every subscriber of its topic.
+// It fails if any of the subscribers could not be delivered to.
+func (p *WebhookProducer) Produce(ctx context.Context, msg ProduceMsg) error {
+	ctx, span := tracer.Start(ctx, "WebhookProducer.Produce",
+		trace.WithAttributes(
+			attribute.String("topic", msg.Topic),
+		),
+	)
+	defer span.End()
+
+	urls := p.cfg.Subscriptions[msg.Topic]
+	if len(urls) == 0 {
+		err := fmt.Errorf("no webhook subscription for topic %s", msg.Topic)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "no webhook subscription")
+		return err
+	}
+
+	var errs []error
+	for _, url := range urls {
+		if err := p.post(ctx, url, msg); err != nil {
+			errs = append(errs, fmt.Errorf("deliver to %s: %w", url, err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to deliver webhook")
+		return err
+	}
+
+	span.SetStatus(codes.Ok, "")
+	return nil
+}
+
+func (p *WebhookProducer) post(ctx context.Context, url string, msg ProduceMsg) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(msg.Payload))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	for k, v := range msg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	// the current span becomes the parent of the subscriber's span
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	if correlationID, ok := correlationid.FromContext(ctx); ok {
+		req.Header.Set(correlationid.Header, correlationID)
+	}
+
+	timestamp := p.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.TopicHeader, msg.Topic)
+	req.Header.Set(webhook.TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(p.cfg.SignatureHeader, webhook.Sign([]byte(p.cfg.Secret), timestamp, msg.Payload))
+	if msg.PartitionKey != nil {
+		req.Header.Set(webhook.PartitionKeyHeader, *msg.PartitionKey)
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer res.Body.Close()
+
+	// drain the body so the connection can be reused
+	//nolint:errcheck
+	io.Copy(io.Discard, io.LimitReader(res.Body, 1<<16))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return &WebhookStatusError{
+			URL:        url,
+			StatusCode: res.StatusCode,
+			RetryAfter: parseRetryAfter(res.Header.Get("Retry-After"), p.now()),
+		}
+	}
+
+	return nil
+}
+
+// parseRetryAfter parses a Retry-After header value, either in seconds or as an HTTP date.
+// It returns zero if the value is absent or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,150 @@
+package mq_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/correlationid"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/outbox"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/ptr"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/webhook"
+)
+
+func newWebhookConfig(urls ...string) config.Webhook {
+	return config.Webhook{
+		Subscriptions:   config.WebhookSubscriptions{"product.created": urls},
+		Secret:          "secret",
+		SignatureHeader: "X-Outbox-Signature",
+		Timeout:         5 * time.Second,
+	}
+}
+
+func TestWebhookProducer(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	t.Run("Should deliver signed message with trace context", func(t *testing.T) {
+		var got *http.Request
+		var body []byte
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r
+			body, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer srv.Close()
+
+		headers := map[string]string{
+			"traceparent":        traceparent,
+			correlationid.Header: "correlation-id",
+		}
+		ctx := outbox.ExtractContextFromHeaders(context.Background(), headers)
+
+		p := mq.NewWebhookProducer(newWebhookConfig(srv.URL))
+		err := p.Produce(ctx, mq.ProduceMsg{
+			Topic:        "product.created",
+			Headers:      headers,
+			Payload:      []byte(`{"product_id":"1"}`),
+			PartitionKey: ptr.New("1"),
+		})
+		require.NoError(t, err)
+
+		require.NotNil(t, got)
+		assert.Equal(t, http.MethodPost, got.Method)
+		assert.JSONEq(t, `{"product_id":"1"}`, string(body))
+		assert.Equal(t, "application/json", got.Header.Get("Content-Type"))
+		assert.Equal(t, "product.created", got.Header.Get(webhook.TopicHeader))
+		assert.Equal(t, "1", got.Header.Get(webhook.PartitionKeyHeader))
+		assert.Equal(t, "correlation-id", got.Header.Get(correlationid.Header))
+		assert.Contains(t, got.Header.Get("traceparent"), "4bf92f3577b34da6a3ce929d0e0e4736")
+
+		timestamp, err := strconv.ParseInt(got.Header.Get(webhook.TimestampHeader), 10, 64)
+		require.NoError(t, err)
+		assert.True(t, webhook.Verify([]byte("secret"), timestamp, body, got.Header.Get("X-Outbox-Signature")))
+	})
+
+	t.Run("Should fail on non-2xx response", func(t *testing.T) {
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer srv.Close()
+
+		p := mq.NewWebhookProducer(newWebhookConfig(srv.URL))
+		err := p.Produce(context.Background(), mq.ProduceMsg{
+			Topic:   "product.created",
+			Payload: []byte(`{}`),
+		})
+
+		var statusErr *mq.WebhookStatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, http.StatusInternalServerError, statusErr.StatusCode)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("Should return the Retry-After of the subscriber without waiting", func(t *testing.T) {
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer srv.Close()
+
+		p := mq.NewWebhookProducer(newWebhookConfig(srv.URL))
+		start := time.Now()
+		err := p.Produce(context.Background(), mq.ProduceMsg{
+			Topic:   "product.created",
+			Payload: []byte(`{}`),
+		})
+
+		var statusErr *mq.WebhookStatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, 2*time.Minute, statusErr.RetryAfter)
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("Should fail when any subscriber fails", func(t *testing.T) {
+		ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer ok.Close()
+		ko := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer ko.Close()
+
+		p := mq.NewWebhookProducer(newWebhookConfig(ok.URL, ko.URL))
+		err := p.Produce(context.Background(), mq.ProduceMsg{
+			Topic:   "product.created",
+			Payload: []byte(`{}`),
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ko.URL)
+		assert.NotContains(t, err.Error(), ok.URL+":")
+	})
+
+	t.Run("Should fail for topic without subscription", func(t *testing.T) {
+		p := mq.NewWebhookProducer(newWebhookConfig("http://localhost"))
+		err := p.Produce(context.Background(), mq.ProduceMsg{
+			Topic:   "unknown",
+			Payload: []byte(`{}`),
+		})
+		require.Error(t, err)
+	})
+}
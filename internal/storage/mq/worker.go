@@ -0,0 +1,110 @@
+package mq
+
+import (
+	"context"
+	"slices"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
+)
+
+// topicPartition identifies a partition of a topic.
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+// partitionWorker handles the fetched records of a partition assigned to the consumer in its own
+// goroutine, so a slow partition does not hold up the others.
+type partitionWorker struct {
+	records chan []*kgo.Record
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// concurrent returns whether records are handled by partition workers rather than by the poll loop.
+func (c *KafkaConsumer) concurrent() bool {
+	return c.cfg.ConsumerConcurrency != config.ConsumerConcurrencySequential
+}
+
+// startWorkers starts a worker for each of the partitions that has none.
+func (c *KafkaConsumer) startWorkers(partitions map[string][]int32) {
+	c.workersMu.Lock()
+	defer c.workersMu.Unlock()
+
+	for topic, ids := range partitions {
+		for _, id := range ids {
+			c.startWorker(topicPartition{topic, id})
+		}
+	}
+}
+
+// startWorker starts a worker for the partition if it has none and returns it.
+// The caller must hold workersMu.
+func (c *KafkaConsumer) startWorker(tp topicPartition) *partitionWorker {
+	if w, ok := c.workers[tp]; ok {
+		return w
+	}
+
+	ctx, cancel := context.WithCancel(c.handleCtx)
+	w := &partitionWorker{
+		// the partition is not fetched until its records are done, at most one fetch is queued
+		records: make(chan []*kgo.Record, 1),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	c.workers[tp] = w
+
+	go c.runWorker(ctx, tp, w)
+	return w
+}
+
+// runWorker handles the records handed to the worker until it is stopped, resuming its partition
+// after each fetch unless the partition is delayed.
+func (c *KafkaConsumer) runWorker(ctx context.Context, tp topicPartition, w *partitionWorker) {
+	defer close(w.done)
+
+	for records := range w.records {
+		if !c.handlePartition(ctx, records) {
+			c.client().ResumeFetchPartitions(map[string][]int32{tp.topic: {tp.partition}})
+		}
+	}
+}
+
+// dispatch hands the fetched records of a partition to its worker. The partition is paused until
+// the worker is done with them, so the records after them are fetched from where the worker left off.
+func (c *KafkaConsumer) dispatch(p kgo.FetchTopicPartition) {
+	c.client().PauseFetchPartitions(map[string][]int32{p.Topic: {p.Partition}})
+
+	c.workersMu.Lock()
+	defer c.workersMu.Unlock()
+
+	c.startWorker(topicPartition{p.Topic, p.Partition}).records <- p.Records
+}
+
+// stopWorkers stops the workers of the partitions, or all of them if partitions is nil, and waits
+// until they are done with the records handed to them. If cancel is set, the records being handled
+// are cancelled first.
+func (c *KafkaConsumer) stopWorkers(partitions map[string][]int32, cancel bool) {
+	c.workersMu.Lock()
+	var stopped []*partitionWorker
+	for tp, w := range c.workers {
+		if partitions != nil && !slices.Contains(partitions[tp.topic], tp.partition) {
+			continue
+		}
+
+		if cancel {
+			w.cancel()
+		}
+		close(w.records)
+		delete(c.workers, tp)
+		stopped = append(stopped, w)
+	}
+	c.workersMu.Unlock()
+
+	for _, w := range stopped {
+		<-w.done
+		w.cancel()
+	}
+}
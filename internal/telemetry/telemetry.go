@@ -7,9 +7,11 @@ import (
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"google.golang.org/grpc/credentials"
@@ -51,22 +53,9 @@ func InitTracer(ctx context.Context, cfg config.Otel) (CleanupFunc, error) {
 		return nil, fmt.Errorf("create exporter: %w", err)
 	}
 
-	resourceAttrs := []attribute.KeyValue{
-		attribute.String("service.name", cfg.ServiceName),
-		attribute.String("library.language", "go"),
-	}
-
-	if cfg.K8sPodName != "" && cfg.K8sNamespace != "" {
-		resourceAttrs = append(resourceAttrs, attribute.String("k8s.pod.name", cfg.K8sPodName))
-		resourceAttrs = append(resourceAttrs, attribute.String("k8s.namespace", cfg.K8sNamespace))
-	}
-
-	resources, err := resource.New(
-		ctx,
-		resource.WithAttributes(resourceAttrs...),
-	)
+	resources, err := newResource(ctx, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("set resources: %w", err)
+		return nil, err
 	}
 
 	otel.SetTracerProvider(
@@ -96,3 +85,81 @@ func InitTracer(ctx context.Context, cfg config.Otel) (CleanupFunc, error) {
 
 	return cleanup, nil
 }
+
+// InitMeter initializes the OpenTelemetry meter, exporting metrics to the collector periodically.
+// Should be called at the start of the application to get the meter set globally.
+func InitMeter(ctx context.Context, cfg config.Otel) (CleanupFunc, error) {
+	if cfg.CollectorURL == "" {
+		// no-op
+		return func(context.Context) error {
+			return nil
+		}, nil
+	}
+
+	var secureOpt otlpmetricgrpc.Option
+
+	if !cfg.Insecure {
+		secureOpt = otlpmetricgrpc.WithTLSCredentials(credentials.NewClientTLSFromCert(nil, ""))
+	} else {
+		secureOpt = otlpmetricgrpc.WithInsecure()
+	}
+
+	exporter, err := otlpmetricgrpc.New(
+		ctx,
+		secureOpt,
+		otlpmetricgrpc.WithEndpoint(cfg.CollectorURL),
+		otlpmetricgrpc.WithHeaders(map[string]string{
+			"Authorization": cfg.CollectorAuth,
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create exporter: %w", err)
+	}
+
+	resources, err := newResource(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)),
+		sdkmetric.WithResource(resources),
+	)
+	otel.SetMeterProvider(provider)
+
+	cleanup := func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		// shutting down the provider exports the metrics collected since the last export
+		if err := provider.Shutdown(ctx); err != nil {
+			return fmt.Errorf("shutdown OpenTelemetry meter provider: %w", err)
+		}
+
+		return nil
+	}
+
+	return cleanup, nil
+}
+
+func newResource(ctx context.Context, cfg config.Otel) (*resource.Resource, error) {
+	resourceAttrs := []attribute.KeyValue{
+		attribute.String("service.name", cfg.ServiceName),
+		attribute.String("library.language", "go"),
+	}
+
+	if cfg.K8sPodName != "" && cfg.K8sNamespace != "" {
+		resourceAttrs = append(resourceAttrs, attribute.String("k8s.pod.name", cfg.K8sPodName))
+		resourceAttrs = append(resourceAttrs, attribute.String("k8s.namespace", cfg.K8sNamespace))
+	}
+
+	resources, err := resource.New(
+		ctx,
+		resource.WithAttributes(resourceAttrs...),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("set resources: %w", err)
+	}
+
+	return resources, nil
+}
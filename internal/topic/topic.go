@@ -0,0 +1,54 @@
+package topic
+
+import (
+	"strconv"
+	"time"
+)
+
+// Cleanup policies of a topic.
+const (
+	CleanupPolicyDelete  = "delete"
+	CleanupPolicyCompact = "compact"
+)
+
+// Spec declares the settings a topic is provisioned with.
+// Settings left at their zero value keep the broker defaults and are not checked for drift.
+type Spec struct {
+	Name string
+	// Partitions is the number of partitions, it can only be increased once the topic exists.
+	Partitions int32
+	// ReplicationFactor is how many brokers hold a copy of each partition.
+	ReplicationFactor int16
+	// Retention is how long records are kept, negative to keep them forever.
+	Retention time.Duration
+	// CleanupPolicy is CleanupPolicyDelete, CleanupPolicyCompact or both, comma separated.
+	CleanupPolicy string
+	// MinCompactionLag is how long a record stays uncompacted, for compacted topics.
+	MinCompactionLag time.Duration
+}
+
+// Configs returns the topic configs of the settings that are set.
+func (s Spec) Configs() map[string]*string {
+	configs := make(map[string]*string)
+	if s.Retention != 0 {
+		configs["retention.ms"] = durationMs(s.Retention)
+	}
+	if s.CleanupPolicy != "" {
+		configs["cleanup.policy"] = &s.CleanupPolicy
+	}
+	if s.MinCompactionLag != 0 {
+		configs["min.compaction.lag.ms"] = durationMs(s.MinCompactionLag)
+	}
+
+	return configs
+}
+
+func durationMs(d time.Duration) *string {
+	ms := int64(-1)
+	if d > 0 {
+		ms = d.Milliseconds()
+	}
+
+	v := strconv.FormatInt(ms, 10)
+	return &v
+}
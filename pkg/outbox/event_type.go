@@ -0,0 +1,9 @@
+package outbox
+
+// EventTypeHeader carries the type of the event a message holds, so several event types can share
+// a topic and keep their relative order.
+const EventTypeHeader = "X-Event-Type"
+
+// EventVersionHeader carries the version of the schema of the event a message holds, so consumers can
+// upcast older versions to the one they handle.
+const EventVersionHeader = "X-Event-Version"
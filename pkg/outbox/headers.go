@@ -3,7 +3,6 @@ package outbox
 import (
 	"context"
 
-	"github.com/twmb/franz-go/pkg/kgo"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/propagation"
 
@@ -24,7 +23,8 @@ func BuildHeaders(ctx context.Context) map[string]string {
 	return headers
 }
 
-// ExtractContextFromHeaders extracts trace context and correlation ID from headers map and injects them into context.
+// ExtractContextFromHeaders extracts trace context and correlation ID from headers map
+// and injects them into context.
 func ExtractContextFromHeaders(ctx context.Context, headers map[string]string) context.Context {
 	propagator := otel.GetTextMapPropagator()
 	ctx = propagator.Extract(ctx, propagation.MapCarrier(headers))
@@ -35,14 +35,3 @@ func ExtractContextFromHeaders(ctx context.Context, headers map[string]string) c
 
 	return ctx
 }
-
-// InjectCorrelationIDFromRecord extracts correlation ID from Kafka record headers and injects it into context.
-// Returns the context with correlation ID if found in headers, otherwise returns the original context.
-func InjectCorrelationIDFromRecord(ctx context.Context, rec *kgo.Record) context.Context {
-	for _, header := range rec.Headers {
-		if header.Key == correlationid.Header {
-			return correlationid.NewContext(ctx, string(header.Value))
-		}
-	}
-	return ctx
-}
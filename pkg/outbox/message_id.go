@@ -0,0 +1,5 @@
+package outbox
+
+// MessageIDHeader carries the id of the outbox message a message was relayed from.
+// Consumers use it to recognize redelivered messages.
+const MessageIDHeader = "X-Outbox-Message-Id"
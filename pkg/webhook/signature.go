@@ -0,0 +1,42 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+const (
+	// TimestampHeader is the header carrying the unix timestamp the signature was computed at.
+	TimestampHeader = "X-Outbox-Timestamp"
+
+	// TopicHeader is the header carrying the outbox topic of the delivered message.
+	TopicHeader = "X-Outbox-Topic"
+
+	// PartitionKeyHeader is the header carrying the partition key of the delivered message, if any.
+	PartitionKeyHeader = "X-Outbox-Partition-Key"
+
+	signaturePrefix = "sha256="
+)
+
+// Sign computes the signature of a webhook body sent at the given unix timestamp.
+// The signature is the hex encoded HMAC-SHA256 of "<timestamp>.<body>", prefixed with "sha256=".
+func Sign(secret []byte, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is a valid signature of body sent at the given unix timestamp.
+func Verify(secret []byte, timestamp int64, body []byte, signature string) bool {
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
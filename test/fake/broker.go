@@ -0,0 +1,231 @@
+package fake
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+)
+
+var (
+	_ mq.Producer = (*Broker)(nil)
+	_ mq.Consumer = (*Broker)(nil)
+)
+
+// Broker is an in-memory message broker acting as both mq.Producer and mq.Consumer.
+//
+// Produced messages are stored in order and delivered, in the same order, to the handlers
+// registered for their topic once the consumer runs. Faults can be injected on produce:
+// failing or silently dropping the Nth produce call, and delaying every produce call.
+// Delivery stops at the first message of a paused topic until the topic is resumed.
+type Broker struct {
+	router *mq.Router
+
+	mu           sync.Mutex
+	produceCalls int
+	failures     map[int]error
+	drops        map[int]struct{}
+	delay        time.Duration
+	produced     []mq.ProduceMsg
+	delivered    int
+	consumeErrs  []error
+	paused       map[string]struct{}
+	notifyChan   chan struct{}
+}
+
+// NewBroker creates an empty in-memory broker.
+func NewBroker() *Broker {
+	return &Broker{
+		router:     mq.NewRouter(),
+		failures:   make(map[int]error),
+		drops:      make(map[int]struct{}),
+		paused:     make(map[string]struct{}),
+		notifyChan: make(chan struct{}, 1),
+	}
+}
+
+// FailNthProduce makes the nth call to Produce, counting from 1, return err.
+func (b *Broker) FailNthProduce(n int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures[n] = err
+}
+
+// DropNthProduce makes the nth call to Produce, counting from 1, succeed without storing the message.
+func (b *Broker) DropNthProduce(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.drops[n] = struct{}{}
+}
+
+// SetProduceDelay makes every call to Produce wait for d before completing.
+func (b *Broker) SetProduceDelay(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.delay = d
+}
+
+func (b *Broker) Produce(ctx context.Context, msg mq.ProduceMsg) error {
+	b.mu.Lock()
+	b.produceCalls++
+	n := b.produceCalls
+	delay := b.delay
+	b.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err, ok := b.failures[n]; ok {
+		return err
+	}
+	if _, ok := b.drops[n]; ok {
+		return nil
+	}
+
+	msg.Headers = maps.Clone(msg.Headers)
+	msg.Payload = slices.Clone(msg.Payload)
+	b.produced = append(b.produced, msg)
+
+	select {
+	case b.notifyChan <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Produced returns the messages stored by the broker, in produce order.
+func (b *Broker) Produced() []mq.ProduceMsg {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return slices.Clone(b.produced)
+}
+
+// ProduceCalls returns how many times Produce was called, including failed and dropped calls.
+func (b *Broker) ProduceCalls() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.produceCalls
+}
+
+// ConsumeErrors returns the errors returned by handlers so far.
+func (b *Broker) ConsumeErrors() []error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return slices.Clone(b.consumeErrs)
+}
+
+func (b *Broker) Use(middlewares ...mq.Middleware) {
+	b.router.Use(middlewares...)
+}
+
+func (b *Broker) RegisterEventHandler(topic, eventType string, handler mq.HandlerFunc) error {
+	return b.router.RegisterEventType(topic, eventType, handler)
+}
+
+func (b *Broker) Pause(topic string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.paused[topic] = struct{}{}
+}
+
+func (b *Broker) Resume(topic string) {
+	b.mu.Lock()
+	delete(b.paused, topic)
+	b.mu.Unlock()
+
+	select {
+	case b.notifyChan <- struct{}{}:
+	default:
+	}
+}
+
+func (b *Broker) RegisterHandler(topic string, handler mq.HandlerFunc) error {
+	return b.router.Register(topic, handler)
+}
+
+func (b *Broker) Run(ctx context.Context) (mq.CleanupFunc, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	doneChan := make(chan struct{})
+	go func() {
+		defer close(doneChan)
+
+		for {
+			b.deliverPending(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-b.notifyChan:
+			}
+		}
+	}()
+
+	cleanup := func() {
+		cancel()
+		<-doneChan
+	}
+
+	return cleanup, nil
+}
+
+func (b *Broker) deliverPending(ctx context.Context) {
+	for ctx.Err() == nil {
+		b.mu.Lock()
+		if b.delivered == len(b.produced) {
+			b.mu.Unlock()
+			return
+		}
+		msg := b.produced[b.delivered]
+		if _, paused := b.paused[msg.Topic]; paused {
+			b.mu.Unlock()
+			return
+		}
+		offset := int64(b.delivered)
+		b.delivered++
+		b.mu.Unlock()
+
+		fn, exists := b.router.Handler(msg.Topic)
+		if !exists {
+			continue
+		}
+
+		var key []byte
+		if msg.PartitionKey != nil {
+			key = []byte(*msg.PartitionKey)
+		}
+
+		if err := fn(ctx, mq.Message{
+			Metadata: mq.Metadata{
+				Topic:   msg.Topic,
+				Key:     key,
+				Headers: msg.Headers,
+				Offset:  offset,
+			},
+			Payload: msg.Payload,
+		}); err != nil {
+			b.mu.Lock()
+			b.consumeErrs = append(b.consumeErrs, err)
+			b.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db"
+)
+
+// ErrNotSupported is returned by the fake DB for raw SQL operations.
+var ErrNotSupported = errors.New("fake: operation not supported")
+
+var _ db.DB = (*DB)(nil)
+
+// DB is a db.DB whose transactions run the given function and undo what fake repositories
+// registered with OnRollback if it fails. It is meant to be used with the fake repositories,
+// raw SQL operations are not supported.
+type DB struct {
+	inTx     bool
+	rollback []func()
+}
+
+// NewDB creates a new fake DB.
+func NewDB() *DB {
+	return &DB{}
+}
+
+func (d *DB) Exec(context.Context, string, ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, ErrNotSupported
+}
+
+func (d *DB) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	return nil, ErrNotSupported
+}
+
+func (d *DB) QueryRow(context.Context, string, ...any) pgx.Row {
+	return errRow{err: ErrNotSupported}
+}
+
+func (d *DB) CopyFrom(context.Context, pgx.Identifier, []string, pgx.CopyFromSource) (int64, error) {
+	return 0, ErrNotSupported
+}
+
+func (d *DB) SendBatch(context.Context, *pgx.Batch) pgx.BatchResults {
+	return errBatchResults{err: ErrNotSupported}
+}
+
+func (d *DB) WithTx(_ context.Context, txFunc func(db.DB) error) error {
+	if d.inTx {
+		return txFunc(d)
+	}
+
+	tx := &DB{inTx: true}
+	if err := txFunc(tx); err != nil {
+		for _, undo := range slices.Backward(tx.rollback) {
+			undo()
+		}
+		return err
+	}
+
+	return nil
+}
+
+// OnRollback registers undo to be called if the transaction of d fails.
+// It is a no-op outside of a transaction.
+func (d *DB) OnRollback(undo func()) {
+	if d.inTx {
+		d.rollback = append(d.rollback, undo)
+	}
+}
+
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(...any) error {
+	return r.err
+}
+
+type errBatchResults struct {
+	err error
+}
+
+func (r errBatchResults) Exec() (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, r.err
+}
+
+func (r errBatchResults) Query() (pgx.Rows, error) {
+	return nil, r.err
+}
+
+func (r errBatchResults) QueryRow() pgx.Row {
+	return errRow(r)
+}
+
+func (r errBatchResults) Close() error {
+	return r.err
+}
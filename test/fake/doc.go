@@ -0,0 +1,3 @@
+// Package fake provides in-memory implementations of the storage interfaces
+// (message queue, database and repositories) for fast and deterministic tests.
+package fake
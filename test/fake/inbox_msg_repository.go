@@ -0,0 +1,78 @@
+package fake
+
+import (
+	"context"
+	"sync"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/repository"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db"
+)
+
+var _ repository.InboxMsgRepository = (*InboxMsgRepository)(nil)
+
+type inboxKey struct {
+	consumer  string
+	messageID string
+}
+
+type inboxStore struct {
+	mu   sync.Mutex
+	msgs map[inboxKey]string
+}
+
+// InboxMsgRepository is an in-memory repository.InboxMsgRepository.
+// Messages recorded through a fake DB transaction are removed again if the transaction fails.
+type InboxMsgRepository struct {
+	store *inboxStore
+	db    db.DB
+}
+
+// NewInboxMsgRepository creates an empty in-memory inbox message repository.
+func NewInboxMsgRepository() *InboxMsgRepository {
+	return &InboxMsgRepository{
+		store: &inboxStore{msgs: map[inboxKey]string{}},
+	}
+}
+
+func (r *InboxMsgRepository) WithDB(db db.DB) repository.InboxMsgRepository {
+	return &InboxMsgRepository{
+		store: r.store,
+		db:    db,
+	}
+}
+
+func (r *InboxMsgRepository) CreateInboxMsg(_ context.Context, params repository.CreateInboxMsgParams) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key := inboxKey{consumer: params.Consumer, messageID: params.MessageID}
+	if _, ok := r.store.msgs[key]; ok {
+		return false, nil
+	}
+	r.store.msgs[key] = params.Topic
+
+	if tx, ok := r.db.(*DB); ok {
+		tx.OnRollback(func() {
+			r.store.mu.Lock()
+			defer r.store.mu.Unlock()
+			delete(r.store.msgs, key)
+		})
+	}
+
+	return true, nil
+}
+
+// Count returns how many messages are recorded for the consumer.
+func (r *InboxMsgRepository) Count(consumer string) int {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	n := 0
+	for key := range r.store.msgs {
+		if key.consumer == consumer {
+			n++
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,418 @@
+package fake
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/apperr"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/model"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/repository"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db"
+)
+
+// OutboxMsg is an outbox message stored by the fake OutboxMsgRepository.
+type OutboxMsg struct {
+	ID            uuid.UUID
+	Topic         string
+	Headers       map[string]string
+	Payload       json.RawMessage
+	PartitionKey  *string
+	CreatedAt     time.Time
+	ProcessedAt   *time.Time
+	Error         *string
+	Attempts      int32
+	NextAttemptAt *time.Time
+}
+
+var _ repository.OutboxMsgRepository = (*OutboxMsgRepository)(nil)
+
+// OutboxMsgRepository is an in-memory repository.OutboxMsgRepository.
+// Messages are listed in insertion order.
+type OutboxMsgRepository struct {
+	mu   sync.Mutex
+	msgs []*OutboxMsg
+}
+
+// NewOutboxMsgRepository creates an empty in-memory outbox message repository.
+func NewOutboxMsgRepository() *OutboxMsgRepository {
+	return &OutboxMsgRepository{}
+}
+
+func (r *OutboxMsgRepository) WithDB(db.DB) repository.OutboxMsgRepository {
+	return r
+}
+
+func (r *OutboxMsgRepository) CreateOutboxMsg(_ context.Context, params repository.CreateOutboxMsgParams) error {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("generate uuid v7: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.msgs = append(r.msgs, &OutboxMsg{
+		ID:           id,
+		Topic:        params.Topic,
+		Headers:      maps.Clone(params.Headers),
+		Payload:      slices.Clone(params.Payload),
+		PartitionKey: params.PartitionKey,
+		CreatedAt:    time.Now(),
+	})
+
+	return nil
+}
+
+func (r *OutboxMsgRepository) ListUnprocessedOutboxMsgs(
+	_ context.Context,
+	params repository.ListUnprocessedOutboxMsgsParams,
+) ([]repository.ListUnprocessedOutboxMsgsResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	results := make([]repository.ListUnprocessedOutboxMsgsResult, 0, params.BatchSize)
+	// partition keys with a message waiting for its retry, their later messages wait too
+	retrying := map[string]struct{}{}
+	for _, msg := range r.msgs {
+		if len(results) == int(params.BatchSize) {
+			break
+		}
+		if msg.ProcessedAt != nil {
+			continue
+		}
+		if msg.NextAttemptAt != nil && msg.NextAttemptAt.After(now) {
+			if msg.PartitionKey != nil {
+				retrying[*msg.PartitionKey] = struct{}{}
+			}
+			continue
+		}
+		if msg.PartitionKey != nil {
+			if _, ok := retrying[*msg.PartitionKey]; ok {
+				continue
+			}
+		}
+
+		results = append(results, repository.ListUnprocessedOutboxMsgsResult{
+			ID:           msg.ID,
+			Topic:        msg.Topic,
+			Headers:      maps.Clone(msg.Headers),
+			Payload:      slices.Clone(msg.Payload),
+			PartitionKey: msg.PartitionKey,
+			CreatedAt:    msg.CreatedAt,
+			Attempts:     msg.Attempts,
+		})
+	}
+
+	return results, nil
+}
+
+func (r *OutboxMsgRepository) BulkUpdateOutboxMsgs(_ context.Context, params repository.BulkUpdateOutboxMsgsParams) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, item := range params.Items {
+		for _, msg := range r.msgs {
+			if msg.ID == item.ID {
+				msg.Error = item.Error
+				msg.Attempts++
+				msg.NextAttemptAt = item.RetryAt
+				if item.RetryAt == nil {
+					msg.ProcessedAt = &now
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *OutboxMsgRepository) ListOutboxMsgs(
+	_ context.Context,
+	params repository.ListOutboxMsgsParams,
+) ([]model.OutboxMsg, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := make([]model.OutboxMsg, 0, params.Limit)
+	for _, msg := range r.msgs {
+		if matchesListParams(msg, params) {
+			results = append(results, msg.model())
+		}
+	}
+
+	slices.SortFunc(results, func(a, b model.OutboxMsg) int {
+		return -compareOutboxMsgCursor(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+	})
+	if len(results) > int(params.Limit) {
+		results = results[:params.Limit]
+	}
+
+	return results, nil
+}
+
+func (r *OutboxMsgRepository) GetOutboxMsg(_ context.Context, id uuid.UUID) (model.OutboxMsg, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, msg := range r.msgs {
+		if msg.ID == id {
+			return msg.model(), nil
+		}
+	}
+
+	return model.OutboxMsg{}, apperr.OutboxMsgNotFoundErr
+}
+
+func (r *OutboxMsgRepository) CountOutboxMsgs(context.Context) ([]repository.CountOutboxMsgsResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	type key struct {
+		topic  string
+		status model.OutboxMsgStatus
+	}
+	counts := make(map[key]int64)
+	for _, msg := range r.msgs {
+		counts[key{topic: msg.Topic, status: msg.model().Status()}]++
+	}
+
+	results := make([]repository.CountOutboxMsgsResult, 0, len(counts))
+	for k, count := range counts {
+		results = append(results, repository.CountOutboxMsgsResult{
+			Topic:  k.topic,
+			Status: k.status,
+			Count:  count,
+		})
+	}
+	slices.SortFunc(results, func(a, b repository.CountOutboxMsgsResult) int {
+		return cmp.Or(cmp.Compare(a.Topic, b.Topic), cmp.Compare(a.Status, b.Status))
+	})
+
+	return results, nil
+}
+
+func (r *OutboxMsgRepository) CountMatchingOutboxMsgs(_ context.Context, params repository.MatchOutboxMsgsParams) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return int64(len(r.matching(params))), nil
+}
+
+func (r *OutboxMsgRepository) ResetOutboxMsgs(_ context.Context, params repository.MatchOutboxMsgsParams) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	msgs := r.matching(params)
+	for _, msg := range msgs {
+		msg.ProcessedAt = nil
+		msg.Error = nil
+		msg.Attempts = 0
+		msg.NextAttemptAt = nil
+	}
+
+	return int64(len(msgs)), nil
+}
+
+func (r *OutboxMsgRepository) CopyOutboxMsgs(_ context.Context, params repository.MatchOutboxMsgsParams) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	msgs := r.matching(params)
+	slices.SortFunc(msgs, func(a, b *OutboxMsg) int {
+		return compareOutboxMsgCursor(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+	})
+
+	now := time.Now()
+	for i, msg := range msgs {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return 0, fmt.Errorf("generate uuid v7: %w", err)
+		}
+
+		r.msgs = append(r.msgs, &OutboxMsg{
+			ID:           id,
+			Topic:        msg.Topic,
+			Headers:      maps.Clone(msg.Headers),
+			Payload:      slices.Clone(msg.Payload),
+			PartitionKey: msg.PartitionKey,
+			CreatedAt:    now.Add(time.Duration(i+1) * time.Microsecond),
+		})
+	}
+
+	return int64(len(msgs)), nil
+}
+
+func (r *OutboxMsgRepository) DeleteOutboxMsgs(_ context.Context, params repository.MatchOutboxMsgsParams) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	msgs := r.matching(params)
+	r.msgs = slices.DeleteFunc(r.msgs, func(msg *OutboxMsg) bool {
+		return slices.Contains(msgs, msg)
+	})
+
+	return int64(len(msgs)), nil
+}
+
+func (r *OutboxMsgRepository) ListOutboxBacklog(context.Context) ([]repository.OutboxBacklogResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	backlog := make(map[string]*repository.OutboxBacklogResult)
+	for _, msg := range r.msgs {
+		if msg.ProcessedAt != nil {
+			continue
+		}
+
+		result, ok := backlog[msg.Topic]
+		if !ok {
+			result = &repository.OutboxBacklogResult{Topic: msg.Topic, OldestCreatedAt: msg.CreatedAt}
+			backlog[msg.Topic] = result
+		}
+		result.Count++
+		if msg.CreatedAt.Before(result.OldestCreatedAt) {
+			result.OldestCreatedAt = msg.CreatedAt
+		}
+	}
+
+	results := make([]repository.OutboxBacklogResult, 0, len(backlog))
+	for _, result := range backlog {
+		results = append(results, *result)
+	}
+	slices.SortFunc(results, func(a, b repository.OutboxBacklogResult) int {
+		return cmp.Compare(a.Topic, b.Topic)
+	})
+
+	return results, nil
+}
+
+func (r *OutboxMsgRepository) ListRelayedOutboxMsgs(
+	_ context.Context,
+	params repository.ListRelayedOutboxMsgsParams,
+) ([]model.OutboxMsg, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := make([]model.OutboxMsg, 0, params.Limit)
+	for _, msg := range r.msgs {
+		switch {
+		case msg.ProcessedAt == nil,
+			compareOutboxMsgCursor(*msg.ProcessedAt, msg.ID, params.ProcessedAfter, params.AfterID) <= 0,
+			params.Topic != nil && msg.Topic != *params.Topic:
+			continue
+		}
+		results = append(results, msg.model())
+	}
+
+	slices.SortFunc(results, func(a, b model.OutboxMsg) int {
+		return compareOutboxMsgCursor(*a.ProcessedAt, a.ID, *b.ProcessedAt, b.ID)
+	})
+	if len(results) > int(params.Limit) {
+		results = results[:params.Limit]
+	}
+
+	return results, nil
+}
+
+// matching returns the stored messages matching the params, in insertion order.
+func (r *OutboxMsgRepository) matching(params repository.MatchOutboxMsgsParams) []*OutboxMsg {
+	var msgs []*OutboxMsg
+	for _, msg := range r.msgs {
+		switch {
+		case msg.ProcessedAt == nil,
+			(msg.Error != nil) != params.Failed,
+			params.IDs != nil && !slices.Contains(params.IDs, msg.ID),
+			params.Topic != nil && msg.Topic != *params.Topic,
+			params.PartitionKey != nil && (msg.PartitionKey == nil || *msg.PartitionKey != *params.PartitionKey),
+			params.CreatedAfter != nil && msg.CreatedAt.Before(*params.CreatedAfter),
+			params.CreatedBefore != nil && !msg.CreatedAt.Before(*params.CreatedBefore),
+			params.ErrorContains != nil && (msg.Error == nil || !strings.Contains(*msg.Error, *params.ErrorContains)):
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+
+	return msgs
+}
+
+// Add stores the message as is, letting tests set up processed and failed messages.
+// A zero ID or CreatedAt is generated.
+func (r *OutboxMsgRepository) Add(msg OutboxMsg) (OutboxMsg, error) {
+	if msg.ID == uuid.Nil {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return OutboxMsg{}, fmt.Errorf("generate uuid v7: %w", err)
+		}
+		msg.ID = id
+	}
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := msg
+	r.msgs = append(r.msgs, &stored)
+
+	return msg, nil
+}
+
+// Messages returns a snapshot of the stored messages, in insertion order.
+func (r *OutboxMsgRepository) Messages() []OutboxMsg {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	msgs := make([]OutboxMsg, len(r.msgs))
+	for i, msg := range r.msgs {
+		msgs[i] = *msg
+	}
+
+	return msgs
+}
+
+func (m *OutboxMsg) model() model.OutboxMsg {
+	return model.OutboxMsg{
+		ID:            m.ID,
+		Topic:         m.Topic,
+		Headers:       maps.Clone(m.Headers),
+		Payload:       slices.Clone(m.Payload),
+		PartitionKey:  m.PartitionKey,
+		CreatedAt:     m.CreatedAt,
+		ProcessedAt:   m.ProcessedAt,
+		Error:         m.Error,
+		Attempts:      m.Attempts,
+		NextAttemptAt: m.NextAttemptAt,
+	}
+}
+
+func matchesListParams(msg *OutboxMsg, params repository.ListOutboxMsgsParams) bool {
+	switch {
+	case params.Topic != nil && msg.Topic != *params.Topic,
+		params.Status != nil && msg.model().Status() != *params.Status,
+		params.CreatedAfter != nil && msg.CreatedAt.Before(*params.CreatedAfter),
+		params.CreatedBefore != nil && !msg.CreatedAt.Before(*params.CreatedBefore),
+		params.ErrorContains != nil && (msg.Error == nil || !strings.Contains(*msg.Error, *params.ErrorContains)),
+		params.After != nil && compareOutboxMsgCursor(msg.CreatedAt, msg.ID, params.After.CreatedAt, params.After.ID) >= 0:
+		return false
+	default:
+		return true
+	}
+}
+
+// compareOutboxMsgCursor orders messages by a time then ID, like the outbox_messages indexes.
+func compareOutboxMsgCursor(createdAtA time.Time, idA uuid.UUID, createdAtB time.Time, idB uuid.UUID) int {
+	return cmp.Or(createdAtA.Compare(createdAtB), bytes.Compare(idA[:], idB[:]))
+}
@@ -0,0 +1,43 @@
+package fake
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/model"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/repository"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/db"
+)
+
+var _ repository.ProductRepository = (*ProductRepository)(nil)
+
+// ProductRepository is an in-memory repository.ProductRepository.
+type ProductRepository struct {
+	mu       sync.Mutex
+	products []model.Product
+}
+
+// NewProductRepository creates an empty in-memory product repository.
+func NewProductRepository() *ProductRepository {
+	return &ProductRepository{}
+}
+
+func (r *ProductRepository) WithDB(db.DB) repository.ProductRepository {
+	return r
+}
+
+func (r *ProductRepository) CreateProduct(_ context.Context, product model.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.products = append(r.products, product)
+	return nil
+}
+
+func (r *ProductRepository) ListAllProducts(context.Context) ([]model.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.products), nil
+}
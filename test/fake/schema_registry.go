@@ -0,0 +1,180 @@
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq/schemaregistry"
+)
+
+var (
+	_ schemaregistry.Registry = (*SchemaRegistry)(nil)
+	_ http.Handler            = (*SchemaRegistry)(nil)
+)
+
+// SchemaRegistry is an in-memory schema registry.
+//
+// It is used directly as a schemaregistry.Registry, or served over HTTP with httptest to back a
+// schemaregistry.Client, implementing the subset of the Confluent REST API the client uses.
+// Like the real registry, ids are global and a schema registered twice keeps its id.
+// Every schema is compatible unless its subject was made incompatible with SetIncompatible.
+type SchemaRegistry struct {
+	mu           sync.Mutex
+	schemas      []schemaregistry.Schema
+	subjects     map[string][]int
+	incompatible map[string]struct{}
+}
+
+// NewSchemaRegistry creates an empty in-memory schema registry.
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{
+		subjects:     make(map[string][]int),
+		incompatible: make(map[string]struct{}),
+	}
+}
+
+// SetIncompatible makes new schemas incompatible with the latest version of the subject.
+func (r *SchemaRegistry) SetIncompatible(subject string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.incompatible[subject] = struct{}{}
+}
+
+// Versions returns the ids of the schemas registered under the subject, oldest first.
+func (r *SchemaRegistry) Versions(subject string) []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]int(nil), r.subjects[subject]...)
+}
+
+func (r *SchemaRegistry) Register(_ context.Context, subject string, schema schemaregistry.Schema) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := 0
+	for i, s := range r.schemas {
+		if s == schema {
+			id = i + 1
+			break
+		}
+	}
+	if id == 0 {
+		r.schemas = append(r.schemas, schema)
+		id = len(r.schemas)
+	}
+
+	for _, v := range r.subjects[subject] {
+		if v == id {
+			return id, nil
+		}
+	}
+	r.subjects[subject] = append(r.subjects[subject], id)
+
+	return id, nil
+}
+
+func (r *SchemaRegistry) SchemaByID(_ context.Context, id int) (schemaregistry.Schema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if id < 1 || id > len(r.schemas) {
+		return schemaregistry.Schema{}, &schemaregistry.APIError{
+			StatusCode: http.StatusNotFound,
+			Code:       schemaregistry.ErrorCodeSchemaNotFound,
+			Message:    "Schema " + strconv.Itoa(id) + " not found",
+		}
+	}
+
+	return r.schemas[id-1], nil
+}
+
+func (r *SchemaRegistry) Compatible(_ context.Context, subject string, schema schemaregistry.Schema) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions := r.subjects[subject]
+	if len(versions) == 0 || r.schemas[versions[len(versions)-1]-1] == schema {
+		return true, nil
+	}
+
+	_, incompatible := r.incompatible[subject]
+	return !incompatible, nil
+}
+
+// ServeHTTP serves registering schemas, looking them up by id and checking compatibility.
+func (r *SchemaRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	path := strings.Trim(req.URL.Path, "/")
+	parts := strings.Split(path, "/")
+
+	switch {
+	case req.Method == http.MethodPost && len(parts) == 3 && parts[0] == "subjects" && parts[2] == "versions":
+		schema, ok := decodeSchema(w, req)
+		if !ok {
+			return
+		}
+		id, _ := r.Register(req.Context(), parts[1], schema)
+		writeJSON(w, http.StatusOK, map[string]int{"id": id})
+
+	case req.Method == http.MethodGet && len(parts) == 3 && parts[0] == "schemas" && parts[1] == "ids":
+		id, err := strconv.Atoi(parts[2])
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, schemaregistry.APIError{Code: schemaregistry.ErrorCodeSchemaNotFound, Message: "Schema not found"})
+			return
+		}
+		schema, err := r.SchemaByID(req.Context(), id)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, err)
+			return
+		}
+		resp := map[string]string{"schema": schema.Schema}
+		if schema.Type != schemaregistry.SchemaTypeAvro {
+			resp["schemaType"] = string(schema.Type)
+		}
+		writeJSON(w, http.StatusOK, resp)
+
+	case req.Method == http.MethodPost && len(parts) == 5 && parts[0] == "compatibility" && parts[1] == "subjects" && parts[3] == "versions":
+		schema, ok := decodeSchema(w, req)
+		if !ok {
+			return
+		}
+		if len(r.Versions(parts[2])) == 0 {
+			writeJSON(w, http.StatusNotFound, schemaregistry.APIError{Code: schemaregistry.ErrorCodeSubjectNotFound, Message: "Subject not found"})
+			return
+		}
+		compatible, _ := r.Compatible(req.Context(), parts[2], schema)
+		writeJSON(w, http.StatusOK, map[string]bool{"is_compatible": compatible})
+
+	default:
+		writeJSON(w, http.StatusNotFound, schemaregistry.APIError{Code: http.StatusNotFound, Message: "HTTP 404 Not Found"})
+	}
+}
+
+func decodeSchema(w http.ResponseWriter, req *http.Request) (schemaregistry.Schema, bool) {
+	var body struct {
+		Schema     string `json:"schema"`
+		SchemaType string `json:"schemaType"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, schemaregistry.APIError{Code: 42201, Message: "Invalid schema"})
+		return schemaregistry.Schema{}, false
+	}
+
+	schemaType := schemaregistry.SchemaType(body.SchemaType)
+	if schemaType == "" {
+		schemaType = schemaregistry.SchemaTypeAvro
+	}
+	return schemaregistry.Schema{Type: schemaType, Schema: body.Schema}, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	w.WriteHeader(status)
+	//nolint:errcheck
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,102 @@
+package integration_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kfake"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/event"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/relay"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/service"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq"
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/storage/mq/middleware"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/correlationid"
+	"github.com/tuanvumaihuynh/outbox-pattern/pkg/outbox"
+	"github.com/tuanvumaihuynh/outbox-pattern/test/fake"
+	"github.com/tuanvumaihuynh/outbox-pattern/test/kafkatest"
+)
+
+func TestRelayKafkaPipeline(t *testing.T) {
+	t.Run("Should deliver created products to consumers through the outbox", func(t *testing.T) {
+		ctx := context.Background()
+		logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+		_, kafkaCfg := kafkatest.NewCluster(t, kfake.SeedTopics(3, event.TopicProductCreated))
+
+		dbClient := fake.NewDB()
+		outboxMsgRepo := fake.NewOutboxMsgRepository()
+		productSvc := service.NewProductService(dbClient, fake.NewProductRepository(), outboxMsgRepo)
+
+		producer, err := mq.NewKafkaProducer(ctx, kafkaCfg)
+		require.NoError(t, err)
+		defer producer.Close()
+
+		consumer, err := mq.NewKafkaConsumer(ctx, kafkaCfg, logger)
+		require.NoError(t, err)
+		consumer.Use(middleware.Recoverer(logger), middleware.CorrelationID())
+
+		var mu sync.Mutex
+		received := map[string]string{}
+		err = mq.RegisterEvent(consumer, event.TopicProductCreated, event.EventTypeProductCreated, func(ctx context.Context, ev event.ProductCreatedEvent, md mq.Metadata) error {
+			if md.Headers[outbox.MessageIDHeader] == "" {
+				return errors.New("missing outbox message id")
+			}
+
+			correlationID, _ := correlationid.FromContext(ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			received[ev.Sku] = correlationID
+			return nil
+		})
+		require.NoError(t, err)
+
+		consumerCleanup, err := consumer.Run(ctx)
+		require.NoError(t, err)
+		defer consumerCleanup()
+
+		relaySvc, err := relay.NewService(config.Relay{
+			BatchSize:       10,
+			Interval:        10 * time.Millisecond,
+			ShutdownTimeout: time.Second,
+		}, logger, dbClient, outboxMsgRepo, producer)
+		require.NoError(t, err)
+		relayCleanup := relaySvc.Run(ctx)
+		defer relayCleanup()
+
+		for _, sku := range []string{"SKU1", "SKU2", "SKU3"} {
+			_, err := productSvc.CreateProduct(correlationid.NewContext(ctx, "correlation-"+sku), service.CreateProductParams{
+				Name:          "Product " + sku,
+				Sku:           sku,
+				Price:         10,
+				StockQuantity: 1,
+			})
+			require.NoError(t, err)
+		}
+
+		require.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(received) == 3
+		}, 20*time.Second, 10*time.Millisecond)
+
+		assert.Equal(t, map[string]string{
+			"SKU1": "correlation-SKU1",
+			"SKU2": "correlation-SKU2",
+			"SKU3": "correlation-SKU3",
+		}, received)
+
+		for _, msg := range outboxMsgRepo.Messages() {
+			assert.NotNil(t, msg.ProcessedAt)
+			assert.Nil(t, msg.Error)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+// Package kafkatest provides an in-process Kafka cluster for tests, backed by franz-go's kfake.
+package kafkatest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/tuanvumaihuynh/outbox-pattern/internal/config"
+)
+
+// NewCluster starts an in-process Kafka cluster closed at the end of the test and returns
+// a config.Kafka pointing at it, with a consumer group unique to the test.
+//
+// Topics are auto-created by default and the config lets clients auto-create them,
+// opts can seed topics or tune the cluster.
+func NewCluster(t testing.TB, opts ...kfake.Opt) (*kfake.Cluster, config.Kafka) {
+	t.Helper()
+
+	opts = append([]kfake.Opt{
+		kfake.NumBrokers(1),
+		kfake.AllowAutoTopicCreation(),
+	}, opts...)
+
+	cluster, err := kfake.NewCluster(opts...)
+	require.NoError(t, err)
+	t.Cleanup(cluster.Close)
+
+	return cluster, config.Kafka{
+		Addresses:        cluster.ListenAddrs(),
+		Group:            GroupName(t),
+		TopicsAutoCreate: true,
+	}
+}
+
+// GroupName returns a consumer group name derived from the test name.
+func GroupName(t testing.TB) string {
+	return "group-" + strings.NewReplacer("/", "-", " ", "-").Replace(t.Name())
+}
+
+// NewClient creates a raw client connected to the cluster and closed at the end of the test.
+func NewClient(t testing.TB, cfg config.Kafka, opts ...kgo.Opt) *kgo.Client {
+	t.Helper()
+
+	opts = append([]kgo.Opt{kgo.SeedBrokers(cfg.Addresses...)}, opts...)
+	cl, err := kgo.NewClient(opts...)
+	require.NoError(t, err)
+	t.Cleanup(cl.Close)
+
+	return cl
+}
+
+// Produce synchronously produces the records with a raw client.
+func Produce(t testing.TB, cfg config.Kafka, records ...*kgo.Record) {
+	t.Helper()
+
+	cl := NewClient(t, cfg, kgo.AllowAutoTopicCreation())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	require.NoError(t, cl.ProduceSync(ctx, records...).FirstErr())
+}
+
+// Consume reads n records of the topic from the start with a raw client, failing the test
+// if they are not received within the timeout.
+func Consume(t testing.TB, cfg config.Kafka, topic string, n int, timeout time.Duration) []*kgo.Record {
+	t.Helper()
+
+	cl := NewClient(t, cfg,
+		kgo.ConsumeTopics(topic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	records := make([]*kgo.Record, 0, n)
+	for len(records) < n {
+		fetches := cl.PollFetches(ctx)
+		if ctx.Err() != nil {
+			require.FailNowf(t, "consume timed out", "received %d of %d records from %s", len(records), n, topic)
+		}
+		require.NoError(t, fetches.Err())
+		records = append(records, fetches.Records()...)
+	}
+
+	return records
+}